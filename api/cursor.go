@@ -0,0 +1,43 @@
+package api
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+)
+
+// historyCursor is the position in the confirmed transaction history of an address or an account
+// the history is ordered from the newest block, index is the number of the transactions of the block at height
+// which precede the position, the transactions are counted after the application of the filter
+// the position does not change when new blocks or mempool transactions arrive
+type historyCursor struct {
+	height uint32
+	index  uint32
+}
+
+// String returns the opaque form of the cursor passed to the clients
+func (c *historyCursor) String() string {
+	buf := make([]byte, 8)
+	binary.BigEndian.PutUint32(buf, c.height)
+	binary.BigEndian.PutUint32(buf[4:], c.index)
+	return base64.RawURLEncoding.EncodeToString(buf)
+}
+
+// parseHistoryCursor parses the cursor returned in Paging.NextCursor, returns nil if the cursor is empty
+func parseHistoryCursor(s string) (*historyCursor, error) {
+	if s == "" {
+		return nil, nil
+	}
+	buf, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil || len(buf) != 8 {
+		return nil, NewAPIError("Invalid cursor", true)
+	}
+	return &historyCursor{
+		height: binary.BigEndian.Uint32(buf),
+		index:  binary.BigEndian.Uint32(buf[4:]),
+	}, nil
+}
+
+// after returns true if the transaction at the position of height and index (counted from 1) follows the cursor
+func (c *historyCursor) after(height, index uint32) bool {
+	return height < c.height || height == c.height && index > c.index
+}
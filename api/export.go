@@ -0,0 +1,243 @@
+package api
+
+import (
+	"blockbook/bchain"
+	"blockbook/db"
+	"math/big"
+	"sort"
+	"time"
+
+	"github.com/golang/glog"
+	"github.com/juju/errors"
+)
+
+// export of the transaction history
+// the confirmed transactions of an address or an account are passed one by one to the callback
+// from the newest to the oldest, the running balance is computed backwards from the current balance
+
+// ExportEntry is a row of the exported transaction history
+type ExportEntry struct {
+	Txid   string `json:"txid"`
+	Time   uint32 `json:"time"`
+	Height uint32 `json:"height"`
+	// Direction is received or sent according to the change of the balance,
+	// self if all outputs of the tx funded by the account return to the account
+	Direction string `json:"direction"`
+	// AmountSat is the change of the balance caused by the tx, negative for the sent txs, fee included
+	AmountSat *Amount `json:"amount"`
+	// FeeSat is the fee of the tx if it was paid by the account
+	FeeSat *Amount `json:"fee,omitempty"`
+	// BalanceSat is the balance after the tx
+	BalanceSat *Amount `json:"balance"`
+	FiatRate   string  `json:"fiatRate,omitempty"`
+	FiatValue  string  `json:"fiatValue,omitempty"`
+}
+
+// ExportCallback is called by ExportHistory for each exported tx
+type ExportCallback func(entry *ExportEntry) error
+
+// ExportDirection values
+const (
+	ExportDirectionReceived = "received"
+	ExportDirectionSent     = "sent"
+	ExportDirectionSelf     = "self"
+)
+
+type historyExport struct {
+	w          *Worker
+	account    map[string]struct{}
+	balance    big.Int
+	fromHeight uint32
+	toHeight   uint32
+	fiat       string
+	fn         ExportCallback
+	count      int
+}
+
+// isAccountAddrDesc checks if the address descriptor belongs to the account, cold staking outputs belong both to the owner and to the staker
+func (e *historyExport) isAccountAddrDesc(addrDesc bchain.AddressDescriptor) bool {
+	if _, found := e.account[string(addrDesc)]; found {
+		return true
+	}
+	owner, staker := e.w.chainParser.GetColdStakingAddrDescs(addrDesc)
+	if owner == nil {
+		return false
+	}
+	_, foundOwner := e.account[string(owner)]
+	_, foundStaker := e.account[string(staker)]
+	return foundOwner || foundStaker
+}
+
+// exportTxid computes the row of the tx and passes it to the callback if the tx is in the exported range
+// the txs must be processed from the newest to the oldest to update the running balance
+func (e *historyExport) exportTxid(txid string, height uint32) error {
+	ta, err := e.w.getTxAddresses(txid)
+	if err != nil {
+		return err
+	}
+	if ta == nil {
+		glog.Warning("DB inconsistency:  tx ", txid, ": not found in txAddresses")
+		return nil
+	}
+	var sent, received, valueIn, valueOut big.Int
+	for i := range ta.Inputs {
+		tai := &ta.Inputs[i]
+		valueIn.Add(&valueIn, &tai.ValueSat)
+		if e.isAccountAddrDesc(tai.AddrDesc) {
+			sent.Add(&sent, &tai.ValueSat)
+		}
+	}
+	for i := range ta.Outputs {
+		tao := &ta.Outputs[i]
+		valueOut.Add(&valueOut, &tao.ValueSat)
+		if e.isAccountAddrDesc(tao.AddrDesc) {
+			received.Add(&received, &tao.ValueSat)
+		}
+	}
+	var amount big.Int
+	amount.Sub(&received, &sent)
+	bt, err := e.w.db.GetBlockTime(height)
+	if err != nil {
+		return err
+	}
+	entry := ExportEntry{
+		Txid:       txid,
+		Time:       bt,
+		Height:     height,
+		AmountSat:  (*Amount)(&amount),
+		BalanceSat: (*Amount)(new(big.Int).Set(&e.balance)),
+	}
+	// the balance before the tx is the balance of the next older tx
+	e.balance.Sub(&e.balance, &amount)
+	// the range of times is checked by the heights of the blocks, the block times are not monotonic
+	if height < e.fromHeight || height >= e.toHeight {
+		return nil
+	}
+	if sent.Sign() > 0 {
+		// coinbase and coinstake txs do not have any fee
+		var fee big.Int
+		if fee.Sub(&valueIn, &valueOut); fee.Sign() > 0 {
+			entry.FeeSat = (*Amount)(&fee)
+		}
+	}
+	if sent.Sign() > 0 && received.Cmp(&valueOut) == 0 && amount.Sign() <= 0 {
+		entry.Direction = ExportDirectionSelf
+	} else if sent.Sign() == 0 || amount.Sign() > 0 {
+		entry.Direction = ExportDirectionReceived
+	} else {
+		entry.Direction = ExportDirectionSent
+	}
+	if e.fiat != "" {
+		e.setFiatValue(&entry)
+	}
+	e.count++
+	return e.fn(&entry)
+}
+
+// setFiatValue sets the fiat rate valid at the time of the tx and the value of the amount in the fiat currency
+func (e *historyExport) setFiatValue(entry *ExportEntry) {
+	t := time.Unix(int64(entry.Time), 0)
+	ticker, err := e.w.db.FiatRatesFindTicker(&t)
+	if err != nil {
+		glog.Errorf("Error finding ticker by date %v. Error: %v", t, err)
+		return
+	} else if ticker == nil {
+		return
+	}
+	rate, found := ticker.Rates[e.fiat]
+	if !found {
+		return
+	}
+	entry.FiatRate = string(rate)
+	entry.FiatValue = fiatAmount((*big.Int)(entry.AmountSat), rate, e.w.chainParser.AmountDecimals())
+}
+
+// ExportHistory passes the confirmed transactions of the address, xpub or output descriptor in the time range
+// to the callback, from the newest to the oldest, the transactions are not collected in memory
+func (w *Worker) ExportHistory(desc string, fromTime, toTime time.Time, fiat string, gap int, fn ExportCallback) error {
+	start := time.Now()
+	if w.chainType != bchain.ChainBitcoinType {
+		return NewAPIError("Export of the history is supported only for Bitcoin type coins", true)
+	}
+	fromHeight, toHeight, err := w.balanceHistoryHeightsFromTo(fromTime, toTime)
+	if err != nil {
+		return err
+	}
+	e := historyExport{
+		w:          w,
+		account:    make(map[string]struct{}),
+		fromHeight: fromHeight,
+		toHeight:   toHeight,
+		fiat:       fiat,
+		fn:         fn,
+	}
+	if fromHeight >= toHeight {
+		return nil
+	}
+	data, _, err := w.getXpubData(desc, 0, 1, AccountDetailsTxidHistory, &AddressFilter{
+		Vout:          AddressFilterVoutOff,
+		OnlyConfirmed: true,
+		FromHeight:    fromHeight,
+	}, gap)
+	if err == nil {
+		if err = e.exportXpubData(data, fromHeight); err != nil {
+			return err
+		}
+		glog.Info("ExportHistory ", desc[:16], ", blocks ", fromHeight, "-", toHeight, ", count ", e.count, " finished in ", time.Since(start))
+		return nil
+	}
+	if IsOutputDescriptor(desc) {
+		return err
+	}
+	addrDesc, address, err := w.getAddrDescAndNormalizeAddress(desc)
+	if err != nil {
+		return err
+	}
+	ba, err := w.db.GetAddrDescBalance(addrDesc, db.AddressBalanceDetailNoUTXO)
+	if err != nil {
+		return errors.Annotatef(err, "GetAddrDescBalance %v", addrDesc)
+	}
+	if ba == nil {
+		return nil
+	}
+	e.account[string(addrDesc)] = struct{}{}
+	e.balance.Set(&ba.BalanceSat)
+	// the newer txs are read too to compute the running balance
+	err = w.db.GetAddrDescTransactions(addrDesc, fromHeight, maxUint32, func(txid string, height uint32, indexes []int32) error {
+		return e.exportTxid(txid, height)
+	})
+	if err != nil {
+		return err
+	}
+	glog.Info("ExportHistory ", address, ", blocks ", fromHeight, "-", toHeight, ", count ", e.count, " finished in ", time.Since(start))
+	return nil
+}
+
+// exportXpubData exports the merged txs of the addresses of the account from the newest to the block fromHeight
+func (e *historyExport) exportXpubData(data *xpubData, fromHeight uint32) error {
+	txc := make(xpubTxids, 0, 32)
+	txcMap := make(map[string]struct{})
+	for _, da := range [][]xpubAddress{data.addresses, data.changeAddresses} {
+		for i := range da {
+			ad := &da[i]
+			e.account[string(ad.addrDesc)] = struct{}{}
+			for _, txid := range ad.txids {
+				if _, found := txcMap[txid.txid]; !found {
+					txcMap[txid.txid] = struct{}{}
+					txc = append(txc, txid)
+				}
+			}
+		}
+	}
+	sort.Stable(txc)
+	e.balance.Set(&data.balanceSat)
+	for i := range txc {
+		if txc[i].height < fromHeight {
+			break
+		}
+		if err := e.exportTxid(txc[i].txid, txc[i].height); err != nil {
+			return err
+		}
+	}
+	return nil
+}
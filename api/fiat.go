@@ -0,0 +1,169 @@
+package api
+
+import (
+	"blockbook/bchain"
+	"blockbook/db"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/juju/errors"
+)
+
+// fiatValues computes the values of the transactions and of the balances in a fiat currency,
+// the rates are loaded from the stored tickers, the tickers found by time are cached for the duration of a request
+type fiatValues struct {
+	w        *Worker
+	currency string
+	last     *db.CurrencyRatesTicker
+	tickers  map[int64]*db.CurrencyRatesTicker
+}
+
+func (w *Worker) newFiatValues(currency string) (*fiatValues, error) {
+	f := &fiatValues{
+		w:        w,
+		currency: strings.ToLower(currency),
+		tickers:  make(map[int64]*db.CurrencyRatesTicker),
+	}
+	var err error
+	if f.last, err = w.db.FiatRatesFindLastTicker(); err != nil {
+		return nil, errors.Annotatef(err, "FiatRatesFindLastTicker")
+	}
+	if f.last == nil {
+		return nil, NewAPIError("No fiat rates available", true)
+	}
+	if _, found := f.last.Rates[f.currency]; !found {
+		return nil, NewAPIError(fmt.Sprintf("Unsupported currency %v", currency), true)
+	}
+	return f, nil
+}
+
+// rateOfTime returns the rate of the first ticker at or after the time, the last rate if there is no such ticker
+func (f *fiatValues) rateOfTime(unix int64) (json.Number, error) {
+	ticker, found := f.tickers[unix]
+	if !found {
+		t := time.Unix(unix, 0)
+		var err error
+		if ticker, err = f.w.db.FiatRatesFindTicker(&t); err != nil {
+			return "", errors.Annotatef(err, "FiatRatesFindTicker %v", t)
+		}
+		if ticker == nil {
+			ticker = f.last
+		}
+		f.tickers[unix] = ticker
+	}
+	return ticker.Rates[f.currency], nil
+}
+
+// fiatAmount returns the amount in the lowest denomination multiplied by the rate, with two decimal places
+func fiatAmount(a *big.Int, rate json.Number, decimals int) string {
+	if a == nil || rate == "" {
+		return ""
+	}
+	r, ok := new(big.Float).SetString(string(rate))
+	if !ok {
+		return ""
+	}
+	v, ok := new(big.Float).SetString(bchain.AmountToDecimalString(a, decimals))
+	if !ok {
+		return ""
+	}
+	return v.Mul(v, r).Text('f', 2)
+}
+
+// setTxFiatValues sets the values of the tx at the rate of the time of the block, the rate of the unconfirmed tx is the last rate
+// the amount relative to the addresses is set only if the addresses are not nil
+func (f *fiatValues) setTxFiatValues(tx *Tx, addresses map[string]struct{}) error {
+	var rate json.Number
+	if tx.Confirmations == 0 {
+		rate = f.last.Rates[f.currency]
+	} else {
+		var err error
+		if rate, err = f.rateOfTime(tx.Blocktime); err != nil {
+			return err
+		}
+	}
+	if rate == "" {
+		return nil
+	}
+	d := f.w.chainParser.AmountDecimals()
+	tv := &TxFiatValues{
+		Currency: f.currency,
+		Rate:     string(rate),
+		Value:    fiatAmount((*big.Int)(tx.ValueOutSat), rate, d),
+		Fees:     fiatAmount((*big.Int)(tx.FeesSat), rate, d),
+	}
+	if addresses != nil {
+		tv.Amount = fiatAmount(tx.addressesAmount(addresses), rate, d)
+	}
+	tx.FiatValues = tv
+	return nil
+}
+
+// addressesAmount returns the value of the outputs minus the value of the inputs of the addresses in the tx
+func (t *Tx) addressesAmount(addresses map[string]struct{}) *big.Int {
+	var amount big.Int
+	isOfAddresses := func(aa []string) bool {
+		for _, a := range aa {
+			if _, found := addresses[a]; found {
+				return true
+			}
+		}
+		return false
+	}
+	for i := range t.Vout {
+		if t.Vout[i].ValueSat != nil && isOfAddresses(t.Vout[i].Addresses) {
+			amount.Add(&amount, (*big.Int)(t.Vout[i].ValueSat))
+		}
+	}
+	for i := range t.Vin {
+		if t.Vin[i].ValueSat != nil && isOfAddresses(t.Vin[i].Addresses) {
+			amount.Sub(&amount, (*big.Int)(t.Vin[i].ValueSat))
+		}
+	}
+	return &amount
+}
+
+// SetTxFiatValues sets the values of the tx in the currency at the time of the block
+func (w *Worker) SetTxFiatValues(tx *Tx, currency string) error {
+	f, err := w.newFiatValues(currency)
+	if err != nil {
+		return err
+	}
+	return f.setTxFiatValues(tx, nil)
+}
+
+// SetAddressFiatValues sets the current value of the balance of the address, xpub or account in the currency
+// and the values of its transactions, including the amount relative to the address, at the time of the block
+func (w *Worker) SetAddressFiatValues(address *Address, currency string) error {
+	f, err := w.newFiatValues(currency)
+	if err != nil {
+		return err
+	}
+	rate := f.last.Rates[f.currency]
+	d := w.chainParser.AmountDecimals()
+	address.FiatValues = &AddressFiatValues{
+		Currency: f.currency,
+		Rate:     string(rate),
+		Balance:  fiatAmount((*big.Int)(address.BalanceSat), rate, d),
+	}
+	if len(address.Transactions) == 0 {
+		return nil
+	}
+	// the addresses of an xpub are in XPubAddresses, the addresses of an account are separated by commas
+	addresses := address.XPubAddresses
+	if len(addresses) == 0 {
+		addresses = make(map[string]struct{})
+		for _, a := range strings.Split(address.AddrStr, ",") {
+			addresses[a] = struct{}{}
+		}
+	}
+	for _, tx := range address.Transactions {
+		if err = f.setTxFiatValues(tx, addresses); err != nil {
+			return err
+		}
+	}
+	return nil
+}
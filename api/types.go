@@ -131,6 +131,15 @@ type Vout struct {
 	Type        string                   `json:"type,omitempty"`
 }
 
+// ColdStakingVoutType is the type of the pay to cold staking output
+const ColdStakingVoutType = "coldstake"
+
+// MasternodeVoutType is the type of the masternode payment output
+const MasternodeVoutType = "masternode"
+
+// BudgetVoutType is the type of the budget proposal payment output
+const BudgetVoutType = "budget"
+
 // TokenType specifies type of token
 type TokenType string
 
@@ -140,6 +149,9 @@ const ERC20TokenType TokenType = "ERC20"
 // XPUBAddressTokenType is address derived from xpub
 const XPUBAddressTokenType TokenType = "XPUBAddress"
 
+// AddressTokenType is address of an account composed of a list of addresses
+const AddressTokenType TokenType = "Address"
+
 // Token contains info about tokens held by an address
 type Token struct {
 	Type             TokenType `json:"type"`
@@ -176,6 +188,33 @@ type EthereumSpecific struct {
 	GasPrice *Amount  `json:"gasPrice"`
 }
 
+// PivxSpecific contains PIVX specific transaction data
+type PivxSpecific struct {
+	ValueBalanceSat *Amount `json:"valueBalance"`
+	ShieldedSpends  int     `json:"shieldedSpends"`
+	ShieldedOutputs int     `json:"shieldedOutputs"`
+}
+
+// SpecialTx contains decoded payload of the special transaction of the chains with deterministic masternodes (Dash, PIVX)
+type SpecialTx struct {
+	Type            int32   `json:"type"`
+	TypeName        string  `json:"typeName"`
+	ProTxHash       string  `json:"proTxHash,omitempty"`
+	CollateralHash  string  `json:"collateralHash,omitempty"`
+	CollateralIndex *uint32 `json:"collateralIndex,omitempty"`
+	Service         string  `json:"service,omitempty"`
+	OwnerAddress    string  `json:"ownerAddress,omitempty"`
+	VotingAddress   string  `json:"votingAddress,omitempty"`
+	PayoutAddress   string  `json:"payoutAddress,omitempty"`
+}
+
+// StakeReward contains the split of the coinstake transaction reward
+type StakeReward struct {
+	StakerSat     *Amount `json:"staker"`
+	MasternodeSat *Amount `json:"masternode,omitempty"`
+	BudgetSat     *Amount `json:"budget,omitempty"`
+}
+
 // Tx holds information about a transaction
 type Tx struct {
 	Txid             string            `json:"txid"`
@@ -193,10 +232,32 @@ type Tx struct {
 	FeesSat          *Amount           `json:"fees,omitempty"`
 	Hex              string            `json:"hex,omitempty"`
 	Rbf              bool              `json:"rbf,omitempty"`
+	IsCoinstake      bool              `json:"isCoinstake,omitempty"`
+	StakeReward      *StakeReward      `json:"stakeReward,omitempty"`
+	FiatValues       *TxFiatValues     `json:"fiatValues,omitempty"`
 	CoinSpecificData interface{}       `json:"-"`
 	CoinSpecificJSON json.RawMessage   `json:"-"`
 	TokenTransfers   []TokenTransfer   `json:"tokenTransfers,omitempty"`
 	EthereumSpecific *EthereumSpecific `json:"ethereumSpecific,omitempty"`
+	PivxSpecific     *PivxSpecific     `json:"pivxSpecific,omitempty"`
+	SpecialTx        *SpecialTx        `json:"specialTx,omitempty"`
+}
+
+// TxFiatValues contains the values of a tx in a fiat currency at the rate of the time of the block
+type TxFiatValues struct {
+	Currency string `json:"currency"`
+	Rate     string `json:"rate"`
+	Value    string `json:"value"`
+	Fees     string `json:"fees,omitempty"`
+	// Amount is the value of the outputs minus the value of the inputs of the requested address or xpub
+	Amount string `json:"amount,omitempty"`
+}
+
+// AddressFiatValues contains the value of the balance of an address or xpub in a fiat currency at the last rate
+type AddressFiatValues struct {
+	Currency string `json:"currency"`
+	Rate     string `json:"rate"`
+	Balance  string `json:"balance"`
 }
 
 // FeeStats contains detailed block fee statistics
@@ -212,6 +273,8 @@ type Paging struct {
 	Page        int `json:"page,omitempty"`
 	TotalPages  int `json:"totalPages,omitempty"`
 	ItemsOnPage int `json:"itemsOnPage,omitempty"`
+	// NextCursor is the opaque position in the history after the returned page, it is empty on the last page
+	NextCursor string `json:"nextCursor,omitempty"`
 }
 
 // TokensToReturn specifies what tokens are returned by GetAddress and GetXpubAddress
@@ -242,6 +305,8 @@ type AddressFilter struct {
 	TokensToReturn TokensToReturn
 	// OnlyConfirmed set to true will ignore mempool transactions; mempool is also ignored if FromHeight/ToHeight filter is specified
 	OnlyConfirmed bool
+	// Cursor returned in Paging.NextCursor continues the confirmed transactions after the previous page, page is then ignored
+	Cursor string
 }
 
 // Address holds information about address and its transactions
@@ -249,12 +314,17 @@ type Address struct {
 	Paging
 	AddrStr               string                `json:"address"`
 	BalanceSat            *Amount               `json:"balance"`
+	DelegatedBalanceSat   *Amount               `json:"delegatedBalance,omitempty"`
 	TotalReceivedSat      *Amount               `json:"totalReceived,omitempty"`
 	TotalSentSat          *Amount               `json:"totalSent,omitempty"`
+	StakingRewardsSat     *Amount               `json:"stakingRewards,omitempty"`
 	UnconfirmedBalanceSat *Amount               `json:"unconfirmedBalance"`
 	UnconfirmedTxs        int                   `json:"unconfirmedTxs"`
 	Txs                   int                   `json:"txs"`
 	NonTokenTxs           int                   `json:"nonTokenTxs,omitempty"`
+	FirstSeenHeight       uint32                `json:"firstSeenHeight,omitempty"`
+	LastActivityHeight    uint32                `json:"lastActivityHeight,omitempty"`
+	FiatValues            *AddressFiatValues    `json:"fiatValues,omitempty"`
 	Transactions          []*Tx                 `json:"transactions,omitempty"`
 	Txids                 []string              `json:"txids,omitempty"`
 	Nonce                 string                `json:"nonce,omitempty"`
@@ -266,6 +336,64 @@ type Address struct {
 	XPubAddresses map[string]struct{} `json:"-"`
 }
 
+// Masternode contains summary of the masternode payments to an address
+type Masternode struct {
+	AddrStr           string  `json:"address"`
+	Payments          int     `json:"payments"`
+	LastPaymentHeight uint32  `json:"lastPaymentHeight,omitempty"`
+	TotalEarnedSat    *Amount `json:"totalEarned"`
+}
+
+// ZerocoinDenomination contains state of the zerocoin pool of one denomination
+type ZerocoinDenomination struct {
+	DenominationSat *Amount `json:"denomination"`
+	Mints           uint32  `json:"mints"`
+	Spends          uint32  `json:"spends"`
+	SupplySat       *Amount `json:"supply"`
+}
+
+// Zerocoin contains state of the zerocoin pool at given height
+type Zerocoin struct {
+	Height         uint32                 `json:"height"`
+	LastChange     uint32                 `json:"lastChange,omitempty"`
+	Denominations  []ZerocoinDenomination `json:"denominations"`
+	TotalSupplySat *Amount                `json:"totalSupply"`
+}
+
+// Supply contains the coin supply after the block of given height
+type Supply struct {
+	Height         uint32  `json:"height"`
+	IssuedSat      *Amount `json:"issued"`
+	BurnedSat      *Amount `json:"burned"`
+	CirculatingSat *Amount `json:"circulating"`
+}
+
+// BudgetProposal contains budget proposal paid in a superblock
+type BudgetProposal struct {
+	Name           string  `json:"name"`
+	Hash           string  `json:"hash"`
+	URL            string  `json:"url,omitempty"`
+	PaymentAddress string  `json:"paymentAddress"`
+	Yeas           int     `json:"yeas"`
+	Nays           int     `json:"nays"`
+	Abstains       int     `json:"abstains"`
+	PaymentSat     *Amount `json:"payment"`
+}
+
+// Superblock contains budget proposals paid in a superblock
+type Superblock struct {
+	Height          uint32           `json:"height"`
+	Projection      bool             `json:"projection,omitempty"`
+	Proposals       []BudgetProposal `json:"proposals"`
+	TotalPaymentSat *Amount          `json:"totalPayment"`
+}
+
+// Superblocks contains list of the superblocks
+type Superblocks struct {
+	NextSuperblock uint32       `json:"nextSuperblock"`
+	Superblocks    []Superblock `json:"superblocks"`
+}
+
 // Utxo is one unspent transaction output
 type Utxo struct {
 	Txid          string  `json:"txid"`
@@ -277,6 +405,7 @@ type Utxo struct {
 	Path          string  `json:"path,omitempty"`
 	Locktime      uint32  `json:"lockTime,omitempty"`
 	Coinbase      bool    `json:"coinbase,omitempty"`
+	Delegated     bool    `json:"delegated,omitempty"`
 }
 
 // Utxos is array of Utxo
@@ -299,12 +428,13 @@ func (a Utxos) Less(i, j int) bool {
 
 // BalanceHistory contains info about one point in time of balance history
 type BalanceHistory struct {
-	Time        uint32  `json:"time"`
-	Txs         uint32  `json:"txs"`
-	ReceivedSat *Amount `json:"received"`
-	SentSat     *Amount `json:"sent"`
-	FiatRate    string  `json:"fiatRate,omitempty"`
-	Txid        string  `json:"txid,omitempty"`
+	Time             uint32  `json:"time"`
+	Txs              uint32  `json:"txs"`
+	ReceivedSat      *Amount `json:"received"`
+	SentSat          *Amount `json:"sent"`
+	StakingRewardSat *Amount `json:"stakingReward,omitempty"`
+	FiatRate         string  `json:"fiatRate,omitempty"`
+	Txid             string  `json:"txid,omitempty"`
 }
 
 // BalanceHistories is array of BalanceHistory
@@ -351,6 +481,12 @@ func (a BalanceHistories) SortAndAggregate(groupByTime uint32) BalanceHistories
 			}
 			(*big.Int)(bha.SentSat).Add((*big.Int)(bha.SentSat), (*big.Int)(bh.SentSat))
 			(*big.Int)(bha.ReceivedSat).Add((*big.Int)(bha.ReceivedSat), (*big.Int)(bh.ReceivedSat))
+			if bh.StakingRewardSat != nil {
+				if bha.StakingRewardSat == nil {
+					bha.StakingRewardSat = &Amount{}
+				}
+				(*big.Int)(bha.StakingRewardSat).Add((*big.Int)(bha.StakingRewardSat), (*big.Int)(bh.StakingRewardSat))
+			}
 		}
 		if bha.Txs > 0 {
 			bha.Txid = ""
@@ -360,27 +496,116 @@ func (a BalanceHistories) SortAndAggregate(groupByTime uint32) BalanceHistories
 	return bhs
 }
 
+// Block types of the proof of stake coins
+const (
+	BlockTypePoS = "pos"
+	BlockTypePoW = "pow"
+)
+
+// BlockSummary contains block header data with the type and the producer of the block (proof of stake coins)
+type BlockSummary struct {
+	db.BlockInfo
+	Type   string `json:"type,omitempty"`
+	Staker string `json:"staker,omitempty"`
+}
+
 // Blocks is list of blocks with paging information
 type Blocks struct {
 	Paging
-	Blocks []db.BlockInfo `json:"blocks"`
+	Blocks     []BlockSummary `json:"blocks"`
+	Filter     string         `json:"-"`
+	BlockTypes bool           `json:"-"`
+}
+
+// StakerBlocks contains number of blocks produced by a staker
+type StakerBlocks struct {
+	Address string `json:"address"`
+	Blocks  int    `json:"blocks"`
+}
+
+// Stakers contains number of blocks produced by the stakers in a range of blocks
+type Stakers struct {
+	FromHeight          uint32         `json:"fromHeight"`
+	ToHeight            uint32         `json:"toHeight"`
+	PosBlocks           int            `json:"posBlocks"`
+	PowBlocks           int            `json:"powBlocks"`
+	UnknownStakerBlocks int            `json:"unknownStakerBlocks"`
+	Stakers             []StakerBlocks `json:"stakers"`
+}
+
+// BlockByTime contains the first block with the time greater or equal to the requested time
+type BlockByTime struct {
+	Height uint32 `json:"height"`
+	Hash   string `json:"hash"`
+	Time   int64  `json:"time"`
+}
+
+// BlockFilter contains the basic compact block filter (BIP158) of the block
+type BlockFilter struct {
+	Height uint32 `json:"height"`
+	Hash   string `json:"hash"`
+	Filter string `json:"filter"`
+	Header string `json:"header,omitempty"`
+}
+
+// RichListAddress contains an address from the rich list
+type RichListAddress struct {
+	Rank       int     `json:"rank"`
+	Address    string  `json:"address"`
+	BalanceSat *Amount `json:"balance"`
+	Txs        int     `json:"txs"`
+	// Share is the percentage of the circulating supply held by the address
+	Share float64 `json:"share,omitempty"`
+}
+
+// RichList contains a page of the addresses with the highest balance
+type RichList struct {
+	Paging
+	Height         uint32            `json:"height"`
+	CirculatingSat *Amount           `json:"circulating,omitempty"`
+	Addresses      []RichListAddress `json:"addresses"`
+}
+
+// OpReturnTx contains an output carrying OP_RETURN data
+type OpReturnTx struct {
+	Txid        string `json:"txid"`
+	Vout        int32  `json:"vout"`
+	BlockHeight uint32 `json:"blockHeight"`
+	BlockTime   int64  `json:"blockTime"`
+	Data        string `json:"data"`
+}
+
+// OpReturnTxs contains a page of the outputs with the OP_RETURN data starting with the prefix
+type OpReturnTxs struct {
+	Paging
+	Prefix string       `json:"prefix"`
+	Txs    []OpReturnTx `json:"txs"`
 }
 
 // BlockInfo contains extended block header data and a list of block txids
 type BlockInfo struct {
-	Hash          string      `json:"hash"`
-	Prev          string      `json:"previousBlockHash,omitempty"`
-	Next          string      `json:"nextBlockHash,omitempty"`
-	Height        uint32      `json:"height"`
-	Confirmations int         `json:"confirmations"`
-	Size          int         `json:"size"`
-	Time          int64       `json:"time,omitempty"`
-	Version       json.Number `json:"version"`
-	MerkleRoot    string      `json:"merkleRoot"`
-	Nonce         string      `json:"nonce"`
-	Bits          string      `json:"bits"`
-	Difficulty    string      `json:"difficulty"`
-	Txids         []string    `json:"tx,omitempty"`
+	Hash          string             `json:"hash"`
+	Prev          string             `json:"previousBlockHash,omitempty"`
+	Next          string             `json:"nextBlockHash,omitempty"`
+	Height        uint32             `json:"height"`
+	Confirmations int                `json:"confirmations"`
+	Size          int                `json:"size"`
+	Time          int64              `json:"time,omitempty"`
+	Version       json.Number        `json:"version"`
+	MerkleRoot    string             `json:"merkleRoot"`
+	Nonce         string             `json:"nonce"`
+	Bits          string             `json:"bits"`
+	Difficulty    string             `json:"difficulty"`
+	Txids         []string           `json:"tx,omitempty"`
+	PivxSpecific  *PivxBlockSpecific `json:"pivxSpecific,omitempty"`
+}
+
+// PivxBlockSpecific contains PIVX specific block data
+type PivxBlockSpecific struct {
+	AccumulatorCheckpoint string `json:"accumulatorCheckpoint,omitempty"`
+	BlockSignature        string `json:"blockSignature,omitempty"`
+	ProofOfStake          bool   `json:"proofOfStake"`
+	Staker                string `json:"staker,omitempty"`
 }
 
 // Block contains information about block
@@ -410,6 +635,7 @@ type BlockbookInfo struct {
 	DbSize            int64                        `json:"dbSize"`
 	DbSizeFromColumns int64                        `json:"dbSizeFromColumns,omitempty"`
 	DbColumns         []common.InternalStateColumn `json:"dbColumns,omitempty"`
+	PrunedHeight      uint32                       `json:"prunedHeight,omitempty"`
 	About             string                       `json:"about"`
 }
 
@@ -6,6 +6,7 @@ import (
 	"blockbook/common"
 	"blockbook/db"
 	"bytes"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"math"
@@ -55,14 +56,33 @@ func (w *Worker) getAddressesFromVout(vout *bchain.Vout) (bchain.AddressDescript
 }
 
 // setSpendingTxToVout is helper function, that finds transaction that spent given output and sets it to the output
-// there is no direct index for the operation, it must be found using addresses -> txaddresses -> tx
+// the spending input is found in the index of spent outpoints, the outputs spent before the index was introduced
+// must be found using addresses -> txaddresses -> tx
 func (w *Worker) setSpendingTxToVout(vout *Vout, txid string, height uint32) error {
-	err := w.db.GetAddrDescTransactions(vout.AddrDesc, height, maxUint32, func(t string, height uint32, indexes []int32) error {
+	si, err := w.db.GetSpendingInput(txid, int32(vout.N))
+	if err != nil {
+		return err
+	}
+	if si != nil {
+		vout.SpentTxID = si.Txid
+		vout.SpentHeight = int(si.Height)
+		vout.SpentIndex = int(si.Vin)
+		return nil
+	}
+	if !vout.Spent {
+		return nil
+	}
+	addrDesc := vout.AddrDesc
+	// cold staking outputs are indexed under the owner and the staker address
+	if owner, _ := w.chainParser.GetColdStakingAddrDescs(addrDesc); owner != nil {
+		addrDesc = owner
+	}
+	err = w.db.GetAddrDescTransactions(addrDesc, height, maxUint32, func(t string, height uint32, indexes []int32) error {
 		for _, index := range indexes {
 			// take only inputs
 			if index < 0 {
 				index = ^index
-				tsp, err := w.db.GetTxAddresses(t)
+				tsp, err := w.getTxAddresses(t)
 				if err != nil {
 					return err
 				} else if tsp == nil {
@@ -94,6 +114,13 @@ func (w *Worker) setSpendingTxToVout(vout *Vout, txid string, height uint32) err
 // GetSpendingTxid returns transaction id of transaction that spent given output
 func (w *Worker) GetSpendingTxid(txid string, n int) (string, error) {
 	start := time.Now()
+	si, err := w.db.GetSpendingInput(txid, int32(n))
+	if err != nil {
+		return "", err
+	}
+	if si != nil {
+		return si.Txid, nil
+	}
 	tx, err := w.GetTransaction(txid, false, false)
 	if err != nil {
 		return "", err
@@ -109,6 +136,46 @@ func (w *Worker) GetSpendingTxid(txid string, n int) (string, error) {
 	return tx.Vout[n].SpentTxID, nil
 }
 
+// isPrunedHeight returns true if the fully spent transactions from the block of given height were removed from the pruned index
+func (w *Worker) isPrunedHeight(height int) bool {
+	return height > 0 && uint32(height) <= w.is.GetPrunedHeight()
+}
+
+// getTxAddresses returns TxAddresses of the transaction or nil if the transaction is not indexed
+// TxAddresses of the transactions removed from the pruned index are reconstructed from the transaction loaded by txCache
+func (w *Worker) getTxAddresses(txid string) (*db.TxAddresses, error) {
+	ta, err := w.db.GetTxAddresses(txid)
+	if err != nil || ta != nil || w.is.GetPrunedHeight() == 0 {
+		return ta, err
+	}
+	tx, err := w.GetTransaction(txid, false, false)
+	if err != nil {
+		return nil, err
+	}
+	if !w.isPrunedHeight(tx.Blockheight) {
+		return nil, nil
+	}
+	ta = &db.TxAddresses{
+		Height:  uint32(tx.Blockheight),
+		Inputs:  make([]db.TxInput, len(tx.Vin)),
+		Outputs: make([]db.TxOutput, len(tx.Vout)),
+	}
+	for i := range tx.Vin {
+		ta.Inputs[i].AddrDesc = tx.Vin[i].AddrDesc
+		if tx.Vin[i].ValueSat != nil {
+			ta.Inputs[i].ValueSat.Set((*big.Int)(tx.Vin[i].ValueSat))
+		}
+	}
+	for i := range tx.Vout {
+		ta.Outputs[i].AddrDesc = tx.Vout[i].AddrDesc
+		ta.Outputs[i].Spent = tx.Vout[i].Spent
+		if tx.Vout[i].ValueSat != nil {
+			ta.Outputs[i].ValueSat.Set((*big.Int)(tx.Vout[i].ValueSat))
+		}
+	}
+	return ta, nil
+}
+
 // GetTransaction reads transaction data from txid
 func (w *Worker) GetTransaction(txid string, spendingTxs bool, specificJSON bool) (*Tx, error) {
 	bchainTx, height, err := w.txCache.GetTransaction(txid)
@@ -127,13 +194,19 @@ func (w *Worker) GetTransactionFromBchainTx(bchainTx *bchain.Tx, height int, spe
 	var ta *db.TxAddresses
 	var tokens []TokenTransfer
 	var ethSpecific *EthereumSpecific
+	var pivxSpecific *PivxSpecific
+	var specialTx *SpecialTx
+	var isCoinstake bool
+	var stakeReward *StakeReward
 	var blockhash string
+	var pruned bool
 	if bchainTx.Confirmations > 0 {
 		if w.chainType == bchain.ChainBitcoinType {
 			ta, err = w.db.GetTxAddresses(bchainTx.Txid)
 			if err != nil {
 				return nil, errors.Annotatef(err, "GetTxAddresses %v", bchainTx.Txid)
 			}
+			pruned = ta == nil && w.isPrunedHeight(height)
 		}
 		blockhash, err = w.db.GetBlockHash(uint32(height))
 		if err != nil {
@@ -167,7 +240,7 @@ func (w *Worker) GetTransactionFromBchainTx(bchainTx *bchain.Tx, height int, spe
 				}
 				if tas == nil {
 					// try to load from backend
-					otx, _, err := w.txCache.GetTransaction(bchainVin.Txid)
+					otx, otxHeight, err := w.txCache.GetTransaction(bchainVin.Txid)
 					if err != nil {
 						if err == bchain.ErrTxNotFound {
 							// try to get AddrDesc using coin specific handling and continue processing the tx
@@ -180,8 +253,8 @@ func (w *Worker) GetTransactionFromBchainTx(bchainTx *bchain.Tx, height int, spe
 						}
 						return nil, errors.Annotatef(err, "txCache.GetTransaction %v", bchainVin.Txid)
 					}
-					// mempool transactions are not in TxAddresses but confirmed should be there, log a problem
-					if bchainTx.Confirmations > 0 {
+					// mempool transactions are not in TxAddresses but confirmed should be there unless pruned, log a problem
+					if bchainTx.Confirmations > 0 && !w.isPrunedHeight(otxHeight) {
 						inSync, _, _ := w.is.GetSyncState()
 						// backend can report tx as confirmed, however blockbook is still syncing (!inSync), in this case do not log a problem
 						if bchainTx.Confirmations != 1 || inSync {
@@ -234,20 +307,63 @@ func (w *Worker) GetTransactionFromBchainTx(bchainTx *bchain.Tx, height int, spe
 		if err != nil {
 			glog.V(2).Infof("getAddressesFromVout error %v, %v, output %v", err, bchainTx.Txid, bchainVout.N)
 		}
+		if owner, _ := w.chainParser.GetColdStakingAddrDescs(vout.AddrDesc); owner != nil {
+			vout.Type = ColdStakingVoutType
+		}
 		if ta != nil {
 			vout.Spent = ta.Outputs[i].Spent
-			if spendingTxs && vout.Spent {
-				err = w.setSpendingTxToVout(vout, bchainTx.Txid, uint32(height))
-				if err != nil {
-					glog.Errorf("setSpendingTxToVout error %v, %v, output %v", err, vout.AddrDesc, vout.N)
-				}
+		} else if pruned {
+			// only the fully spent transactions are pruned
+			vout.Spent = w.chainParser.IsAddrDescIndexable(vout.AddrDesc)
+		}
+		if spendingTxs && vout.Spent {
+			err = w.setSpendingTxToVout(vout, bchainTx.Txid, uint32(height))
+			if err != nil {
+				glog.Errorf("setSpendingTxToVout error %v, %v, output %v", err, vout.AddrDesc, vout.N)
 			}
 		}
 	}
 	if w.chainType == bchain.ChainBitcoinType {
-		// for coinbase transactions valIn is 0
+		masternodeVout := w.chainParser.GetMasternodePaymentVout(bchainTx)
+		if masternodeVout >= 0 && masternodeVout < len(vouts) {
+			if w.isBudgetPayment(uint32(height), &vouts[masternodeVout]) {
+				vouts[masternodeVout].Type = BudgetVoutType
+				masternodeVout = -1
+			} else {
+				vouts[masternodeVout].Type = MasternodeVoutType
+			}
+		}
+		sd, err := w.chainParser.GetShieldedTxData(bchainTx)
+		if err != nil {
+			glog.Errorf("GetShieldedTxData error %v, %v", err, bchainTx.Txid)
+		}
+		if sd != nil {
+			// positive value balance is unshielded to transparent outputs, negative value balance is shielded
+			if sd.ValueBalanceSat.Sign() > 0 {
+				valInSat.Add(&valInSat, &sd.ValueBalanceSat)
+			} else {
+				valOutSat.Sub(&valOutSat, &sd.ValueBalanceSat)
+			}
+			pivxSpecific = &PivxSpecific{
+				ValueBalanceSat: (*Amount)(&sd.ValueBalanceSat),
+				ShieldedSpends:  sd.ShieldedSpends,
+				ShieldedOutputs: sd.ShieldedOutputs,
+			}
+		}
+		spd, err := w.chainParser.GetSpecialTxData(bchainTx)
+		if err != nil {
+			glog.Errorf("GetSpecialTxData error %v, %v", err, bchainTx.Txid)
+		}
+		if spd != nil {
+			specialTx = newSpecialTx(spd)
+		}
+		if w.chainParser.IsCoinstakeTx(bchainTx) {
+			isCoinstake = true
+			stakeReward = getStakeReward(vins, vouts, &valInSat, masternodeVout)
+		}
+		// for coinbase transactions valIn is 0, coinstake transactions do not pay fees
 		feesSat.Sub(&valInSat, &valOutSat)
-		if feesSat.Sign() == -1 {
+		if feesSat.Sign() == -1 || isCoinstake {
 			feesSat.SetUint64(0)
 		}
 		pValInSat = &valInSat
@@ -324,16 +440,94 @@ func (w *Worker) GetTransactionFromBchainTx(bchainTx *bchain.Tx, height int, spe
 		Version:          bchainTx.Version,
 		Hex:              bchainTx.Hex,
 		Rbf:              rbf,
+		IsCoinstake:      isCoinstake,
+		StakeReward:      stakeReward,
 		Vin:              vins,
 		Vout:             vouts,
 		CoinSpecificData: bchainTx.CoinSpecificData,
 		CoinSpecificJSON: sj,
 		TokenTransfers:   tokens,
 		EthereumSpecific: ethSpecific,
+		PivxSpecific:     pivxSpecific,
+		SpecialTx:        specialTx,
 	}
 	return r, nil
 }
 
+func newSpecialTx(sd *bchain.SpecialTxData) *SpecialTx {
+	r := &SpecialTx{
+		Type:           sd.Type,
+		TypeName:       sd.TypeName,
+		ProTxHash:      sd.ProTxHash,
+		CollateralHash: sd.CollateralTxid,
+		Service:        sd.ServiceAddress,
+		OwnerAddress:   sd.OwnerAddress,
+		VotingAddress:  sd.VotingAddress,
+		PayoutAddress:  sd.PayoutAddress,
+	}
+	if sd.CollateralTxid != "" {
+		vout := sd.CollateralVout
+		r.CollateralIndex = &vout
+	}
+	return r
+}
+
+// isBudgetPayment checks if the output in the block of given height pays a budget proposal
+// the budget is paid in the superblock and the following blocks of the budget cycle to the proposals stored for the superblock,
+// only the proposals with the payments found in the blocks are considered
+func (w *Worker) isBudgetPayment(height uint32, vout *Vout) bool {
+	cycle := w.chainParser.SuperblockCycle()
+	if cycle == 0 || height == 0 || len(vout.Addresses) == 0 {
+		return false
+	}
+	superblock := height - height%cycle
+	proposals, err := w.db.GetSuperblockBudget(superblock)
+	if err != nil {
+		glog.Error("GetSuperblockBudget ", superblock, " error ", err)
+		return false
+	}
+	for i := range proposals {
+		if proposals[i].PaymentAddress == vout.Addresses[0] && proposals[i].PaidSat.Sign() > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// getStakeReward computes the split of the reward of the coinstake transaction
+// the outputs of the coinstake transaction are the empty output, the outputs to the staker,
+// the masternode payment and the budget payments in the superblocks
+// the staker outputs are the outputs to the address of the first input or to the same script as the first staker output
+// the split cannot be computed for zerocoin stakes, their staked value is not known, nil is returned
+func getStakeReward(vins []Vin, vouts []Vout, valInSat *big.Int, masternodeVout int) *StakeReward {
+	if len(vins) == 0 || len(vins[0].AddrDesc) == 0 || vins[0].ValueSat == nil || (*big.Int)(vins[0].ValueSat).Sign() == 0 {
+		return nil
+	}
+	var stakerSat, masternodeSat, budgetSat big.Int
+	for i := 1; i < len(vouts); i++ {
+		vout := &vouts[i]
+		if vout.ValueSat == nil {
+			continue
+		}
+		if i == masternodeVout {
+			masternodeSat.Set((*big.Int)(vout.ValueSat))
+		} else if bytes.Equal(vout.AddrDesc, vins[0].AddrDesc) || bytes.Equal(vout.AddrDesc, vouts[1].AddrDesc) {
+			stakerSat.Add(&stakerSat, (*big.Int)(vout.ValueSat))
+		} else {
+			budgetSat.Add(&budgetSat, (*big.Int)(vout.ValueSat))
+		}
+	}
+	stakerSat.Sub(&stakerSat, valInSat)
+	r := StakeReward{StakerSat: (*Amount)(&stakerSat)}
+	if masternodeVout > 0 {
+		r.MasternodeSat = (*Amount)(&masternodeSat)
+	}
+	if budgetSat.Sign() != 0 {
+		r.BudgetSat = (*Amount)(&budgetSat)
+	}
+	return &r
+}
+
 func (w *Worker) getAddressTxids(addrDesc bchain.AddressDescriptor, mempool bool, filter *AddressFilter, maxResults int) ([]string, error) {
 	var err error
 	txids := make([]string, 0, 4)
@@ -348,19 +542,10 @@ func (w *Worker) getAddressTxids(addrDesc bchain.AddressDescriptor, mempool bool
 		}
 	} else {
 		callback = func(txid string, height uint32, indexes []int32) error {
-			for _, index := range indexes {
-				vout := index
-				if vout < 0 {
-					vout = ^vout
-				}
-				if (filter.Vout == AddressFilterVoutInputs && index < 0) ||
-					(filter.Vout == AddressFilterVoutOutputs && index >= 0) ||
-					(vout == int32(filter.Vout)) {
-					txids = append(txids, txid)
-					if len(txids) >= maxResults {
-						return &db.StopIteration{}
-					}
-					break
+			if matchVoutFilter(filter, indexes) {
+				txids = append(txids, txid)
+				if len(txids) >= maxResults {
+					return &db.StopIteration{}
 				}
 			}
 			return nil
@@ -394,6 +579,65 @@ func (w *Worker) getAddressTxids(addrDesc bchain.AddressDescriptor, mempool bool
 	return txids, nil
 }
 
+// matchVoutFilter returns true if any of the indexes of the address in the tx (input negative, output positive) matches the vout filter
+func matchVoutFilter(filter *AddressFilter, indexes []int32) bool {
+	if filter.Vout == AddressFilterVoutOff {
+		return true
+	}
+	for _, index := range indexes {
+		vout := index
+		if vout < 0 {
+			vout = ^vout
+		}
+		if (filter.Vout == AddressFilterVoutInputs && index < 0) ||
+			(filter.Vout == AddressFilterVoutOutputs && index >= 0) ||
+			(vout == int32(filter.Vout)) {
+			return true
+		}
+	}
+	return false
+}
+
+// getAddressTxidsFromCursor returns confirmed txids of the address following the cursor and their positions in the history
+// the history is read from the block of the cursor, therefore the cost does not depend on the depth of the history
+func (w *Worker) getAddressTxidsFromCursor(addrDesc bchain.AddressDescriptor, filter *AddressFilter, cursor *historyCursor, maxResults int) ([]string, []historyCursor, error) {
+	txids := make([]string, 0, 4)
+	positions := make([]historyCursor, 0, 4)
+	to := filter.ToHeight
+	if to == 0 {
+		to = maxUint32
+	}
+	if cursor != nil && cursor.height < to {
+		to = cursor.height
+	}
+	if to < filter.FromHeight {
+		return txids, positions, nil
+	}
+	var position historyCursor
+	err := w.db.GetAddrDescTransactions(addrDesc, filter.FromHeight, to, func(txid string, height uint32, indexes []int32) error {
+		if !matchVoutFilter(filter, indexes) {
+			return nil
+		}
+		if position.height != height || position.index == 0 {
+			position = historyCursor{height: height}
+		}
+		position.index++
+		if cursor != nil && !cursor.after(position.height, position.index) {
+			return nil
+		}
+		txids = append(txids, txid)
+		positions = append(positions, position)
+		if len(txids) >= maxResults {
+			return &db.StopIteration{}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return txids, positions, nil
+}
+
 func (t *Tx) getAddrVoutValue(addrDesc bchain.AddressDescriptor) *big.Int {
 	var val big.Int
 	for _, vout := range t.Vout {
@@ -440,6 +684,7 @@ func (w *Worker) txFromTxAddress(txid string, ta *db.TxAddresses, bi *db.BlockIn
 		vin.N = i
 		vin.ValueSat = (*Amount)(&tai.ValueSat)
 		valInSat.Add(&valInSat, &tai.ValueSat)
+		vin.AddrDesc = tai.AddrDesc
 		vin.Addresses, vin.IsAddress, err = tai.Addresses(w.chainParser)
 		if err != nil {
 			glog.Errorf("tai.Addresses error %v, tx %v, input %v, tai %+v", err, txid, i, tai)
@@ -452,15 +697,28 @@ func (w *Worker) txFromTxAddress(txid string, ta *db.TxAddresses, bi *db.BlockIn
 		vout.N = i
 		vout.ValueSat = (*Amount)(&tao.ValueSat)
 		valOutSat.Add(&valOutSat, &tao.ValueSat)
+		vout.AddrDesc = tao.AddrDesc
 		vout.Addresses, vout.IsAddress, err = tao.Addresses(w.chainParser)
 		if err != nil {
 			glog.Errorf("tai.Addresses error %v, tx %v, output %v, tao %+v", err, txid, i, tao)
 		}
 		vout.Spent = tao.Spent
 	}
-	// for coinbase transactions valIn is 0
+	var stakeReward *StakeReward
+	isCoinstake := w.db.IsCoinstake(ta)
+	if isCoinstake {
+		// the transaction is not available, the masternode payment is the last output if it is not paid to the staker
+		masternodeVout := len(vouts) - 1
+		if masternodeVout <= 1 || bytes.Equal(vouts[masternodeVout].AddrDesc, vouts[1].AddrDesc) {
+			masternodeVout = -1
+		} else if w.isBudgetPayment(ta.Height, &vouts[masternodeVout]) {
+			masternodeVout = -1
+		}
+		stakeReward = getStakeReward(vins, vouts, &valInSat, masternodeVout)
+	}
+	// for coinbase transactions valIn is 0, coinstake transactions do not pay fees
 	feesSat.Sub(&valInSat, &valOutSat)
-	if feesSat.Sign() == -1 {
+	if feesSat.Sign() == -1 || isCoinstake {
 		feesSat.SetUint64(0)
 	}
 	r := &Tx{
@@ -472,6 +730,8 @@ func (w *Worker) txFromTxAddress(txid string, ta *db.TxAddresses, bi *db.BlockIn
 		Txid:          txid,
 		ValueInSat:    (*Amount)(&valInSat),
 		ValueOutSat:   (*Amount)(&valOutSat),
+		IsCoinstake:   isCoinstake,
+		StakeReward:   stakeReward,
 		Vin:           vins,
 		Vout:          vouts,
 	}
@@ -620,7 +880,9 @@ func (w *Worker) txFromTxid(txid string, bestheight uint32, option AccountDetail
 			return nil, errors.Annotatef(err, "GetTxAddresses %v", txid)
 		}
 		if ta == nil {
-			glog.Warning("DB inconsistency:  tx ", txid, ": not found in txAddresses")
+			if w.is.GetPrunedHeight() == 0 {
+				glog.Warning("DB inconsistency:  tx ", txid, ": not found in txAddresses")
+			}
 			// as fallback, get tx from backend
 			tx, err = w.GetTransaction(txid, false, true)
 			if err != nil {
@@ -682,6 +944,8 @@ func (w *Worker) GetAddress(address string, page int, txsOnPage int, option Acco
 		pg                       Paging
 		uBalSat                  big.Int
 		totalReceived, totalSent *big.Int
+		delegated                *big.Int
+		stakingRewards           *big.Int
 		nonce                    string
 		unconfirmedTxs           int
 		nonTokenTxs              int
@@ -691,6 +955,10 @@ func (w *Worker) GetAddress(address string, page int, txsOnPage int, option Acco
 	if err != nil {
 		return nil, err
 	}
+	cursor, err := parseHistoryCursor(filter.Cursor)
+	if err != nil {
+		return nil, err
+	}
 	if w.chainType == bchain.ChainEthereumType {
 		var n uint64
 		ba, tokens, erc20c, n, nonTokenTxs, totalResults, err = w.getEthereumTypeAddressBalances(addrDesc, option, filter)
@@ -735,7 +1003,8 @@ func (w *Worker) GetAddress(address string, page int, txsOnPage int, option Acco
 					unconfirmedTxs++
 					uBalSat.Add(&uBalSat, tx.getAddrVoutValue(addrDesc))
 					uBalSat.Sub(&uBalSat, tx.getAddrVinValue(addrDesc))
-					if page == 0 {
+					// the cursor continues the confirmed history, the mempool txs are returned only on the first page
+					if page == 0 && cursor == nil {
 						if option == AccountDetailsTxidHistory {
 							txids = append(txids, tx.Txid)
 						} else if option >= AccountDetailsTxHistoryLight {
@@ -748,23 +1017,42 @@ func (w *Worker) GetAddress(address string, page int, txsOnPage int, option Acco
 	}
 	// get tx history if requested by option or check mempool if there are some transactions for a new address
 	if option >= AccountDetailsTxidHistory {
-		txc, err := w.getAddressTxids(addrDesc, false, filter, (page+1)*txsOnPage)
-		if err != nil {
-			return nil, errors.Annotatef(err, "getAddressTxids %v false", addrDesc)
+		var from, to int
+		var txc []string
+		var positions []historyCursor
+		if cursor != nil {
+			// load one more txid to find out if there is a next page
+			txc, positions, err = w.getAddressTxidsFromCursor(addrDesc, filter, cursor, txsOnPage+1)
+			if err != nil {
+				return nil, errors.Annotatef(err, "getAddressTxidsFromCursor %v", addrDesc)
+			}
+			pg = Paging{ItemsOnPage: txsOnPage}
+			to = len(txc)
+			if to > txsOnPage {
+				to = txsOnPage
+				pg.NextCursor = positions[to-1].String()
+			}
+		} else {
+			txc, positions, err = w.getAddressTxidsFromCursor(addrDesc, filter, nil, (page+1)*txsOnPage)
+			if err != nil {
+				return nil, errors.Annotatef(err, "getAddressTxidsFromCursor %v", addrDesc)
+			}
+			pg, from, to, page = computePaging(len(txc), page, txsOnPage)
+			if len(txc) >= txsOnPage {
+				if totalResults < 0 {
+					pg.TotalPages = -1
+				} else {
+					pg, _, _, _ = computePaging(totalResults, page, txsOnPage)
+				}
+			}
+			if to-from == txsOnPage && (pg.TotalPages < 0 || pg.Page < pg.TotalPages) {
+				pg.NextCursor = positions[to-1].String()
+			}
 		}
 		bestheight, _, err := w.db.GetBestBlock()
 		if err != nil {
 			return nil, errors.Annotatef(err, "GetBestBlock")
 		}
-		var from, to int
-		pg, from, to, page = computePaging(len(txc), page, txsOnPage)
-		if len(txc) >= txsOnPage {
-			if totalResults < 0 {
-				pg.TotalPages = -1
-			} else {
-				pg, _, _, _ = computePaging(totalResults, page, txsOnPage)
-			}
-		}
 		for i := from; i < to; i++ {
 			txid := txc[i]
 			if option == AccountDetailsTxidHistory {
@@ -778,18 +1066,42 @@ func (w *Worker) GetAddress(address string, page int, txsOnPage int, option Acco
 			}
 		}
 	}
+	balance := &ba.BalanceSat
 	if w.chainType == bchain.ChainBitcoinType {
-		totalReceived = ba.ReceivedSat()
-		totalSent = &ba.SentSat
+		if w.chainParser.IsColdStakingStakerAddrDesc(addrDesc) {
+			// staker does not own the coins, the balance of the staker address is the value delegated to it
+			delegated = balance
+			balance = &big.Int{}
+		} else {
+			totalReceived = ba.ReceivedSat()
+			totalSent = &ba.SentSat
+		}
+		if w.chainParser.SupportsCoinstake() {
+			sr, err := w.db.GetAddrDescStakingRewards(addrDesc)
+			if err != nil {
+				return nil, errors.Annotatef(err, "GetAddrDescStakingRewards %v", addrDesc)
+			}
+			if sr != nil {
+				// staking rewards are reported separately from the received amount
+				stakingRewards = &sr.RewardSat
+				if totalReceived != nil {
+					totalReceived.Sub(totalReceived, stakingRewards)
+				}
+			}
+		}
 	}
 	r := &Address{
 		Paging:                pg,
 		AddrStr:               address,
-		BalanceSat:            (*Amount)(&ba.BalanceSat),
+		BalanceSat:            (*Amount)(balance),
+		DelegatedBalanceSat:   (*Amount)(delegated),
 		TotalReceivedSat:      (*Amount)(totalReceived),
 		TotalSentSat:          (*Amount)(totalSent),
+		StakingRewardsSat:     (*Amount)(stakingRewards),
 		Txs:                   int(ba.Txs),
 		NonTokenTxs:           nonTokenTxs,
+		FirstSeenHeight:       ba.FirstHeight,
+		LastActivityHeight:    ba.LastHeight,
 		UnconfirmedBalanceSat: (*Amount)(&uBalSat),
 		UnconfirmedTxs:        unconfirmedTxs,
 		Transactions:          txs,
@@ -802,30 +1114,424 @@ func (w *Worker) GetAddress(address string, page int, txsOnPage int, option Acco
 	return r, nil
 }
 
-func (w *Worker) balanceHistoryHeightsFromTo(fromTime, toTime time.Time) (uint32, uint32, uint32, uint32) {
-	fromUnix := uint32(0)
-	toUnix := maxUint32
+// GetMasternode returns summary of the masternode payments to the address
+func (w *Worker) GetMasternode(address string) (*Masternode, error) {
+	start := time.Now()
+	if w.chainType != bchain.ChainBitcoinType {
+		return nil, NewAPIError("Masternodes are not supported", true)
+	}
+	addrDesc, address, err := w.getAddrDescAndNormalizeAddress(address)
+	if err != nil {
+		return nil, err
+	}
+	var total big.Int
+	r := &Masternode{
+		AddrStr:        address,
+		TotalEarnedSat: (*Amount)(&total),
+	}
+	err = w.db.GetAddrDescMasternodePayments(addrDesc, 0, maxUint32, func(height uint32, valueSat *big.Int) error {
+		// payments are iterated from the newest
+		if r.Payments == 0 {
+			r.LastPaymentHeight = height
+		}
+		r.Payments++
+		total.Add(&total, valueSat)
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Annotatef(err, "GetAddrDescMasternodePayments %v", addrDesc)
+	}
+	glog.Info("GetMasternode ", address, " finished in ", time.Since(start))
+	return r, nil
+}
+
+// heightOrBestHeight parses the height parameter, empty height means the best block
+func (w *Worker) heightOrBestHeight(height string) (uint32, error) {
+	bestheight, _, err := w.db.GetBestBlock()
+	if err != nil {
+		return 0, errors.Annotatef(err, "GetBestBlock")
+	}
+	if height == "" {
+		return bestheight, nil
+	}
+	h, err := strconv.Atoi(height)
+	if err != nil || h < 0 || uint32(h) > bestheight {
+		return 0, NewAPIError(fmt.Sprintf("Invalid height %v", height), true)
+	}
+	return uint32(h), nil
+}
+
+// GetZerocoinPool returns state of the zerocoin pool at given height, empty height means the best block
+func (w *Worker) GetZerocoinPool(height string) (*Zerocoin, error) {
+	start := time.Now()
+	if w.chainType != bchain.ChainBitcoinType {
+		return nil, NewAPIError("Zerocoin is not supported", true)
+	}
+	h, err := w.heightOrBestHeight(height)
+	if err != nil {
+		return nil, err
+	}
+	zp, err := w.db.GetZerocoinPool(h)
+	if err != nil {
+		return nil, errors.Annotatef(err, "GetZerocoinPool %v", h)
+	}
+	var total big.Int
+	r := &Zerocoin{
+		Height:         h,
+		Denominations:  []ZerocoinDenomination{},
+		TotalSupplySat: (*Amount)(&total),
+	}
+	if zp != nil {
+		r.LastChange = zp.Height
+		for i := range zp.Denominations {
+			zd := &zp.Denominations[i]
+			var denomination, supply big.Int
+			denomination.SetInt64(zd.Denomination)
+			// spends of zerocoins with unrecognized denomination could exceed mints, do not go negative
+			if zd.Mints > zd.Spends {
+				supply.SetInt64(int64(zd.Mints - zd.Spends))
+				supply.Mul(&supply, &denomination)
+			}
+			total.Add(&total, &supply)
+			r.Denominations = append(r.Denominations, ZerocoinDenomination{
+				DenominationSat: (*Amount)(&denomination),
+				Mints:           zd.Mints,
+				Spends:          zd.Spends,
+				SupplySat:       (*Amount)(&supply),
+			})
+		}
+	}
+	glog.Info("GetZerocoinPool ", h, " finished in ", time.Since(start))
+	return r, nil
+}
+
+// GetSupply returns the coin supply after the block of given height, empty height means the best block
+func (w *Worker) GetSupply(height string) (*Supply, error) {
+	start := time.Now()
+	if w.chainType != bchain.ChainBitcoinType {
+		return nil, NewAPIError("Supply is not supported", true)
+	}
+	h, err := w.heightOrBestHeight(height)
+	if err != nil {
+		return nil, err
+	}
+	s, err := w.db.GetSupply(h)
+	if err != nil {
+		return nil, errors.Annotatef(err, "GetSupply %v", h)
+	}
+	if s == nil || s.Height != h {
+		return nil, NewAPIError(fmt.Sprintf("Supply at height %v is not available", h), true)
+	}
+	var circulating big.Int
+	circulating.Sub(&s.IssuedSat, &s.BurnedSat)
+	glog.Info("GetSupply ", h, " finished in ", time.Since(start))
+	return &Supply{
+		Height:         h,
+		IssuedSat:      (*Amount)(&s.IssuedSat),
+		BurnedSat:      (*Amount)(&s.BurnedSat),
+		CirculatingSat: (*Amount)(&circulating),
+	}, nil
+}
+
+// richListMaxAddresses is the maximum number of the addresses returned in the rich list
+const richListMaxAddresses = 1000
+
+// GetRichList returns a page of the addresses with the highest balance together with their share of the circulating supply
+func (w *Worker) GetRichList(page int, itemsOnPage int) (*RichList, error) {
+	start := time.Now()
+	if w.chainType != bchain.ChainBitcoinType {
+		return nil, NewAPIError("Rich list is not supported", true)
+	}
+	page--
+	if page < 0 {
+		page = 0
+	}
+	bestheight, _, err := w.db.GetBestBlock()
+	if err != nil {
+		return nil, errors.Annotatef(err, "GetBestBlock")
+	}
+	count, err := w.db.GetRichListSize(richListMaxAddresses)
+	if err != nil {
+		return nil, errors.Annotatef(err, "GetRichListSize")
+	}
+	pg, from, to, page := computePaging(count, page, itemsOnPage)
+	rl, err := w.db.GetRichList(from, to-from)
+	if err != nil {
+		return nil, errors.Annotatef(err, "GetRichList %v", from)
+	}
+	r := &RichList{
+		Paging:    pg,
+		Height:    bestheight,
+		Addresses: make([]RichListAddress, len(rl)),
+	}
+	var circulating *big.Float
+	s, err := w.db.GetSupply(bestheight)
+	if err != nil {
+		return nil, errors.Annotatef(err, "GetSupply %v", bestheight)
+	}
+	if s != nil && s.Height == bestheight {
+		var c big.Int
+		c.Sub(&s.IssuedSat, &s.BurnedSat)
+		r.CirculatingSat = (*Amount)(&c)
+		if c.Sign() > 0 {
+			circulating = new(big.Float).SetInt(&c)
+		}
+	}
+	for i := range rl {
+		a := &r.Addresses[i]
+		a.Rank = from + i + 1
+		a.BalanceSat = (*Amount)(&rl[i].BalanceSat)
+		addresses, _, err := w.chainParser.GetAddressesFromAddrDesc(rl[i].AddrDesc)
+		if err != nil {
+			glog.Warningf("GetAddressesFromAddrDesc error %v, %v", err, rl[i].AddrDesc)
+		}
+		if len(addresses) > 0 {
+			a.Address = addresses[0]
+		}
+		ab, err := w.db.GetAddrDescBalance(rl[i].AddrDesc, db.AddressBalanceDetailNoUTXO)
+		if err != nil {
+			return nil, errors.Annotatef(err, "GetAddrDescBalance %v", a.Address)
+		}
+		if ab != nil {
+			a.Txs = int(ab.Txs)
+		}
+		if circulating != nil {
+			share, _ := new(big.Float).Quo(new(big.Float).SetInt(&rl[i].BalanceSat), circulating).Float64()
+			a.Share = share * 100
+		}
+	}
+	glog.Info("GetRichList page ", page+1, " finished in ", time.Since(start))
+	return r, nil
+}
+
+// GetOpReturnTxs returns a page of the outputs with the OP_RETURN data starting with hexPrefix in the blocks fromHeight-toHeight
+// the outputs are ordered from the newest to the oldest, toHeight 0 means up to the best block
+func (w *Worker) GetOpReturnTxs(hexPrefix string, page int, itemsOnPage int, fromHeight, toHeight uint32) (*OpReturnTxs, error) {
+	start := time.Now()
+	if w.chainType != bchain.ChainBitcoinType {
+		return nil, NewAPIError("OP_RETURN index is not supported", true)
+	}
+	prefix, err := hex.DecodeString(hexPrefix)
+	if err != nil || len(prefix) == 0 {
+		return nil, NewAPIError("Invalid OP_RETURN prefix", true)
+	}
+	if toHeight == 0 {
+		toHeight = maxUint32
+	}
+	page--
+	if page < 0 {
+		page = 0
+	}
+	// one more output is read to find out if there is a next page, the total number of the outputs is not computed
+	outputs, err := w.db.GetOpReturnOutputs(prefix, fromHeight, toHeight, page*itemsOnPage, itemsOnPage+1)
+	if err != nil {
+		return nil, errors.Annotatef(err, "GetOpReturnOutputs %v", hexPrefix)
+	}
+	pg := Paging{
+		ItemsOnPage: itemsOnPage,
+		Page:        page + 1,
+		TotalPages:  page + 1,
+	}
+	if len(outputs) > itemsOnPage {
+		outputs = outputs[:itemsOnPage]
+		pg.TotalPages = -1
+	}
+	r := &OpReturnTxs{
+		Paging: pg,
+		Prefix: hexPrefix,
+		Txs:    make([]OpReturnTx, len(outputs)),
+	}
+	for i := range outputs {
+		o := &outputs[i]
+		bt, err := w.db.GetBlockTime(o.Height)
+		if err != nil {
+			return nil, errors.Annotatef(err, "GetBlockTime %v", o.Height)
+		}
+		r.Txs[i] = OpReturnTx{
+			Txid:        o.Txid,
+			Vout:        o.Vout,
+			BlockHeight: o.Height,
+			BlockTime:   int64(bt),
+			Data:        hex.EncodeToString(o.Data),
+		}
+	}
+	glog.Info("GetOpReturnTxs ", hexPrefix, " page ", page+1, " finished in ", time.Since(start))
+	return r, nil
+}
+
+// GetSuperblocks returns budget proposals paid in the stored superblocks and projected to be paid in the next superblock
+// the mined superblocks contain only the proposals with the payments found in the blocks of the budget cycle
+func (w *Worker) GetSuperblocks() (*Superblocks, error) {
+	start := time.Now()
+	cycle := w.chainParser.SuperblockCycle()
+	if cycle == 0 {
+		return nil, NewAPIError("Superblocks are not supported", true)
+	}
+	bestheight, _, err := w.db.GetBestBlock()
+	if err != nil {
+		return nil, errors.Annotatef(err, "GetBestBlock")
+	}
+	r := &Superblocks{
+		NextSuperblock: (bestheight/cycle + 1) * cycle,
+		Superblocks:    []Superblock{},
+	}
+	err = w.db.GetSuperblockBudgets(func(height uint32, proposals []bchain.BudgetProposal) error {
+		var total big.Int
+		sb := Superblock{
+			Height:          height,
+			Projection:      height > bestheight,
+			Proposals:       make([]BudgetProposal, 0, len(proposals)),
+			TotalPaymentSat: (*Amount)(&total),
+		}
+		for i := range proposals {
+			bp := &proposals[i]
+			payment := &bp.PaymentSat
+			if !sb.Projection {
+				if bp.PaidSat.Sign() <= 0 {
+					continue
+				}
+				payment = &bp.PaidSat
+			}
+			total.Add(&total, payment)
+			sb.Proposals = append(sb.Proposals, BudgetProposal{
+				Name:           bp.Name,
+				Hash:           bp.Hash,
+				URL:            bp.URL,
+				PaymentAddress: bp.PaymentAddress,
+				Yeas:           bp.Yeas,
+				Nays:           bp.Nays,
+				Abstains:       bp.Abstains,
+				PaymentSat:     (*Amount)(payment),
+			})
+		}
+		r.Superblocks = append(r.Superblocks, sb)
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Annotatef(err, "GetSuperblockBudgets")
+	}
+	glog.Info("GetSuperblocks finished in ", time.Since(start))
+	return r, nil
+}
+
+// blockHeightOfTime returns the height of the first block with the time greater or equal to the given time or maxUint32 if no such block
+func (w *Worker) blockHeightOfTime(t uint32) (uint32, error) {
+	height, found, err := w.db.GetBlockHeightOfTime(t)
+	if err != nil {
+		return 0, errors.Annotatef(err, "GetBlockHeightOfTime %v", t)
+	}
+	if !found {
+		return maxUint32, nil
+	}
+	return height, nil
+}
+
+// balanceHistoryHeightsFromTo returns the range of the heights of the blocks in the time range, the higher height is not included
+func (w *Worker) balanceHistoryHeightsFromTo(fromTime, toTime time.Time) (uint32, uint32, error) {
+	var err error
 	fromHeight := uint32(0)
 	toHeight := maxUint32
 	if !fromTime.IsZero() {
-		fromUnix = uint32(fromTime.Unix())
-		fromHeight = w.is.GetBlockHeightOfTime(fromUnix)
+		if fromHeight, err = w.blockHeightOfTime(uint32(fromTime.Unix())); err != nil {
+			return 0, 0, err
+		}
 	}
 	if !toTime.IsZero() {
-		toUnix = uint32(toTime.Unix())
-		toHeight = w.is.GetBlockHeightOfTime(toUnix)
+		if toHeight, err = w.blockHeightOfTime(uint32(toTime.Unix())); err != nil {
+			return 0, 0, err
+		}
+	}
+	return fromHeight, toHeight, nil
+}
+
+// GetBlockByTime returns the first block with the time greater or equal to the given unix time
+func (w *Worker) GetBlockByTime(unixTime string) (*BlockByTime, error) {
+	start := time.Now()
+	t, err := strconv.ParseUint(unixTime, 10, 32)
+	if err != nil {
+		return nil, NewAPIError(fmt.Sprintf("Invalid time %v", unixTime), true)
+	}
+	height, found, err := w.db.GetBlockHeightOfTime(uint32(t))
+	if err != nil {
+		return nil, errors.Annotatef(err, "GetBlockHeightOfTime %v", t)
+	}
+	if !found {
+		return nil, NewAPIError(fmt.Sprintf("Block with time %v or later not found", t), true)
+	}
+	bi, err := w.db.GetBlockInfo(height)
+	if err != nil {
+		return nil, errors.Annotatef(err, "GetBlockInfo %v", height)
+	}
+	if bi == nil {
+		return nil, NewAPIError(fmt.Sprintf("Block %v not found", height), true)
+	}
+	glog.Info("GetBlockByTime ", t, " finished in ", time.Since(start))
+	return &BlockByTime{
+		Height: height,
+		Hash:   bi.Hash,
+		Time:   bi.Time,
+	}, nil
+}
+
+// GetBlockFilter returns the basic compact block filter (BIP158) of the block of given height
+func (w *Worker) GetBlockFilter(height string) (*BlockFilter, error) {
+	start := time.Now()
+	if w.chainType != bchain.ChainBitcoinType {
+		return nil, NewAPIError("Block filters are not supported", true)
+	}
+	h, err := strconv.ParseUint(height, 10, 32)
+	if err != nil {
+		return nil, NewAPIError(fmt.Sprintf("Invalid block height %v", height), true)
+	}
+	hash, err := w.db.GetBlockHash(uint32(h))
+	if err != nil {
+		return nil, errors.Annotatef(err, "GetBlockHash %v", h)
 	}
-	return fromUnix, fromHeight, toUnix, toHeight
+	if hash == "" {
+		return nil, NewAPIError(fmt.Sprintf("Block %v not found", h), true)
+	}
+	bf, err := w.db.GetBlockFilter(uint32(h))
+	if err != nil {
+		return nil, errors.Annotatef(err, "GetBlockFilter %v", h)
+	}
+	if bf == nil {
+		return nil, NewAPIError(fmt.Sprintf("Filter of block %v not found", h), true)
+	}
+	r := &BlockFilter{
+		Height: bf.Height,
+		Hash:   hash,
+		Filter: hex.EncodeToString(bf.Filter),
+	}
+	if len(bf.Header) > 0 {
+		// the header is displayed in the reversed byte order like the hashes
+		header := make([]byte, len(bf.Header))
+		for i := range bf.Header {
+			header[len(header)-1-i] = bf.Header[i]
+		}
+		r.Header = hex.EncodeToString(header)
+	}
+	glog.Info("GetBlockFilter ", h, " finished in ", time.Since(start))
+	return r, nil
 }
 
-func (w *Worker) balanceHistoryForTxid(addrDesc bchain.AddressDescriptor, txid string, fromUnix, toUnix uint32) (*BalanceHistory, error) {
+// isAddrDescOfOutput checks if the output with outputAddrDesc belongs to addrDesc
+// cold staking outputs belong both to the owner and to the staker
+func (w *Worker) isAddrDescOfOutput(addrDesc, outputAddrDesc bchain.AddressDescriptor) bool {
+	if bytes.Equal(addrDesc, outputAddrDesc) {
+		return true
+	}
+	owner, staker := w.chainParser.GetColdStakingAddrDescs(outputAddrDesc)
+	return owner != nil && (bytes.Equal(addrDesc, owner) || bytes.Equal(addrDesc, staker))
+}
+
+func (w *Worker) balanceHistoryForTxid(addrDesc bchain.AddressDescriptor, txid string, fromHeight, toHeight uint32) (*BalanceHistory, error) {
 	var time uint32
 	var err error
 	var ta *db.TxAddresses
 	var bchainTx *bchain.Tx
 	var height uint32
 	if w.chainType == bchain.ChainBitcoinType {
-		ta, err = w.db.GetTxAddresses(txid)
+		ta, err = w.getTxAddresses(txid)
 		if err != nil {
 			return nil, err
 		}
@@ -846,10 +1552,14 @@ func (w *Worker) balanceHistoryForTxid(addrDesc bchain.AddressDescriptor, txid s
 		}
 		height = uint32(h)
 	}
-	time = w.is.GetBlockTime(height)
-	if time < fromUnix || time >= toUnix {
+	// the range of times is checked by the heights of the blocks, the block times are not monotonic
+	if height < fromHeight || height >= toHeight {
 		return nil, nil
 	}
+	time, err = w.db.GetBlockTime(height)
+	if err != nil {
+		return nil, err
+	}
 	bh := BalanceHistory{
 		Time:        time,
 		Txs:         1,
@@ -860,16 +1570,23 @@ func (w *Worker) balanceHistoryForTxid(addrDesc bchain.AddressDescriptor, txid s
 	if w.chainType == bchain.ChainBitcoinType {
 		for i := range ta.Inputs {
 			tai := &ta.Inputs[i]
-			if bytes.Equal(addrDesc, tai.AddrDesc) {
+			if w.isAddrDescOfOutput(addrDesc, tai.AddrDesc) {
 				(*big.Int)(bh.SentSat).Add((*big.Int)(bh.SentSat), &tai.ValueSat)
 			}
 		}
 		for i := range ta.Outputs {
 			tao := &ta.Outputs[i]
-			if bytes.Equal(addrDesc, tao.AddrDesc) {
+			if w.isAddrDescOfOutput(addrDesc, tao.AddrDesc) {
 				(*big.Int)(bh.ReceivedSat).Add((*big.Int)(bh.ReceivedSat), &tao.ValueSat)
 			}
 		}
+		if w.db.IsCoinstake(ta) {
+			// staking reward is reported separately from the received amount
+			if r, ok := w.db.CoinstakeRewards(ta)[string(addrDesc)]; ok {
+				(*big.Int)(bh.ReceivedSat).Sub((*big.Int)(bh.ReceivedSat), r)
+				bh.StakingRewardSat = (*Amount)(r)
+			}
+		}
 	} else if w.chainType == bchain.ChainEthereumType {
 		var value big.Int
 		ethTxData := eth.GetEthereumTxData(bchainTx)
@@ -940,7 +1657,10 @@ func (w *Worker) GetBalanceHistory(address string, fromTime, toTime time.Time, f
 	if err != nil {
 		return nil, err
 	}
-	fromUnix, fromHeight, toUnix, toHeight := w.balanceHistoryHeightsFromTo(fromTime, toTime)
+	fromHeight, toHeight, err := w.balanceHistoryHeightsFromTo(fromTime, toTime)
+	if err != nil {
+		return nil, err
+	}
 	if fromHeight >= toHeight {
 		return bhs, nil
 	}
@@ -949,7 +1669,7 @@ func (w *Worker) GetBalanceHistory(address string, fromTime, toTime time.Time, f
 		return nil, err
 	}
 	for txi := len(txs) - 1; txi >= 0; txi-- {
-		bh, err := w.balanceHistoryForTxid(addrDesc, txs[txi], fromUnix, toUnix)
+		bh, err := w.balanceHistoryForTxid(addrDesc, txs[txi], fromHeight, toHeight)
 		if err != nil {
 			return nil, err
 		}
@@ -979,9 +1699,12 @@ func (w *Worker) waitForBackendSync() {
 	}
 }
 
+// getAddrDescUtxo returns unspent outputs of the address descriptor
+// the outputs of the cold staking staker are delegated to it by the owner, the staker cannot spend them, they are marked as delegated
 func (w *Worker) getAddrDescUtxo(addrDesc bchain.AddressDescriptor, ba *db.AddrBalance, onlyConfirmed bool, onlyMempool bool) (Utxos, error) {
 	w.waitForBackendSync()
 	var err error
+	delegated := w.chainParser.IsColdStakingStakerAddrDesc(addrDesc)
 	r := make(Utxos, 0, 8)
 	spentInMempool := make(map[string]struct{})
 	if !onlyConfirmed {
@@ -1026,6 +1749,7 @@ func (w *Worker) getAddrDescUtxo(addrDesc bchain.AddressDescriptor, ba *db.AddrB
 									AmountSat: (*Amount)(&vout.ValueSat),
 									Locktime:  bchainTx.LockTime,
 									Coinbase:  coinbase,
+									Delegated: delegated,
 								})
 							}
 						}
@@ -1079,6 +1803,7 @@ func (w *Worker) getAddrDescUtxo(addrDesc bchain.AddressDescriptor, ba *db.AddrB
 						Height:        int(utxo.Height),
 						Confirmations: confirmations,
 						Coinbase:      coinbase,
+						Delegated:     delegated,
 					})
 				}
 				checksum.Sub(&checksum, &utxo.ValueSat)
@@ -1109,36 +1834,197 @@ func (w *Worker) GetAddressUtxo(address string, onlyConfirmed bool) (Utxos, erro
 	return r, nil
 }
 
-// GetBlocks returns BlockInfo for blocks on given page
-func (w *Worker) GetBlocks(page int, blocksOnPage int) (*Blocks, error) {
+// defaultStakersBlocks is the number of the last blocks in which the stakers are counted if the range is not specified
+const defaultStakersBlocks = 1440
+
+// stakerAddress returns the address of the staker or empty string if the staker is not known
+func (w *Worker) stakerAddress(addrDesc bchain.AddressDescriptor) string {
+	if len(addrDesc) == 0 {
+		return ""
+	}
+	a, s, err := w.chainParser.GetAddressesFromAddrDesc(addrDesc)
+	if err != nil || !s || len(a) == 0 {
+		return ""
+	}
+	return a[0]
+}
+
+func (w *Worker) blockSummary(bi *db.BlockInfo, bs *db.BlockStaker) BlockSummary {
+	r := BlockSummary{BlockInfo: *bi}
+	if bs != nil {
+		if bs.ProofOfStake {
+			r.Type = BlockTypePoS
+			r.Staker = w.stakerAddress(bs.Staker)
+		} else {
+			r.Type = BlockTypePoW
+		}
+	}
+	return r
+}
+
+// blocksOfType returns the number of blocks of given type up to the block (inclusive)
+func blocksOfType(bs *db.BlockStaker, blockType string) int {
+	if blockType == BlockTypePoS {
+		return int(bs.PosBlocks)
+	}
+	return int(bs.Height) + 1 - int(bs.PosBlocks)
+}
+
+// findBlockOfType returns the height of the n-th block (counted from 1) of given type
+func (w *Worker) findBlockOfType(n int, bestheight uint32, blockType string) (int, error) {
+	var err error
+	h := sort.Search(int(bestheight)+1, func(i int) bool {
+		if err != nil {
+			return true
+		}
+		var bs *db.BlockStaker
+		if bs, err = w.db.GetBlockStaker(uint32(i)); err != nil {
+			return true
+		}
+		return bs != nil && blocksOfType(bs, blockType) >= n
+	})
+	if err != nil {
+		return 0, errors.Annotatef(err, "GetBlockStaker")
+	}
+	return h, nil
+}
+
+// GetBlocks returns BlockInfo for blocks on given page, the blocks of the proof of stake coins can be filtered by the block type
+func (w *Worker) GetBlocks(page int, blocksOnPage int, blockType string) (*Blocks, error) {
 	start := time.Now()
 	page--
 	if page < 0 {
 		page = 0
 	}
+	if blockType != "" && blockType != BlockTypePoS && blockType != BlockTypePoW {
+		return nil, NewAPIError(fmt.Sprintf("Invalid block type %v", blockType), true)
+	}
 	b, _, err := w.db.GetBestBlock()
 	bestheight := int(b)
 	if err != nil {
 		return nil, errors.Annotatef(err, "GetBestBlock")
 	}
-	pg, from, to, page := computePaging(bestheight+1, page, blocksOnPage)
-	r := &Blocks{Paging: pg}
-	r.Blocks = make([]db.BlockInfo, to-from)
-	for i := from; i < to; i++ {
-		bi, err := w.db.GetBlockInfo(uint32(bestheight - i))
+	var bestStaker *db.BlockStaker
+	if w.chainParser.SupportsCoinstake() {
+		if bestStaker, err = w.db.GetBlockStaker(b); err != nil {
+			return nil, errors.Annotatef(err, "GetBlockStaker %v", b)
+		}
+	}
+	count := bestheight + 1
+	if blockType != "" {
+		if bestStaker == nil {
+			return nil, NewAPIError("Filter by block type is not supported", true)
+		}
+		count = blocksOfType(bestStaker, blockType)
+	}
+	pg, from, to, page := computePaging(count, page, blocksOnPage)
+	r := &Blocks{
+		Paging:     pg,
+		Filter:     blockType,
+		BlockTypes: bestStaker != nil,
+	}
+	r.Blocks = make([]BlockSummary, 0, to-from)
+	height := bestheight - from
+	if blockType != "" && from < to {
+		// the first block on the page is the (count-from)-th block of the type
+		if height, err = w.findBlockOfType(count-from, b, blockType); err != nil {
+			return nil, err
+		}
+	}
+	for ; height >= 0 && len(r.Blocks) < to-from; height-- {
+		var bs *db.BlockStaker
+		if r.BlockTypes {
+			if bs, err = w.db.GetBlockStaker(uint32(height)); err != nil {
+				return nil, errors.Annotatef(err, "GetBlockStaker %v", height)
+			}
+			if blockType != "" && (bs == nil || bs.ProofOfStake != (blockType == BlockTypePoS)) {
+				continue
+			}
+		}
+		bi, err := w.db.GetBlockInfo(uint32(height))
 		if err != nil {
 			return nil, err
 		}
 		if bi == nil {
-			r.Blocks = r.Blocks[:i]
 			break
 		}
-		r.Blocks[i-from] = *bi
+		r.Blocks = append(r.Blocks, w.blockSummary(bi, bs))
 	}
 	glog.Info("GetBlocks page ", page, " finished in ", time.Since(start))
 	return r, nil
 }
 
+// GetStakers returns the number of blocks produced by the stakers in the range of blocks given by heights or by time (the to time is exclusive),
+// without the range the last defaultStakersBlocks blocks are used
+func (w *Worker) GetStakers(fromHeight, toHeight string, fromTime, toTime time.Time) (*Stakers, error) {
+	start := time.Now()
+	if !w.chainParser.SupportsCoinstake() {
+		return nil, NewAPIError("Stakers are not supported", true)
+	}
+	higher, err := w.heightOrBestHeight(toHeight)
+	if err != nil {
+		return nil, err
+	}
+	var lower uint32
+	if fromHeight != "" {
+		if lower, err = w.heightOrBestHeight(fromHeight); err != nil {
+			return nil, err
+		}
+	} else if higher >= defaultStakersBlocks {
+		lower = higher - defaultStakersBlocks + 1
+	}
+	if !fromTime.IsZero() || !toTime.IsZero() {
+		fh, th, err := w.balanceHistoryHeightsFromTo(fromTime, toTime)
+		if err != nil {
+			return nil, err
+		}
+		if !fromTime.IsZero() {
+			lower = fh
+		}
+		if !toTime.IsZero() && th <= higher {
+			if th == 0 {
+				return &Stakers{Stakers: []StakerBlocks{}}, nil
+			}
+			higher = th - 1
+		}
+	}
+	r := &Stakers{
+		FromHeight: lower,
+		ToHeight:   higher,
+		Stakers:    []StakerBlocks{},
+	}
+	if lower > higher {
+		return r, nil
+	}
+	stakers := make(map[string]int)
+	if err = w.db.GetBlockStakers(lower, higher, func(bs *db.BlockStaker) error {
+		if !bs.ProofOfStake {
+			r.PowBlocks++
+			return nil
+		}
+		r.PosBlocks++
+		if a := w.stakerAddress(bs.Staker); a != "" {
+			stakers[a]++
+		} else {
+			r.UnknownStakerBlocks++
+		}
+		return nil
+	}); err != nil {
+		return nil, errors.Annotatef(err, "GetBlockStakers %v-%v", lower, higher)
+	}
+	for a, n := range stakers {
+		r.Stakers = append(r.Stakers, StakerBlocks{Address: a, Blocks: n})
+	}
+	sort.Slice(r.Stakers, func(i, j int) bool {
+		if r.Stakers[i].Blocks != r.Stakers[j].Blocks {
+			return r.Stakers[i].Blocks > r.Stakers[j].Blocks
+		}
+		return r.Stakers[i].Address < r.Stakers[j].Address
+	})
+	glog.Info("GetStakers ", lower, "-", higher, " finished in ", time.Since(start))
+	return r, nil
+}
+
 // getFiatRatesResult checks if CurrencyRatesTicker contains all necessary data and returns formatted result
 func (w *Worker) getFiatRatesResult(currency string, ticker *db.CurrencyRatesTicker) (*db.ResultTickerAsString, error) {
 	rates := make(map[string]json.Number, 2)
@@ -1347,7 +2233,7 @@ func (w *Worker) GetFeeStats(bid string) (*FeeStats, error) {
 		}
 
 		// Get values of TX inputs and outputs
-		txAddresses, err := w.db.GetTxAddresses(txid)
+		txAddresses, err := w.getTxAddresses(txid)
 		if err != nil {
 			return nil, errors.Annotatef(err, "GetTxAddresses")
 		}
@@ -1445,6 +2331,19 @@ func (w *Worker) GetBlock(bid string, page int, txsOnPage int) (*Block, error) {
 	}
 	txs = txs[:txi]
 	bi.Txids = nil
+	var pivxSpecific *PivxBlockSpecific
+	if w.chainParser.SupportsCoinstake() {
+		bd, err := w.chain.GetPosBlockData(bi.Hash)
+		if err != nil {
+			return nil, errors.Annotatef(err, "GetPosBlockData %v", bi.Hash)
+		}
+		pivxSpecific = &PivxBlockSpecific{
+			AccumulatorCheckpoint: bd.AccumulatorCheckpoint,
+			BlockSignature:        bd.BlockSignature,
+			ProofOfStake:          bd.ProofOfStake,
+			Staker:                bd.Staker,
+		}
+	}
 	glog.Info("GetBlock ", bid, ", page ", page, " finished in ", time.Since(start))
 	return &Block{
 		Paging: pg,
@@ -1462,6 +2361,7 @@ func (w *Worker) GetBlock(bid string, page int, txsOnPage int) (*Block, error) {
 			Nonce:         string(bi.Nonce),
 			Txids:         bi.Txids,
 			Version:       bi.Version,
+			PivxSpecific:  pivxSpecific,
 		},
 		TxCount:      txCount,
 		Transactions: txs,
@@ -1568,6 +2468,7 @@ func (w *Worker) GetSystemInfo(internal bool) (*SystemInfo, error) {
 		DbSize:            w.db.DatabaseSizeOnDisk(),
 		DbSizeFromColumns: internalDBSize,
 		DbColumns:         columnStats,
+		PrunedHeight:      w.is.GetPrunedHeight(),
 		About:             Text.BlockbookAbout,
 	}
 	backendInfo := &BackendInfo{
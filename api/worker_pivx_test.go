@@ -0,0 +1,95 @@
+// +build unittest
+
+package api
+
+import (
+	"blockbook/bchain"
+	"blockbook/bchain/coins/btc"
+	"blockbook/bchain/coins/pivx"
+	"blockbook/db"
+	"blockbook/tests/dbtestdata"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+func setupPivxWorker(t *testing.T) (*Worker, func()) {
+	tmp, err := ioutil.TempDir("", "testdb")
+	if err != nil {
+		t.Fatal(err)
+	}
+	parser := pivx.NewPivXParser(pivx.GetChainParams("test"), &btc.Configuration{BlockAddressesToKeep: 4})
+	d, err := db.NewRocksDB(tmp, 100000, -1, parser, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	is, err := d.LoadInternalState("fakecoin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	d.SetInternalState(is)
+	for _, b := range []*bchain.Block{
+		dbtestdata.GetTestPivxBlock1(parser),
+		dbtestdata.GetTestPivxBlock2(parser),
+		dbtestdata.GetTestPivxBlock3(parser),
+	} {
+		if err := d.ConnectBlock(b); err != nil {
+			t.Fatal(err)
+		}
+	}
+	is.FinishedSync(1002)
+	w := &Worker{
+		db:          d,
+		chainParser: parser,
+		chainType:   bchain.ChainBitcoinType,
+		is:          is,
+	}
+	return w, func() {
+		if err := d.Close(); err != nil {
+			t.Fatal(err)
+		}
+		os.RemoveAll(tmp)
+	}
+}
+
+func Test_getAddrDescUtxo_ColdStaking(t *testing.T) {
+	w, done := setupPivxWorker(t)
+	defer done()
+	tests := []struct {
+		name     string
+		addrDesc string
+		want     string
+	}{
+		{
+			name:     "owner",
+			addrDesc: dbtestdata.PivxScriptOwner,
+			want:     `[{"txid":"` + dbtestdata.PivxTxidB3T2 + `","vout":1,"value":"5200000000","height":1002,"confirmations":1}]`,
+		},
+		{
+			name:     "staker",
+			addrDesc: dbtestdata.PivxAddrDescStaker,
+			want:     `[{"txid":"` + dbtestdata.PivxTxidB3T2 + `","vout":1,"value":"5200000000","height":1002,"confirmations":1,"delegated":true}]`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			addrDesc, err := hex.DecodeString(tt.addrDesc)
+			if err != nil {
+				t.Fatal(err)
+			}
+			utxos, err := w.getAddrDescUtxo(addrDesc, nil, true, false)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := json.Marshal(utxos)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("getAddrDescUtxo() = %v, want %v", string(got), tt.want)
+			}
+		})
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"math/big"
 	"sort"
+	"strings"
 	"sync"
 	"time"
 
@@ -16,6 +17,9 @@ import (
 const defaultAddressesGap = 20
 const maxAddressesGap = 10000
 
+// maxAccountAddresses is the maximum number of addresses of an account composed of a list of addresses
+const maxAccountAddresses = 1000
+
 const txInput = 1
 const txOutput = 2
 
@@ -58,6 +62,7 @@ type xpubData struct {
 	gap             int
 	accessed        int64
 	basePath        string
+	descriptor      *bchain.OutputDescriptor
 	dataHeight      uint32
 	dataHash        string
 	txCountEstimate uint32
@@ -140,6 +145,10 @@ func (w *Worker) xpubCheckAndLoadTxids(ad *xpubAddress, filter *AddressFilter, m
 		}
 		return nil
 	}
+	// skip the address without activity in the filtered range of heights, it cannot contribute any txid
+	if filter != nil && (filter.FromHeight > ad.balance.LastHeight || filter.ToHeight != 0 && filter.ToHeight < ad.balance.FirstHeight) {
+		return nil
+	}
 	// load all txids to get paging correctly
 	newTxids, complete, err := w.xpubGetAddressTxids(ad.addrDesc, false, 0, maxHeight, maxInt)
 	if err != nil {
@@ -171,7 +180,22 @@ func (w *Worker) xpubDerivedAddressBalance(data *xpubData, ad *xpubAddress) (boo
 	return false, nil
 }
 
+// IsOutputDescriptor returns true if the xpub parameter is an output descriptor, e.g. wpkh(xpub.../<0;1>/*)
+func IsOutputDescriptor(xpub string) bool {
+	return strings.IndexByte(xpub, '(') >= 0
+}
+
+// xpubDeriveAddresses derives the addresses of the chain of the xpub or of the output descriptor in index range
+func (w *Worker) xpubDeriveAddresses(xpub string, data *xpubData, change int, fromIndex, toIndex uint32) ([]bchain.AddressDescriptor, error) {
+	if data.descriptor != nil {
+		return w.chainParser.DeriveOutputDescriptorAddresses(data.descriptor, change, fromIndex, toIndex)
+	}
+	return w.chainParser.DeriveAddressDescriptorsFromTo(xpub, uint32(change), fromIndex, toIndex)
+}
+
 func (w *Worker) xpubScanAddresses(xpub string, data *xpubData, addresses []xpubAddress, gap int, change int, minDerivedIndex int, fork bool) (int, []xpubAddress, error) {
+	// the address is used if it has a transaction at any height, the gap does not depend on the filter of heights,
+	// FirstHeight and LastHeight of the balance are used only to skip loading txids in xpubCheckAndLoadTxids
 	// rescan known addresses
 	lastUsed := 0
 	for i := range addresses {
@@ -199,7 +223,7 @@ func (w *Worker) xpubScanAddresses(xpub string, data *xpubData, addresses []xpub
 		if to < minDerivedIndex {
 			to = minDerivedIndex
 		}
-		descriptors, err := w.chainParser.DeriveAddressDescriptorsFromTo(xpub, uint32(change), uint32(from), uint32(to))
+		descriptors, err := w.xpubDeriveAddresses(xpub, data, change, uint32(from), uint32(to))
 		if err != nil {
 			return 0, nil, err
 		}
@@ -235,7 +259,7 @@ func (w *Worker) tokenFromXpubAddress(data *xpubData, ad *xpubAddress, changeInd
 			totalReceived = ad.balance.ReceivedSat()
 		}
 	}
-	return Token{
+	t := Token{
 		Type:             XPUBAddressTokenType,
 		Name:             address,
 		Decimals:         w.chainParser.AmountDecimals(),
@@ -243,8 +267,18 @@ func (w *Worker) tokenFromXpubAddress(data *xpubData, ad *xpubAddress, changeInd
 		TotalReceivedSat: (*Amount)(totalReceived),
 		TotalSentSat:     (*Amount)(totalSent),
 		Transfers:        transfers,
-		Path:             fmt.Sprintf("%s/%d/%d", data.basePath, changeIndex, index),
 	}
+	// the addresses of an account composed of a list of addresses are not derived and do not have any path
+	if data.basePath == "" {
+		t.Type = AddressTokenType
+	} else {
+		change := uint32(changeIndex)
+		if data.descriptor != nil {
+			change = data.descriptor.ChangeIndexes[changeIndex]
+		}
+		t.Path = fmt.Sprintf("%s/%d/%d", data.basePath, change, index)
+	}
+	return t
 }
 
 func evictXpubCacheItems() {
@@ -302,10 +336,18 @@ func (w *Worker) getXpubData(xpub string, page int, txsOnPage int, option Accoun
 		fork := false
 		if !found || data.gap != gap {
 			data = xpubData{gap: gap}
-			data.basePath, err = w.chainParser.DerivationBasePath(xpub)
-			if err != nil {
-				glog.Warning("DerivationBasePath error", err)
-				data.basePath = "unknown"
+			if IsOutputDescriptor(xpub) {
+				data.descriptor, err = w.chainParser.ParseOutputDescriptor(xpub)
+				if err != nil {
+					return nil, 0, NewAPIError(fmt.Sprintf("Invalid output descriptor, %v", err), true)
+				}
+				data.basePath = data.descriptor.BasePath
+			} else {
+				data.basePath, err = w.chainParser.DerivationBasePath(xpub)
+				if err != nil {
+					glog.Warning("DerivationBasePath error", err)
+					data.basePath = "unknown"
+				}
 			}
 		} else {
 			hash, err := w.db.GetBlockHash(data.dataHeight)
@@ -329,9 +371,12 @@ func (w *Worker) getXpubData(xpub string, page int, txsOnPage int, option Accoun
 			if err != nil {
 				return nil, 0, err
 			}
-			_, data.changeAddresses, err = w.xpubScanAddresses(xpub, &data, data.changeAddresses, gap, 1, lastUsedIndex, fork)
-			if err != nil {
-				return nil, 0, err
+			// the output descriptor may specify only one chain
+			if data.descriptor == nil || len(data.descriptor.ChangeIndexes) > 1 {
+				_, data.changeAddresses, err = w.xpubScanAddresses(xpub, &data, data.changeAddresses, gap, 1, lastUsedIndex, fork)
+				if err != nil {
+					return nil, 0, err
+				}
 			}
 		}
 		if option >= AccountDetailsTxidHistory {
@@ -361,10 +406,73 @@ func (w *Worker) GetXpubAddress(xpub string, page int, txsOnPage int, option Acc
 	if page < 0 {
 		page = 0
 	}
-	type mempoolMap struct {
-		tx          *Tx
-		inputOutput byte
+	data, bestheight, err := w.getXpubData(xpub, page, txsOnPage, option, filter, gap)
+	if err != nil {
+		return nil, err
+	}
+	addr, err := w.xpubDataToAddress(data, xpub, bestheight, page, txsOnPage, option, filter)
+	if err != nil {
+		return nil, err
+	}
+	glog.Info("GetXpubAddress ", xpub[:16], ", ", len(data.addresses)+len(data.changeAddresses), " derived addresses, ", addr.Txs, " confirmed txs, finished in ", time.Since(start))
+	return addr, nil
+}
+
+// GetAddressesAccount computes value and gets transactions of the account composed of the given addresses
+func (w *Worker) GetAddressesAccount(addresses []string, page int, txsOnPage int, option AccountDetails, filter *AddressFilter) (*Address, error) {
+	start := time.Now()
+	if w.chainType != bchain.ChainBitcoinType {
+		return nil, NewAPIError("Accounts composed of multiple addresses are supported only for Bitcoin type coins", true)
+	}
+	if len(addresses) == 0 {
+		return nil, NewAPIError("Missing addresses", true)
+	}
+	if len(addresses) > maxAccountAddresses {
+		return nil, NewAPIError(fmt.Sprintf("Too many addresses, maximum is %d", maxAccountAddresses), true)
 	}
+	page--
+	if page < 0 {
+		page = 0
+	}
+	bestheight, _, err := w.db.GetBestBlock()
+	if err != nil {
+		return nil, errors.Annotatef(err, "GetBestBlock")
+	}
+	// the account is not cached, the addresses are loaded the same way as the derived addresses of an xpub
+	var data xpubData
+	normalized := make([]string, 0, len(addresses))
+	unique := make(map[string]struct{}, len(addresses))
+	for _, a := range addresses {
+		addrDesc, address, err := w.getAddrDescAndNormalizeAddress(a)
+		if err != nil {
+			return nil, err
+		}
+		if _, found := unique[string(addrDesc)]; found {
+			continue
+		}
+		unique[string(addrDesc)] = struct{}{}
+		ad := xpubAddress{addrDesc: addrDesc}
+		if _, err = w.xpubDerivedAddressBalance(&data, &ad); err != nil {
+			return nil, err
+		}
+		if option >= AccountDetailsTxidHistory {
+			if err = w.xpubCheckAndLoadTxids(&ad, filter, bestheight, (page+1)*txsOnPage); err != nil {
+				return nil, err
+			}
+		}
+		data.addresses = append(data.addresses, ad)
+		normalized = append(normalized, address)
+	}
+	addr, err := w.xpubDataToAddress(&data, strings.Join(normalized, ","), bestheight, page, txsOnPage, option, filter)
+	if err != nil {
+		return nil, err
+	}
+	glog.Info("GetAddressesAccount ", len(data.addresses), " addresses, ", addr.Txs, " confirmed txs, finished in ", time.Since(start))
+	return addr, nil
+}
+
+// xpubDataToAddress merges the balances and the transactions of the addresses of the account, page is indexed from 0
+func (w *Worker) xpubDataToAddress(data *xpubData, addrStr string, bestheight uint32, page int, txsOnPage int, option AccountDetails, filter *AddressFilter) (*Address, error) {
 	var (
 		txc            xpubTxids
 		txmMap         map[string]*Tx
@@ -373,11 +481,10 @@ func (w *Worker) GetXpubAddress(xpub string, page int, txsOnPage int, option Acc
 		txids          []string
 		pg             Paging
 		filtered       bool
-		err            error
 		uBalSat        big.Int
 		unconfirmedTxs int
 	)
-	data, bestheight, err := w.getXpubData(xpub, page, txsOnPage, option, filter, gap)
+	cursor, err := parseHistoryCursor(filter.Cursor)
 	if err != nil {
 		return nil, err
 	}
@@ -432,8 +539,8 @@ func (w *Worker) GetXpubAddress(xpub string, page int, txsOnPage int, option Acc
 						}
 						uBalSat.Add(&uBalSat, tx.getAddrVoutValue(ad.addrDesc))
 						uBalSat.Sub(&uBalSat, tx.getAddrVinValue(ad.addrDesc))
-						// mempool txs are returned only on the first page, uniquely and filtered
-						if page == 0 && !foundTx && (txidFilter == nil || txidFilter(&txid, ad)) {
+						// mempool txs are returned only on the first page without cursor, uniquely and filtered
+						if page == 0 && cursor == nil && !foundTx && (txidFilter == nil || txidFilter(&txid, ad)) {
 							mempoolEntries = append(mempoolEntries, bchain.MempoolTxidEntry{Txid: txid.txid, Time: uint32(tx.Blocktime)})
 						}
 					}
@@ -479,15 +586,35 @@ func (w *Worker) GetXpubAddress(xpub string, page int, txsOnPage int, option Acc
 		if filtered {
 			totalResults = -1
 		}
+		// positions of the txids in the history, the index is counted within the block
+		positions := make([]historyCursor, len(txc))
+		for i := range txc {
+			positions[i] = historyCursor{height: txc[i].height, index: 1}
+			if i > 0 && txc[i-1].height == txc[i].height {
+				positions[i].index = positions[i-1].index + 1
+			}
+		}
 		var from, to int
-		pg, from, to, page = computePaging(len(txc), page, txsOnPage)
-		if len(txc) >= txsOnPage {
-			if totalResults < 0 {
-				pg.TotalPages = -1
-			} else {
-				pg, _, _, _ = computePaging(totalResults, page, txsOnPage)
+		if cursor != nil {
+			from = sort.Search(len(txc), func(i int) bool { return cursor.after(positions[i].height, positions[i].index) })
+			to = from + txsOnPage
+			if to > len(txc) {
+				to = len(txc)
+			}
+			pg = Paging{ItemsOnPage: txsOnPage}
+		} else {
+			pg, from, to, page = computePaging(len(txc), page, txsOnPage)
+			if len(txc) >= txsOnPage {
+				if totalResults < 0 {
+					pg.TotalPages = -1
+				} else {
+					pg, _, _, _ = computePaging(totalResults, page, txsOnPage)
+				}
 			}
 		}
+		if to > from && to < len(txc) {
+			pg.NextCursor = positions[to-1].String()
+		}
 		// get confirmed transactions
 		for i := from; i < to; i++ {
 			xpubTxid := &txc[i]
@@ -532,7 +659,7 @@ func (w *Worker) GetXpubAddress(xpub string, page int, txsOnPage int, option Acc
 	totalReceived.Add(&data.balanceSat, &data.sentSat)
 	addr := Address{
 		Paging:                pg,
-		AddrStr:               xpub,
+		AddrStr:               addrStr,
 		BalanceSat:            (*Amount)(&data.balanceSat),
 		TotalReceivedSat:      (*Amount)(&totalReceived),
 		TotalSentSat:          (*Amount)(&data.sentSat),
@@ -545,7 +672,6 @@ func (w *Worker) GetXpubAddress(xpub string, page int, txsOnPage int, option Acc
 		Tokens:                tokens,
 		XPubAddresses:         xpubAddresses,
 	}
-	glog.Info("GetXpubAddress ", xpub[:16], ", ", len(data.addresses)+len(data.changeAddresses), " derived addresses, ", txCount, " confirmed txs, finished in ", time.Since(start))
 	return &addr, nil
 }
 
@@ -594,7 +720,10 @@ func (w *Worker) GetXpubUtxo(xpub string, onlyConfirmed bool, gap int) (Utxos, e
 func (w *Worker) GetXpubBalanceHistory(xpub string, fromTime, toTime time.Time, fiat string, gap int) (BalanceHistories, error) {
 	bhs := make(BalanceHistories, 0)
 	start := time.Now()
-	fromUnix, fromHeight, toUnix, toHeight := w.balanceHistoryHeightsFromTo(fromTime, toTime)
+	fromHeight, toHeight, err := w.balanceHistoryHeightsFromTo(fromTime, toTime)
+	if err != nil {
+		return nil, err
+	}
 	if fromHeight >= toHeight {
 		return bhs, nil
 	}
@@ -612,7 +741,7 @@ func (w *Worker) GetXpubBalanceHistory(xpub string, fromTime, toTime time.Time,
 			ad := &da[i]
 			txids := ad.txids
 			for txi := len(txids) - 1; txi >= 0; txi-- {
-				bh, err := w.balanceHistoryForTxid(ad.addrDesc, txids[txi].txid, fromUnix, toUnix)
+				bh, err := w.balanceHistoryForTxid(ad.addrDesc, txids[txi].txid, fromHeight, toHeight)
 				if err != nil {
 					return nil, err
 				}
@@ -58,3 +58,18 @@ func (b *BaseChain) EthereumTypeGetErc20ContractInfo(contractDesc AddressDescrip
 func (b *BaseChain) EthereumTypeGetErc20ContractBalance(addrDesc, contractDesc AddressDescriptor) (*big.Int, error) {
 	return nil, errors.New("Not supported")
 }
+
+// GetBudgetProjection is not supported
+func (b *BaseChain) GetBudgetProjection() ([]BudgetProposal, error) {
+	return nil, errors.New("Not supported")
+}
+
+// GetPosBlockData is not supported
+func (b *BaseChain) GetPosBlockData(hash string) (*PosBlockData, error) {
+	return nil, errors.New("Not supported")
+}
+
+// GetBudgetProposals is not supported
+func (b *BaseChain) GetBudgetProposals() ([]BudgetProposal, error) {
+	return nil, errors.New("Not supported")
+}
@@ -294,6 +294,66 @@ func (p *BaseParser) DeriveAddressDescriptorsFromTo(xpub string, change uint32,
 	return nil, errors.New("Not supported")
 }
 
+// ParseOutputDescriptor is unsupported
+func (p *BaseParser) ParseOutputDescriptor(descriptor string) (*OutputDescriptor, error) {
+	return nil, errors.New("Not supported")
+}
+
+// DeriveOutputDescriptorAddresses is unsupported
+func (p *BaseParser) DeriveOutputDescriptorAddresses(descriptor *OutputDescriptor, chain int, fromIndex uint32, toIndex uint32) ([]AddressDescriptor, error) {
+	return nil, errors.New("Not supported")
+}
+
+// GetShieldedTxData returns nil, by default the transactions do not have shielded part
+func (p *BaseParser) GetShieldedTxData(tx *Tx) (*ShieldedTxData, error) {
+	return nil, nil
+}
+
+// GetSpecialTxData returns nil, by default the chain does not have special transactions
+func (p *BaseParser) GetSpecialTxData(tx *Tx) (*SpecialTxData, error) {
+	return nil, nil
+}
+
+// GetColdStakingAddrDescs returns nil owner and staker, by default cold staking is not supported
+func (p *BaseParser) GetColdStakingAddrDescs(addrDesc AddressDescriptor) (AddressDescriptor, AddressDescriptor) {
+	return nil, nil
+}
+
+// IsColdStakingStakerAddrDesc returns false, by default cold staking is not supported
+func (p *BaseParser) IsColdStakingStakerAddrDesc(addrDesc AddressDescriptor) bool {
+	return false
+}
+
+// GetOPReturnData returns nil, by default the outputs do not carry OP_RETURN data
+func (p *BaseParser) GetOPReturnData(addrDesc AddressDescriptor) []byte {
+	return nil
+}
+
+// SupportsCoinstake returns false, by default the chain is not proof of stake
+func (p *BaseParser) SupportsCoinstake() bool {
+	return false
+}
+
+// IsCoinstakeTx returns false, by default the chain is not proof of stake
+func (p *BaseParser) IsCoinstakeTx(tx *Tx) bool {
+	return false
+}
+
+// GetMasternodePaymentVout returns -1, by default the chain does not have masternodes
+func (p *BaseParser) GetMasternodePaymentVout(tx *Tx) int {
+	return -1
+}
+
+// GetZerocoinMintsAndSpends returns nil, by default the chain does not have zerocoin
+func (p *BaseParser) GetZerocoinMintsAndSpends(tx *Tx) ([]int64, []int64) {
+	return nil, nil
+}
+
+// SuperblockCycle returns 0, by default the chain does not have superblocks
+func (p *BaseParser) SuperblockCycle() uint32 {
+	return 0
+}
+
 // EthereumTypeGetErc20FromTx is unsupported
 func (p *BaseParser) EthereumTypeGetErc20FromTx(tx *Tx) ([]Erc20Transfer, error) {
 	return nil, errors.New("Not supported")
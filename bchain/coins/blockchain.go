@@ -304,6 +304,21 @@ func (c *blockChainWithMetrics) EthereumTypeGetErc20ContractBalance(addrDesc, co
 	return c.b.EthereumTypeGetErc20ContractBalance(addrDesc, contractDesc)
 }
 
+func (c *blockChainWithMetrics) GetBudgetProjection() (v []bchain.BudgetProposal, err error) {
+	defer func(s time.Time) { c.observeRPCLatency("GetBudgetProjection", s, err) }(time.Now())
+	return c.b.GetBudgetProjection()
+}
+
+func (c *blockChainWithMetrics) GetPosBlockData(hash string) (v *bchain.PosBlockData, err error) {
+	defer func(s time.Time) { c.observeRPCLatency("GetPosBlockData", s, err) }(time.Now())
+	return c.b.GetPosBlockData(hash)
+}
+
+func (c *blockChainWithMetrics) GetBudgetProposals() (v []bchain.BudgetProposal, err error) {
+	defer func(s time.Time) { c.observeRPCLatency("GetBudgetProposals", s, err) }(time.Now())
+	return c.b.GetBudgetProposals()
+}
+
 type mempoolWithMetrics struct {
 	mempool bchain.Mempool
 	m       *common.Metrics
@@ -117,6 +117,33 @@ func (p *BitcoinParser) addressToOutputScript(address string) ([]byte, error) {
 
 // TryParseOPReturn tries to process OP_RETURN script and return its string representation
 func (p *BitcoinParser) TryParseOPReturn(script []byte) string {
+	if data := opReturnData(script); data != nil {
+		var ed string
+
+		ed = p.tryParseOmni(data)
+		if ed != "" {
+			return ed
+		}
+
+		isASCII := true
+		for _, c := range data {
+			if c < 32 || c > 127 {
+				isASCII = false
+				break
+			}
+		}
+		if isASCII {
+			ed = "(" + string(data) + ")"
+		} else {
+			ed = hex.EncodeToString(data)
+		}
+		return "OP_RETURN " + ed
+	}
+	return ""
+}
+
+// opReturnData returns the data of the OP_RETURN script or nil if the script is not OP_RETURN with the data
+func opReturnData(script []byte) []byte {
 	if len(script) > 1 && script[0] == txscript.OP_RETURN {
 		// trying 2 variants of OP_RETURN data
 		// 1) OP_RETURN OP_PUSHDATA1 <datalen> <data>
@@ -135,29 +162,15 @@ func (p *BitcoinParser) TryParseOPReturn(script []byte) string {
 			data = script[2:]
 		}
 		if l == len(data) {
-			var ed string
-
-			ed = p.tryParseOmni(data)
-			if ed != "" {
-				return ed
-			}
-
-			isASCII := true
-			for _, c := range data {
-				if c < 32 || c > 127 {
-					isASCII = false
-					break
-				}
-			}
-			if isASCII {
-				ed = "(" + string(data) + ")"
-			} else {
-				ed = hex.EncodeToString(data)
-			}
-			return "OP_RETURN " + ed
+			return data
 		}
 	}
-	return ""
+	return nil
+}
+
+// GetOPReturnData returns the data carried by the OP_RETURN output, the address descriptor of the output is its script
+func (p *BitcoinParser) GetOPReturnData(addrDesc bchain.AddressDescriptor) []byte {
+	return opReturnData(addrDesc)
 }
 
 var omniCurrencyMap = map[uint32]string{
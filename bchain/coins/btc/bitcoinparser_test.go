@@ -258,6 +258,47 @@ func TestGetAddressesFromAddrDesc(t *testing.T) {
 	}
 }
 
+func TestGetOPReturnData(t *testing.T) {
+	tests := []struct {
+		name   string
+		script string
+		want   string
+	}{
+		{
+			name:   "OP_RETURN",
+			script: "6a072020f1686f6a20",
+			want:   "2020f1686f6a20",
+		},
+		{
+			name:   "OP_RETURN OP_PUSHDATA1",
+			script: "6a4c0b446c6f7568792074657874",
+			want:   "446c6f7568792074657874",
+		},
+		{
+			name:   "OP_RETURN invalid length",
+			script: "6a082020f1686f6a20",
+			want:   "",
+		},
+		{
+			name:   "P2PKH",
+			script: "76a914be027bf3eac907bd4ac8cb9c5293b6f37662722088ac",
+			want:   "",
+		},
+	}
+
+	parser := NewBitcoinParser(GetChainParams("main"), &Configuration{})
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, _ := hex.DecodeString(tt.script)
+			got := hex.EncodeToString(parser.GetOPReturnData(b))
+			if got != tt.want {
+				t.Errorf("GetOPReturnData() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
 var (
 	testTx1, testTx2 bchain.Tx
 
@@ -0,0 +1,395 @@
+package btc
+
+import (
+	"blockbook/bchain"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/juju/errors"
+	"github.com/martinboehm/btcutil"
+	"github.com/martinboehm/btcutil/hdkeychain"
+)
+
+// output descriptors
+// the descriptors of the accounts with ranged extended public keys are supported, i.e. pkh(KEY), wpkh(KEY), sh(wpkh(KEY))
+// and multi(k,KEY,...) or sortedmulti(k,KEY,...), bare or wrapped in sh(), wsh() or sh(wsh())
+// KEY is an extended public key with an optional origin [fingerprint/path] followed by non hardened derivation steps
+// and the range /*, the last step before the range is the chain, it can be the multipath step <0;1> defined by BIP389
+// specifying the receiving and the change chain
+
+const descriptorInputCharset = "0123456789()[],'/*abcdefgh@:$%{}IJKLMNOPQRSTUVWXYZ&+-.;<=>?!^_|~ijklmnopqrstuvwxyzABCDEFGH`#\"\\ "
+const descriptorChecksumCharset = "qpzry9x8gf2tvdw0s3jn54khce6mua7l"
+
+var descriptorChecksumGenerator = [5]uint64{0xf5dee51989, 0xa9fdca3312, 0x1bab10e32d, 0x3706b1677a, 0x644d626ffd}
+
+type descriptorType int
+
+const (
+	descriptorPKH descriptorType = iota
+	descriptorWPKH
+	descriptorSHWPKH
+	descriptorMulti
+	descriptorSHMulti
+	descriptorWSHMulti
+	descriptorSHWSHMulti
+)
+
+type descriptorKey struct {
+	extKey *hdkeychain.ExtendedKey
+	// originPath is the derivation path of the extended key
+	originPath string
+	// path are the derivation steps from the extended key to the chain
+	path []uint32
+	// chains are the alternative derivation steps of the chains
+	chains []uint32
+}
+
+type parsedDescriptor struct {
+	typ       descriptorType
+	threshold int
+	sorted    bool
+	keys      []descriptorKey
+}
+
+func descriptorPolymod(c uint64, val int) uint64 {
+	c0 := c >> 35
+	c = (c&0x7ffffffff)<<5 ^ uint64(val)
+	for i := uint(0); i < 5; i++ {
+		if (c0>>i)&1 != 0 {
+			c ^= descriptorChecksumGenerator[i]
+		}
+	}
+	return c
+}
+
+// descriptorChecksum computes the checksum of the descriptor defined by BIP380
+func descriptorChecksum(desc string) (string, error) {
+	c := uint64(1)
+	cls := 0
+	clsCount := 0
+	for _, ch := range desc {
+		pos := strings.IndexRune(descriptorInputCharset, ch)
+		if pos < 0 {
+			return "", errors.Errorf("Invalid character %q in descriptor", ch)
+		}
+		c = descriptorPolymod(c, pos&31)
+		cls = cls*3 + pos>>5
+		clsCount++
+		if clsCount == 3 {
+			c = descriptorPolymod(c, cls)
+			cls = 0
+			clsCount = 0
+		}
+	}
+	if clsCount > 0 {
+		c = descriptorPolymod(c, cls)
+	}
+	for i := 0; i < 8; i++ {
+		c = descriptorPolymod(c, 0)
+	}
+	c ^= 1
+	checksum := make([]byte, 8)
+	for i := range checksum {
+		checksum[i] = descriptorChecksumCharset[(c>>(5*uint(7-i)))&31]
+	}
+	return string(checksum), nil
+}
+
+// unwrapDescriptor returns the argument of the descriptor expression name(argument)
+func unwrapDescriptor(s string, name string) (string, bool) {
+	if strings.HasPrefix(s, name+"(") && strings.HasSuffix(s, ")") {
+		return s[len(name)+1 : len(s)-1], true
+	}
+	return "", false
+}
+
+func parseDescriptorStep(step string) (uint32, error) {
+	n, err := strconv.ParseUint(step, 10, 31)
+	if err != nil {
+		return 0, errors.Errorf("Invalid derivation step %v, only non hardened steps are supported after the extended key", step)
+	}
+	return uint32(n), nil
+}
+
+// parseDescriptorOrigin converts the key origin fingerprint/path to the derivation path
+func parseDescriptorOrigin(origin string) (string, error) {
+	steps := strings.Split(origin, "/")
+	if fp, err := hex.DecodeString(steps[0]); err != nil || len(fp) != 4 {
+		return "", errors.Errorf("Invalid key origin fingerprint %v", steps[0])
+	}
+	path := "m"
+	for _, step := range steps[1:] {
+		hardened := strings.HasSuffix(step, "'") || strings.HasSuffix(step, "h")
+		if hardened {
+			step = step[:len(step)-1]
+		}
+		if _, err := strconv.ParseUint(step, 10, 31); err != nil {
+			return "", errors.Errorf("Invalid key origin step %v", step)
+		}
+		path += "/" + step
+		if hardened {
+			path += "'"
+		}
+	}
+	return path, nil
+}
+
+// descriptorKeyPath returns the derivation path of the key without origin, it is known only for the master key
+func descriptorKeyPath(extKey *hdkeychain.ExtendedKey) string {
+	if extKey.Depth() == 0 {
+		return "m"
+	}
+	var c string
+	cn := extKey.ChildNum()
+	if cn >= hdkeychain.HardenedKeyStart {
+		cn -= hdkeychain.HardenedKeyStart
+		c = "'"
+	}
+	return "unknown/" + strconv.Itoa(int(cn)) + c
+}
+
+func (p *BitcoinParser) parseDescriptorKey(pd *parsedDescriptor, s string) error {
+	var key descriptorKey
+	if strings.HasPrefix(s, "[") {
+		i := strings.IndexByte(s, ']')
+		if i < 0 {
+			return errors.Errorf("Invalid key origin in %v", s)
+		}
+		var err error
+		if key.originPath, err = parseDescriptorOrigin(s[1:i]); err != nil {
+			return err
+		}
+		s = s[i+1:]
+	}
+	steps := strings.Split(s, "/")
+	extKey, err := hdkeychain.NewKeyFromString(steps[0], p.Params.Base58CksumHasher)
+	if err != nil {
+		return errors.Annotatef(err, "Invalid extended key %v", steps[0])
+	}
+	if extKey.IsPrivate() {
+		return errors.New("Private keys are not supported")
+	}
+	key.extKey = extKey
+	if key.originPath == "" {
+		key.originPath = descriptorKeyPath(extKey)
+	}
+	if len(steps) < 3 || steps[len(steps)-1] != "*" {
+		return errors.Errorf("Extended key %v must be followed by the chain and the range, e.g. /0/* or /<0;1>/*", steps[0])
+	}
+	for _, step := range steps[1 : len(steps)-2] {
+		n, err := parseDescriptorStep(step)
+		if err != nil {
+			return err
+		}
+		key.path = append(key.path, n)
+	}
+	chain := steps[len(steps)-2]
+	if strings.HasPrefix(chain, "<") && strings.HasSuffix(chain, ">") {
+		for _, step := range strings.Split(chain[1:len(chain)-1], ";") {
+			n, err := parseDescriptorStep(step)
+			if err != nil {
+				return err
+			}
+			key.chains = append(key.chains, n)
+		}
+		if len(key.chains) < 2 {
+			return errors.Errorf("Invalid multipath step %v", chain)
+		}
+	} else {
+		n, err := parseDescriptorStep(chain)
+		if err != nil {
+			return err
+		}
+		key.chains = []uint32{n}
+	}
+	pd.keys = append(pd.keys, key)
+	return nil
+}
+
+func (p *BitcoinParser) parseDescriptorMulti(pd *parsedDescriptor, s string) error {
+	args, ok := unwrapDescriptor(s, "multi")
+	if !ok {
+		if args, ok = unwrapDescriptor(s, "sortedmulti"); !ok {
+			return errors.Errorf("Unsupported descriptor %v", s)
+		}
+		pd.sorted = true
+	}
+	a := strings.Split(args, ",")
+	n := len(a) - 1
+	k, err := strconv.Atoi(a[0])
+	if err != nil || k < 1 || k > n {
+		return errors.Errorf("Invalid multisig threshold %v", a[0])
+	}
+	if n > 16 {
+		return errors.Errorf("Too many keys in multisig, %v", n)
+	}
+	pd.threshold = k
+	for _, key := range a[1:] {
+		if err = p.parseDescriptorKey(pd, key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ParseOutputDescriptor parses the output descriptor of an account, the checksum is verified if present
+func (p *BitcoinParser) ParseOutputDescriptor(descriptor string) (*bchain.OutputDescriptor, error) {
+	desc := strings.TrimSpace(descriptor)
+	if i := strings.LastIndexByte(desc, '#'); i >= 0 {
+		checksum, err := descriptorChecksum(desc[:i])
+		if err != nil {
+			return nil, err
+		}
+		if desc[i+1:] != checksum {
+			return nil, errors.Errorf("Invalid descriptor checksum %v, expected %v", desc[i+1:], checksum)
+		}
+		desc = desc[:i]
+	}
+	pd := &parsedDescriptor{}
+	var err error
+	if inner, ok := unwrapDescriptor(desc, "sh"); ok {
+		if key, ok := unwrapDescriptor(inner, "wpkh"); ok {
+			pd.typ = descriptorSHWPKH
+			err = p.parseDescriptorKey(pd, key)
+		} else if multi, ok := unwrapDescriptor(inner, "wsh"); ok {
+			pd.typ = descriptorSHWSHMulti
+			err = p.parseDescriptorMulti(pd, multi)
+		} else {
+			pd.typ = descriptorSHMulti
+			err = p.parseDescriptorMulti(pd, inner)
+		}
+	} else if multi, ok := unwrapDescriptor(desc, "wsh"); ok {
+		pd.typ = descriptorWSHMulti
+		err = p.parseDescriptorMulti(pd, multi)
+	} else if key, ok := unwrapDescriptor(desc, "wpkh"); ok {
+		pd.typ = descriptorWPKH
+		err = p.parseDescriptorKey(pd, key)
+	} else if key, ok := unwrapDescriptor(desc, "pkh"); ok {
+		pd.typ = descriptorPKH
+		err = p.parseDescriptorKey(pd, key)
+	} else {
+		pd.typ = descriptorMulti
+		err = p.parseDescriptorMulti(pd, desc)
+	}
+	if err != nil {
+		return nil, err
+	}
+	// BIP389, all keys must have the same number of chains, the i-th chain of the descriptor uses the i-th chain of each key
+	chains := pd.keys[0].chains
+	for i := range pd.keys {
+		if len(pd.keys[i].chains) != len(chains) {
+			return nil, errors.New("All keys of the descriptor must have the same number of chains")
+		}
+	}
+	if len(chains) > 2 {
+		return nil, errors.New("At most two chains, the receiving and the change chain, are supported")
+	}
+	basePath := pd.keys[0].originPath
+	for _, step := range pd.keys[0].path {
+		basePath += "/" + strconv.Itoa(int(step))
+	}
+	return &bchain.OutputDescriptor{
+		Descriptor:    desc,
+		BasePath:      basePath,
+		ChangeIndexes: append([]uint32(nil), chains...),
+		Data:          pd,
+	}, nil
+}
+
+func p2shScript(redeemScript []byte) []byte {
+	script := append([]byte{0xa9, 0x14}, btcutil.Hash160(redeemScript)...)
+	return append(script, 0x87)
+}
+
+func p2wshScript(witnessScript []byte) []byte {
+	hash := sha256.Sum256(witnessScript)
+	return append([]byte{0x00, 0x20}, hash[:]...)
+}
+
+func p2wpkhScript(pubKey []byte) []byte {
+	return append([]byte{0x00, 0x14}, btcutil.Hash160(pubKey)...)
+}
+
+func p2pkhScript(pubKey []byte) []byte {
+	script := append([]byte{0x76, 0xa9, 0x14}, btcutil.Hash160(pubKey)...)
+	return append(script, 0x88, 0xac)
+}
+
+// multisigScript returns the script OP_k <pubKey>... OP_n OP_CHECKMULTISIG
+func multisigScript(k int, pubKeys [][]byte) []byte {
+	script := []byte{byte(0x50 + k)}
+	for _, pubKey := range pubKeys {
+		script = append(script, byte(len(pubKey)))
+		script = append(script, pubKey...)
+	}
+	return append(script, byte(0x50+len(pubKeys)), 0xae)
+}
+
+func (pd *parsedDescriptor) script(pubKeys [][]byte) []byte {
+	switch pd.typ {
+	case descriptorPKH:
+		return p2pkhScript(pubKeys[0])
+	case descriptorWPKH:
+		return p2wpkhScript(pubKeys[0])
+	case descriptorSHWPKH:
+		return p2shScript(p2wpkhScript(pubKeys[0]))
+	}
+	if pd.sorted {
+		sort.Slice(pubKeys, func(i, j int) bool { return bytes.Compare(pubKeys[i], pubKeys[j]) < 0 })
+	}
+	multisig := multisigScript(pd.threshold, pubKeys)
+	switch pd.typ {
+	case descriptorSHMulti:
+		return p2shScript(multisig)
+	case descriptorWSHMulti:
+		return p2wshScript(multisig)
+	case descriptorSHWSHMulti:
+		return p2shScript(p2wshScript(multisig))
+	}
+	return multisig
+}
+
+// DeriveOutputDescriptorAddresses derives address descriptors of the chain of the descriptor for addresses in index range
+func (p *BitcoinParser) DeriveOutputDescriptorAddresses(descriptor *bchain.OutputDescriptor, chain int, fromIndex uint32, toIndex uint32) ([]bchain.AddressDescriptor, error) {
+	pd, ok := descriptor.Data.(*parsedDescriptor)
+	if !ok {
+		return nil, errors.New("Descriptor was not parsed by this parser")
+	}
+	if toIndex <= fromIndex {
+		return nil, errors.New("toIndex<=fromIndex")
+	}
+	if chain < 0 || chain >= len(descriptor.ChangeIndexes) {
+		return nil, errors.Errorf("Descriptor does not have chain %d", chain)
+	}
+	chainExtKeys := make([]*hdkeychain.ExtendedKey, len(pd.keys))
+	for i := range pd.keys {
+		var err error
+		k := &pd.keys[i]
+		extKey := k.extKey
+		for _, step := range k.path {
+			if extKey, err = extKey.Child(step); err != nil {
+				return nil, err
+			}
+		}
+		if chainExtKeys[i], err = extKey.Child(k.chains[chain]); err != nil {
+			return nil, err
+		}
+	}
+	ad := make([]bchain.AddressDescriptor, toIndex-fromIndex)
+	pubKeys := make([][]byte, len(chainExtKeys))
+	for index := fromIndex; index < toIndex; index++ {
+		for i, chainExtKey := range chainExtKeys {
+			indexExtKey, err := chainExtKey.Child(index)
+			if err != nil {
+				return nil, err
+			}
+			pubKeys[i] = indexExtKey.PubKeyBytes()
+		}
+		ad[index-fromIndex] = pd.script(pubKeys)
+	}
+	return ad, nil
+}
@@ -0,0 +1,157 @@
+// +build unittest
+
+package btc
+
+import (
+	"reflect"
+	"testing"
+)
+
+func Test_descriptorChecksum(t *testing.T) {
+	tests := []struct {
+		desc string
+		want string
+	}{
+		{
+			desc: "raw(deadbeef)",
+			want: "89f8spxm",
+		},
+		{
+			desc: "sh(multi(2,[00000000/111'/222]xprvA1RpRA33e1JQ7ifknakTFpgNXPmW2YvmhqLQYMmrj4xJXXWYpDPS3xz7iAxn8L39njGVyuoseXzU6rcxFLJ8HFsTjSyQbLYnMpCqE2VbFWc,xprv9uPDJpEQgRQfDcW7BkF7eTya6RPxXeJCqCJGHuCJ4GiRVLzkTXBAJMu2qaMWPrS7AANYqdq6vcBcBUdJCVVFceUvJFjaPdGZ2y9WACViL4L/0))",
+			want: "ggrsrxfy",
+		},
+	}
+	for _, tt := range tests {
+		got, err := descriptorChecksum(tt.desc)
+		if err != nil {
+			t.Errorf("descriptorChecksum() error = %v", err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("descriptorChecksum() = %v, want %v", got, tt.want)
+		}
+	}
+}
+
+func TestBitcoinParser_OutputDescriptor(t *testing.T) {
+	btcMainParser := NewBitcoinParser(GetChainParams("main"), &Configuration{XPubMagic: 76067358, XPubMagicSegwitP2sh: 77429938, XPubMagicSegwitNative: 78792518})
+	tests := []struct {
+		name          string
+		descriptor    string
+		basePath      string
+		changeIndexes []uint32
+		want          [][]string
+		wantErr       bool
+	}{
+		{
+			name:          "pkh single chain",
+			descriptor:    "pkh(xpub6BosfCnifzxcFwrSzQiqu2DBVTshkCXacvNsWGYJVVhhawA7d4R5WSWGFNbi8Aw6ZRc1brxMyWMzG3DSSSSoekkudhUd9yLb6qx39T9nMdj/0/*)#t3qu2qap",
+			basePath:      "unknown/0'",
+			changeIndexes: []uint32{0},
+			want:          [][]string{{"1LqBGSKuX5yYUonjxT5qGfpUsXKYYWeabA"}},
+		},
+		{
+			name:          "sh(wpkh) multipath",
+			descriptor:    "sh(wpkh(ypub6Ww3ibxVfGzLrAH1PNcjyAWenMTbbAosGNB6VvmSEgytSER9azLDWCxoJwW7Ke7icmizBMXrzBx9979FfaHxHcrArf3zbeJJJUZPf663zsP/<0;1>/*))",
+			basePath:      "unknown/0'",
+			changeIndexes: []uint32{0, 1},
+			want:          [][]string{{"37VucYSaXLCAsxYyAPfbSi9eh4iEcbShgf"}, nil},
+		},
+		{
+			name:          "wpkh with origin",
+			descriptor:    "wpkh([73c5da0a/84'/0'/0']zpub6rFR7y4Q2AijBEqTUquhVz398htDFrtymD9xYYfG1m4wAcvPhXNfE3EfH1r1ADqtfSdVCToUG868RvUUkgDKf31mGDtKsAYz2oz2AGutZYs/<0;1>/*)#23uh6s7g",
+			basePath:      "m/84'/0'/0'",
+			changeIndexes: []uint32{0, 1},
+			want:          [][]string{{"bc1qcr8te4kr609gcawutmrza0j4xv80jy8z306fyu"}, {"bc1q8c6fshw2dlwun7ekn9qwf37cu2rn755upcp6el"}},
+		},
+		{
+			name:          "wsh(sortedmulti)",
+			descriptor:    "wsh(sortedmulti(2,[00000001/48'/0'/0'/2']xpub6BosfCnifzxcFwrSzQiqu2DBVTshkCXacvNsWGYJVVhhawA7d4R5WSWGFNbi8Aw6ZRc1brxMyWMzG3DSSSSoekkudhUd9yLb6qx39T9nMdj/<0;1>/*,[00000002/48'/0'/0'/2']xpub6CQdEahwhKRTLYpP6cyb7ZaGb3r4tVdyPX6dC1PfrNuByrCkWDgUkmpD28UdV9QccKgY1ZiAbGv1Fakcg2LxdFVSTNKHcjdRjqhjPK8Trkb/<0;1>/*))#84htelys",
+			basePath:      "m/48'/0'/0'/2'",
+			changeIndexes: []uint32{0, 1},
+			want: [][]string{
+				{"bc1q945ewdxr9nm62q9he59l6nafg3vpwdxvvxsxrf85qq8g9s29ukqqzq9yxx", "bc1q4k9vvqr43w6mjh0uv223l8dk3dm5ry3cl7pw463rd36f25h3qywqx4w0pz"},
+				{"bc1qc5vhcv4y8daznsnmgq65hewm7sa3vd5vwjscczys9w8ufjvyx9tqctlvq3"},
+			},
+		},
+		{
+			name:          "sh(multi)",
+			descriptor:    "sh(multi(1,xpub6BosfCnifzxcFwrSzQiqu2DBVTshkCXacvNsWGYJVVhhawA7d4R5WSWGFNbi8Aw6ZRc1brxMyWMzG3DSSSSoekkudhUd9yLb6qx39T9nMdj/0/*,xpub6CQdEahwhKRTLYpP6cyb7ZaGb3r4tVdyPX6dC1PfrNuByrCkWDgUkmpD28UdV9QccKgY1ZiAbGv1Fakcg2LxdFVSTNKHcjdRjqhjPK8Trkb/0/*))",
+			basePath:      "unknown/0'",
+			changeIndexes: []uint32{0},
+			want:          [][]string{{"3Bb94jbYSsztVRxYtAwZwZ3pH3AH7poJf3"}},
+		},
+		{
+			name:          "sh(wsh(multi))",
+			descriptor:    "sh(wsh(multi(2,xpub6BosfCnifzxcFwrSzQiqu2DBVTshkCXacvNsWGYJVVhhawA7d4R5WSWGFNbi8Aw6ZRc1brxMyWMzG3DSSSSoekkudhUd9yLb6qx39T9nMdj/1/*,xpub6CQdEahwhKRTLYpP6cyb7ZaGb3r4tVdyPX6dC1PfrNuByrCkWDgUkmpD28UdV9QccKgY1ZiAbGv1Fakcg2LxdFVSTNKHcjdRjqhjPK8Trkb/1/*)))",
+			basePath:      "unknown/0'",
+			changeIndexes: []uint32{1},
+			want:          [][]string{{"3FnLQpVGUDGpjCeug6ev9a2mmEDX9UfKpg"}},
+		},
+		{
+			name:       "invalid checksum",
+			descriptor: "pkh(xpub6BosfCnifzxcFwrSzQiqu2DBVTshkCXacvNsWGYJVVhhawA7d4R5WSWGFNbi8Aw6ZRc1brxMyWMzG3DSSSSoekkudhUd9yLb6qx39T9nMdj/0/*)#t3qu2qaq",
+			wantErr:    true,
+		},
+		{
+			name:       "hardened range",
+			descriptor: "pkh(xpub6BosfCnifzxcFwrSzQiqu2DBVTshkCXacvNsWGYJVVhhawA7d4R5WSWGFNbi8Aw6ZRc1brxMyWMzG3DSSSSoekkudhUd9yLb6qx39T9nMdj/0h/*)",
+			wantErr:    true,
+		},
+		{
+			name:       "missing chain",
+			descriptor: "wpkh(xpub6BosfCnifzxcFwrSzQiqu2DBVTshkCXacvNsWGYJVVhhawA7d4R5WSWGFNbi8Aw6ZRc1brxMyWMzG3DSSSSoekkudhUd9yLb6qx39T9nMdj/*)",
+			wantErr:    true,
+		},
+		{
+			name:       "invalid threshold",
+			descriptor: "wsh(multi(3,xpub6BosfCnifzxcFwrSzQiqu2DBVTshkCXacvNsWGYJVVhhawA7d4R5WSWGFNbi8Aw6ZRc1brxMyWMzG3DSSSSoekkudhUd9yLb6qx39T9nMdj/0/*,xpub6CQdEahwhKRTLYpP6cyb7ZaGb3r4tVdyPX6dC1PfrNuByrCkWDgUkmpD28UdV9QccKgY1ZiAbGv1Fakcg2LxdFVSTNKHcjdRjqhjPK8Trkb/0/*))",
+			wantErr:    true,
+		},
+		{
+			name:       "unsupported script",
+			descriptor: "tr(xpub6BosfCnifzxcFwrSzQiqu2DBVTshkCXacvNsWGYJVVhhawA7d4R5WSWGFNbi8Aw6ZRc1brxMyWMzG3DSSSSoekkudhUd9yLb6qx39T9nMdj/0/*)",
+			wantErr:    true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			od, err := btcMainParser.ParseOutputDescriptor(tt.descriptor)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ParseOutputDescriptor() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if err != nil {
+				return
+			}
+			if od.BasePath != tt.basePath {
+				t.Errorf("ParseOutputDescriptor() BasePath = %v, want %v", od.BasePath, tt.basePath)
+			}
+			if !reflect.DeepEqual(od.ChangeIndexes, tt.changeIndexes) {
+				t.Errorf("ParseOutputDescriptor() ChangeIndexes = %v, want %v", od.ChangeIndexes, tt.changeIndexes)
+			}
+			for chain, want := range tt.want {
+				if want == nil {
+					continue
+				}
+				got, err := btcMainParser.DeriveOutputDescriptorAddresses(od, chain, 0, uint32(len(want)))
+				if err != nil {
+					t.Errorf("DeriveOutputDescriptorAddresses() error = %v", err)
+					return
+				}
+				gotAddresses := make([]string, len(got))
+				for i, ad := range got {
+					aa, _, err := btcMainParser.GetAddressesFromAddrDesc(ad)
+					if err != nil || len(aa) != 1 {
+						t.Errorf("DeriveOutputDescriptorAddresses() got incorrect address descriptor %v, error %v", ad, err)
+						return
+					}
+					gotAddresses[i] = aa[0]
+				}
+				if !reflect.DeepEqual(gotAddresses, want) {
+					t.Errorf("DeriveOutputDescriptorAddresses() chain %d = %v, want %v", chain, gotAddresses, want)
+				}
+			}
+		})
+	}
+}
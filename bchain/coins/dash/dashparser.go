@@ -98,3 +98,12 @@ func (p *DashParser) PackTx(tx *bchain.Tx, height uint32, blockTime int64) ([]by
 func (p *DashParser) UnpackTx(buf []byte) (*bchain.Tx, uint32, error) {
 	return p.baseparser.UnpackTx(buf)
 }
+
+// GetMasternodePaymentVout returns index of the masternode payment output or -1
+// masternode payment is the second output of the coinbase transaction, following the miner output
+func (p *DashParser) GetMasternodePaymentVout(tx *bchain.Tx) int {
+	if len(tx.Vin) != 1 || tx.Vin[0].Coinbase == "" || len(tx.Vout) < 2 {
+		return -1
+	}
+	return 1
+}
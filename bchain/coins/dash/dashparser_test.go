@@ -12,6 +12,7 @@ import (
 	"math/big"
 	"path/filepath"
 	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -56,17 +57,17 @@ var testParseBlockTxs = map[int]testBlock{
 		},
 	},
 	// first block with special transactions, invalid for bitcoin parser
-	// 1028160: {
-	// 	size: 2347,
-	// 	time: 1551246710,
-	// 	txs: []string{
-	// 		"71d6975e3b79b52baf26c3269896a34f3bedfb04561c692ffa31f64dada1f9c4",
-	// 		"ed732a404cdfd4e0475a7a016200b7eef191f2c9de0ffdef8a20091c0499299c",
-	// 		"99d0613f82ea1f928bbc98318665adbdf5b40d206bd487fe77d542e86c903f55",
-	// 		"05cbf334a563468d0e378c56b43fb5254ee9c0e35ca8fab5cb242ebd825ae97b",
-	// 		"ee91fae7be36b3b81bc60992e904e1ae91e7dffdd5751ccaef557ba62ea80a4f",
-	// 	},
-	// },
+	1028160: {
+		size: 2347,
+		time: 1551246710,
+		txs: []string{
+			"71d6975e3b79b52baf26c3269896a34f3bedfb04561c692ffa31f64dada1f9c4",
+			"ed732a404cdfd4e0475a7a016200b7eef191f2c9de0ffdef8a20091c0499299c",
+			"99d0613f82ea1f928bbc98318665adbdf5b40d206bd487fe77d542e86c903f55",
+			"05cbf334a563468d0e378c56b43fb5254ee9c0e35ca8fab5cb242ebd825ae97b",
+			"ee91fae7be36b3b81bc60992e904e1ae91e7dffdd5751ccaef557ba62ea80a4f",
+		},
+	},
 }
 
 func helperLoadBlock(t *testing.T, height int) []byte {
@@ -331,3 +332,154 @@ func Test_UnpackTx(t *testing.T) {
 		})
 	}
 }
+
+func Test_GetMasternodePaymentVout(t *testing.T) {
+	p := NewDashParser(GetChainParams("main"), &btc.Configuration{})
+	tests := []struct {
+		name string
+		tx   *bchain.Tx
+		want int
+	}{
+		{
+			name: "normal tx",
+			tx:   &testTx1,
+			want: -1,
+		},
+		{
+			name: "coinbase tx",
+			tx:   &testTx2,
+			want: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := p.GetMasternodePaymentVout(tt.tx); got != tt.want {
+				t.Errorf("DashParser.GetMasternodePaymentVout() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_GetSpecialTxData(t *testing.T) {
+	p := NewDashParser(GetChainParams("main"), &btc.Configuration{})
+	tests := []struct {
+		name string
+		tx   *bchain.Tx
+		want *bchain.SpecialTxData
+	}{
+		{
+			name: "normal tx",
+			tx:   &testTx1,
+			want: nil,
+		},
+		{
+			name: "coinbase tx",
+			tx:   &testTx2,
+			want: &bchain.SpecialTxData{Type: TxTypeCoinbase, TypeName: "CbTx"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := p.GetSpecialTxData(tt.tx)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("DashParser.GetSpecialTxData() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_decodeSpecialTxData(t *testing.T) {
+	p := NewDashParser(GetChainParams("main"), &btc.Configuration{})
+	tests := []struct {
+		name    string
+		txType  int32
+		payload string
+		want    *bchain.SpecialTxData
+	}{
+		{
+			name:   "ProRegTx",
+			txType: TxTypeProReg,
+			payload: "0100" + "0000" + "0000" +
+				"f85264d11a747bdba77d411e5e4a3d35e3aeb5843b34a95234a2121ac65496bd" + "01000000" +
+				"00000000000000000000ffff01020304" + "270f" +
+				"70dcef2a22575d7a8f0779fb1d6cdd48135bd227" +
+				strings.Repeat("00", 48) +
+				"71348f7780e955a2a60eba17ecc4c826ebc23a98" +
+				"0000" +
+				"1976a9146a341485a9444b35dc9cb90d24e7483de7d37e0088ac" +
+				strings.Repeat("00", 32) + "00",
+			want: &bchain.SpecialTxData{
+				Type:           TxTypeProReg,
+				TypeName:       "ProRegTx",
+				CollateralTxid: "bd9654c61a12a23452a9343b84b5aee3353d4a5e1e417da7db7b741ad16452f8",
+				CollateralVout: 1,
+				ServiceAddress: "1.2.3.4:9999",
+				OwnerAddress:   "XkycBX1ykVXXs92pAi6ZQwZPEre9kSHHKH",
+				VotingAddress:  "Xm1R9thKBm2EZKZevXsmMX4DVwQQuTohZu",
+				PayoutAddress:  "XkNPrBSJtrHZUvUqb3JF4g5rMB3uzaJfEL",
+			},
+		},
+		{
+			name:   "ProRegTx with internal collateral",
+			txType: TxTypeProReg,
+			payload: "0100" + "0000" + "0000" +
+				strings.Repeat("00", 32) + "00000000" +
+				strings.Repeat("00", 18) +
+				"70dcef2a22575d7a8f0779fb1d6cdd48135bd227" +
+				strings.Repeat("00", 48) +
+				"71348f7780e955a2a60eba17ecc4c826ebc23a98" +
+				"0000" +
+				"1976a9146a341485a9444b35dc9cb90d24e7483de7d37e0088ac" +
+				strings.Repeat("00", 32) + "00",
+			want: &bchain.SpecialTxData{
+				Type:           TxTypeProReg,
+				TypeName:       "ProRegTx",
+				CollateralTxid: "ed732a404cdfd4e0475a7a016200b7eef191f2c9de0ffdef8a20091c0499299c",
+				OwnerAddress:   "XkycBX1ykVXXs92pAi6ZQwZPEre9kSHHKH",
+				VotingAddress:  "Xm1R9thKBm2EZKZevXsmMX4DVwQQuTohZu",
+				PayoutAddress:  "XkNPrBSJtrHZUvUqb3JF4g5rMB3uzaJfEL",
+			},
+		},
+		{
+			name:   "ProUpServTx",
+			txType: TxTypeProUpServ,
+			payload: "0100" +
+				"f85264d11a747bdba77d411e5e4a3d35e3aeb5843b34a95234a2121ac65496bd" +
+				"00000000000000000000ffff01020304" + "270f" +
+				"00" + strings.Repeat("00", 32) + strings.Repeat("00", 96),
+			want: &bchain.SpecialTxData{
+				Type:           TxTypeProUpServ,
+				TypeName:       "ProUpServTx",
+				ProTxHash:      "bd9654c61a12a23452a9343b84b5aee3353d4a5e1e417da7db7b741ad16452f8",
+				ServiceAddress: "1.2.3.4:9999",
+			},
+		},
+		{
+			name:   "ProUpRevTx",
+			txType: TxTypeProUpRev,
+			payload: "0100" +
+				"f85264d11a747bdba77d411e5e4a3d35e3aeb5843b34a95234a2121ac65496bd" +
+				"0100" + strings.Repeat("00", 32) + strings.Repeat("00", 96),
+			want: &bchain.SpecialTxData{
+				Type:      TxTypeProUpRev,
+				TypeName:  "ProUpRevTx",
+				ProTxHash: "bd9654c61a12a23452a9343b84b5aee3353d4a5e1e417da7db7b741ad16452f8",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, _ := hex.DecodeString(tt.payload)
+			got, err := p.decodeSpecialTxData(tt.txType, "ed732a404cdfd4e0475a7a016200b7eef191f2c9de0ffdef8a20091c0499299c", b)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("DashParser.decodeSpecialTxData() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
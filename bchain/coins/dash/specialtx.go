@@ -0,0 +1,234 @@
+package dash
+
+import (
+	"blockbook/bchain"
+	"blockbook/bchain/coins/utils"
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+
+	"github.com/juju/errors"
+	"github.com/martinboehm/btcd/chaincfg/chainhash"
+	"github.com/martinboehm/btcd/wire"
+)
+
+// Dash special transaction types (DIP2), the type is stored in the upper 16 bits of the transaction version
+const (
+	TxTypeNormal    = 0
+	TxTypeProReg    = 1
+	TxTypeProUpServ = 2
+	TxTypeProUpReg  = 3
+	TxTypeProUpRev  = 4
+	TxTypeCoinbase  = 5
+	TxTypeQuorum    = 6
+)
+
+// SpecialTxVersion is the first transaction version which can have the extra payload
+const SpecialTxVersion = 3
+
+var specialTxTypeNames = map[int32]string{
+	TxTypeProReg:    "ProRegTx",
+	TxTypeProUpServ: "ProUpServTx",
+	TxTypeProUpReg:  "ProUpRegTx",
+	TxTypeProUpRev:  "ProUpRevTx",
+	TxTypeCoinbase:  "CbTx",
+	TxTypeQuorum:    "QcTx",
+}
+
+func specialTxTypeName(txType int32) string {
+	if n, ok := specialTxTypeNames[txType]; ok {
+		return n
+	}
+	return "Unknown"
+}
+
+// dashTx is transaction decoded from Dash wire format
+type dashTx struct {
+	msgTx   wire.MsgTx
+	version int32
+	txType  int32
+	payload []byte
+	raw     []byte
+}
+
+// decodeTx decodes one transaction from the reader r, which reads from the buffer b
+// the extra payload of the special transactions follows the part decoded by wire.MsgTx
+func decodeTx(b []byte, r *bytes.Reader) (*dashTx, error) {
+	start := len(b) - r.Len()
+	t := dashTx{}
+	// Dash does not support segwit, the witness encoding could confuse the transactions without inputs
+	if err := t.msgTx.BtcDecode(r, 0, wire.BaseEncoding); err != nil {
+		return nil, err
+	}
+	t.version, t.txType = utils.SplitTxVersion(t.msgTx.Version)
+	if t.version >= SpecialTxVersion && t.txType != TxTypeNormal {
+		var err error
+		if t.payload, err = wire.ReadVarBytes(r, 0, wire.MaxBlockPayload, "extraPayload"); err != nil {
+			return nil, errors.Annotatef(err, "extraPayload")
+		}
+	}
+	t.raw = b[start : len(b)-r.Len()]
+	return &t, nil
+}
+
+// txFromDashTx converts decoded Dash transaction to bchain.Tx, the decoded extra payload is stored in CoinSpecificData
+func (p *DashParser) txFromDashTx(t *dashTx, parseAddresses bool) bchain.Tx {
+	tx := p.TxFromMsgTx(&t.msgTx, parseAddresses)
+	if t.txType != TxTypeNormal {
+		// the hash of the wire.MsgTx does not include the extra payload
+		tx.Txid = chainhash.DoubleHashH(t.raw).String()
+		tx.Version = t.version
+		sd, err := p.decodeSpecialTxData(t.txType, tx.Txid, t.payload)
+		if err != nil {
+			// the payload is verified by the backend, keep at least the type of the transaction
+			sd = &bchain.SpecialTxData{Type: t.txType, TypeName: specialTxTypeName(t.txType)}
+		}
+		tx.CoinSpecificData = sd
+	}
+	return tx
+}
+
+// ParseTx parses byte array containing transaction and returns Tx struct
+func (p *DashParser) ParseTx(b []byte) (*bchain.Tx, error) {
+	t, err := decodeTx(b, bytes.NewReader(b))
+	if err != nil {
+		return nil, err
+	}
+	tx := p.txFromDashTx(t, true)
+	tx.Hex = hex.EncodeToString(b)
+	return &tx, nil
+}
+
+// ParseBlock parses raw block to our Block struct, the block can contain special transactions
+func (p *DashParser) ParseBlock(b []byte) (*bchain.Block, error) {
+	r := bytes.NewReader(b)
+	h := wire.BlockHeader{}
+	if err := h.Deserialize(r); err != nil {
+		return nil, errors.Annotatef(err, "Deserialize")
+	}
+	txCount, err := wire.ReadVarInt(r, 0)
+	if err != nil {
+		return nil, errors.Annotatef(err, "DecodeTransactions")
+	}
+	if txCount > uint64(r.Len()) {
+		return nil, errors.Errorf("DecodeTransactions: too many transactions %d", txCount)
+	}
+	txs := make([]bchain.Tx, txCount)
+	for ti := range txs {
+		t, err := decodeTx(b, r)
+		if err != nil {
+			return nil, errors.Annotatef(err, "DecodeTransactions")
+		}
+		txs[ti] = p.txFromDashTx(t, false)
+	}
+	return &bchain.Block{
+		BlockHeader: bchain.BlockHeader{
+			Size: len(b),
+			Time: h.Timestamp.Unix(),
+		},
+		Txs: txs,
+	}, nil
+}
+
+// decodeSpecialTxData decodes the extra payload of the special transaction with given txid
+func (p *DashParser) decodeSpecialTxData(txType int32, txid string, payload []byte) (*bchain.SpecialTxData, error) {
+	sd := &bchain.SpecialTxData{
+		Type:     txType,
+		TypeName: specialTxTypeName(txType),
+	}
+	r := bytes.NewReader(payload)
+	var version uint16
+	if err := binary.Read(r, binary.LittleEndian, &version); err != nil {
+		return nil, errors.Annotatef(err, "nVersion")
+	}
+	var err error
+	switch txType {
+	case TxTypeProReg:
+		// masternode type and mode
+		if err = utils.SkipBytes(r, 4); err != nil {
+			return nil, errors.Annotatef(err, "nType")
+		}
+		if sd.CollateralTxid, sd.CollateralVout, err = utils.ReadOutPoint(r); err != nil {
+			return nil, errors.Annotatef(err, "collateralOutpoint")
+		}
+		// null collateral outpoint means that the collateral is an output of the registration transaction
+		if sd.CollateralTxid == "" {
+			sd.CollateralTxid = txid
+		}
+		if sd.ServiceAddress, err = utils.ReadServiceAddress(r); err != nil {
+			return nil, errors.Annotatef(err, "addr")
+		}
+		if sd.OwnerAddress, err = utils.ReadKeyIDAddress(r, p); err != nil {
+			return nil, errors.Annotatef(err, "keyIDOwner")
+		}
+		if err = utils.SkipBytes(r, utils.BLSPublicKeySize); err != nil {
+			return nil, errors.Annotatef(err, "pubKeyOperator")
+		}
+		if sd.VotingAddress, err = utils.ReadKeyIDAddress(r, p); err != nil {
+			return nil, errors.Annotatef(err, "keyIDVoting")
+		}
+		// operator reward
+		if err = utils.SkipBytes(r, 2); err != nil {
+			return nil, errors.Annotatef(err, "nOperatorReward")
+		}
+		if sd.PayoutAddress, err = utils.ReadScriptAddress(r, p); err != nil {
+			return nil, errors.Annotatef(err, "scriptPayout")
+		}
+	case TxTypeProUpServ:
+		// masternode type was added in the version 2 of the payload
+		if version >= 2 {
+			if err = utils.SkipBytes(r, 2); err != nil {
+				return nil, errors.Annotatef(err, "nType")
+			}
+		}
+		if sd.ProTxHash, err = utils.ReadHash(r); err != nil {
+			return nil, errors.Annotatef(err, "proTxHash")
+		}
+		if sd.ServiceAddress, err = utils.ReadServiceAddress(r); err != nil {
+			return nil, errors.Annotatef(err, "addr")
+		}
+	case TxTypeProUpReg:
+		if sd.ProTxHash, err = utils.ReadHash(r); err != nil {
+			return nil, errors.Annotatef(err, "proTxHash")
+		}
+		// mode and operator key
+		if err = utils.SkipBytes(r, 2+utils.BLSPublicKeySize); err != nil {
+			return nil, errors.Annotatef(err, "pubKeyOperator")
+		}
+		if sd.VotingAddress, err = utils.ReadKeyIDAddress(r, p); err != nil {
+			return nil, errors.Annotatef(err, "keyIDVoting")
+		}
+		if sd.PayoutAddress, err = utils.ReadScriptAddress(r, p); err != nil {
+			return nil, errors.Annotatef(err, "scriptPayout")
+		}
+	case TxTypeProUpRev:
+		if sd.ProTxHash, err = utils.ReadHash(r); err != nil {
+			return nil, errors.Annotatef(err, "proTxHash")
+		}
+	}
+	return sd, nil
+}
+
+// GetSpecialTxData returns decoded payload of the special transaction or nil if the transaction is not special
+// transactions from the backend json have the raw json in CoinSpecificData, the payload is decoded from the hex
+func (p *DashParser) GetSpecialTxData(tx *bchain.Tx) (*bchain.SpecialTxData, error) {
+	if sd, ok := tx.CoinSpecificData.(*bchain.SpecialTxData); ok {
+		return sd, nil
+	}
+	if len(tx.Hex) < 8 {
+		return nil, nil
+	}
+	b, err := hex.DecodeString(tx.Hex)
+	if err != nil {
+		return nil, errors.Annotatef(err, "txid %v", tx.Txid)
+	}
+	// fast path, check the transaction version and type without decoding the whole transaction
+	if v, tt := utils.SplitTxVersion(int32(binary.LittleEndian.Uint32(b))); v < SpecialTxVersion || tt == TxTypeNormal {
+		return nil, nil
+	}
+	t, err := decodeTx(b, bytes.NewReader(b))
+	if err != nil {
+		return nil, errors.Annotatef(err, "txid %v", tx.Txid)
+	}
+	return p.txFromDashTx(t, false).CoinSpecificData.(*bchain.SpecialTxData), nil
+}
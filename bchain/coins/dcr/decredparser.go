@@ -306,6 +306,17 @@ func (p *DecredParser) DeriveAddressDescriptorsFromTo(xpub string, change uint32
 	return ad, nil
 }
 
+// ParseOutputDescriptor is not supported, the descriptors do not define Decred scripts
+func (p *DecredParser) ParseOutputDescriptor(descriptor string) (*bchain.OutputDescriptor, error) {
+	return nil, errors.New("Not supported")
+}
+
+// DeriveOutputDescriptorAddresses is not supported
+func (p *DecredParser) DeriveOutputDescriptorAddresses(descriptor *bchain.OutputDescriptor, chain int,
+	fromIndex uint32, toIndex uint32) ([]bchain.AddressDescriptor, error) {
+	return nil, errors.New("Not supported")
+}
+
 // DerivationBasePath returns base path of xpub which whose full format is
 // m/44'/<coin type>'/<account>'/<branch>/<address index>. This function only
 // returns a path up to m/44'/<coin type>'/<account>'/ whereby the rest of the
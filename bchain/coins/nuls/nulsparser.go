@@ -195,6 +195,16 @@ func (p *NulsParser) DeriveAddressDescriptorsFromTo(xpub string, change uint32,
 	return ad, nil
 }
 
+// ParseOutputDescriptor is not supported, NULS addresses are not defined by scripts
+func (p *NulsParser) ParseOutputDescriptor(descriptor string) (*bchain.OutputDescriptor, error) {
+	return nil, errors.New("Not supported")
+}
+
+// DeriveOutputDescriptorAddresses is not supported
+func (p *NulsParser) DeriveOutputDescriptorAddresses(descriptor *bchain.OutputDescriptor, chain int, fromIndex uint32, toIndex uint32) ([]bchain.AddressDescriptor, error) {
+	return nil, errors.New("Not supported")
+}
+
 func (p *NulsParser) xor(body []byte) byte {
 	var xor byte = 0x00
 	for i := 0; i < len(body); i++ {
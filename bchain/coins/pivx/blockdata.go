@@ -0,0 +1,25 @@
+package pivx
+
+import (
+	"blockbook/bchain"
+)
+
+// block signature is DER encoded ECDSA signature
+const maxBlockSignatureSize = 80
+
+// coinstakeStaker returns address of the staker of the coinstake transaction, which is the address of the first staker output
+// the staker of the cold stake is the staking address of the P2CS output, the staker of the zerocoin stake is not known
+func (p *PivXParser) coinstakeStaker(tx *bchain.Tx) string {
+	addrDesc, err := p.GetAddrDescFromVout(&tx.Vout[1])
+	if err != nil {
+		return ""
+	}
+	addresses, searchable, err := p.GetAddressesFromAddrDesc(addrDesc)
+	if err != nil || !searchable || len(addresses) == 0 {
+		return ""
+	}
+	if isP2CSScript(addrDesc) && len(addresses) > 1 {
+		return addresses[1]
+	}
+	return addresses[0]
+}
@@ -0,0 +1,57 @@
+package pivx
+
+import (
+	"blockbook/bchain"
+)
+
+// SupportsCoinstake returns true, PIVX is proof of stake chain
+func (p *PivXParser) SupportsCoinstake() bool {
+	return true
+}
+
+// IsCoinstakeTx checks if the transaction is coinstake transaction
+// coinstake transaction spends an output or a zerocoin and the first of at least two outputs is empty
+// the zerocoin spend input is not a coinbase input, TxFromMsgTx and the backend keep its null prevout in Txid
+func (p *PivXParser) IsCoinstakeTx(tx *bchain.Tx) bool {
+	if len(tx.Vin) == 0 || len(tx.Vout) < 2 {
+		return false
+	}
+	if tx.Vin[0].Coinbase != "" {
+		return false
+	}
+	vout := &tx.Vout[0]
+	return vout.ValueSat.Sign() == 0 && vout.ScriptPubKey.Hex == ""
+}
+
+// budget is paid in superblocks, once in a budget cycle
+const (
+	mainnetBudgetCycleBlocks = 43200
+	testnetBudgetCycleBlocks = 144
+)
+
+// SuperblockCycle returns number of blocks of the budget cycle, superblock is the first block of the cycle
+func (p *PivXParser) SuperblockCycle() uint32 {
+	if p.Params.Net == MainnetMagic {
+		return mainnetBudgetCycleBlocks
+	}
+	return testnetBudgetCycleBlocks
+}
+
+// GetMasternodePaymentVout returns index of the masternode payment output or -1
+// masternode payment (or budget payment in the superblock and the blocks following it) is the last output of the coinstake transaction (or of the coinbase transaction of proof of work block),
+// if it is paid to a different script than the first staker output (or the miner output)
+// the detection is a heuristic, the masternode paid to the same script as the staker is not found
+// and the last output of the stake split to different scripts is reported as the masternode payment
+func (p *PivXParser) GetMasternodePaymentVout(tx *bchain.Tx) int {
+	first := 0
+	if p.IsCoinstakeTx(tx) {
+		first = 1
+	} else if len(tx.Vin) != 1 || tx.Vin[0].Coinbase == "" {
+		return -1
+	}
+	last := len(tx.Vout) - 1
+	if last <= first || tx.Vout[last].ScriptPubKey.Hex == tx.Vout[first].ScriptPubKey.Hex {
+		return -1
+	}
+	return last
+}
@@ -0,0 +1,121 @@
+package pivx
+
+import (
+	"blockbook/bchain"
+
+	"github.com/martinboehm/btcutil"
+	"github.com/martinboehm/btcutil/chaincfg"
+	"github.com/martinboehm/btcutil/txscript"
+)
+
+// cold staking op codes
+const (
+	OP_CHECKCOLDSTAKEVERIFY_LOF = 0xd1
+	OP_CHECKCOLDSTAKEVERIFY     = 0xd2
+)
+
+// P2CS script is
+// OP_DUP OP_HASH160 OP_ROT OP_IF OP_CHECKCOLDSTAKEVERIFY[_LOF] <staker pkh> OP_ELSE <owner pkh> OP_ENDIF OP_EQUALVERIFY OP_CHECKSIG
+const (
+	p2csScriptLen   = 51
+	p2csStakerStart = 6
+	p2csOwnerStart  = 28
+)
+
+// staker address descriptor is OP_CHECKCOLDSTAKEVERIFY <staker pkh>
+// it is not a valid output script, therefore it cannot collide with address descriptors of real outputs
+const stakerAddrDescLen = 22
+
+// staking address encoding magics
+var (
+	MainNetStakingAddrID = []byte{63} // starting with 'S'
+	TestNetStakingAddrID = []byte{73} // starting with 'W'
+)
+
+func newStakingParams(params *chaincfg.Params) *chaincfg.Params {
+	sp := *params
+	if params.Net == MainnetMagic {
+		sp.PubKeyHashAddrID = MainNetStakingAddrID
+	} else {
+		sp.PubKeyHashAddrID = TestNetStakingAddrID
+	}
+	return &sp
+}
+
+// isP2CSScript checks if script is pay to cold staking script
+func isP2CSScript(script []byte) bool {
+	return len(script) == p2csScriptLen &&
+		script[0] == txscript.OP_DUP &&
+		script[1] == txscript.OP_HASH160 &&
+		script[2] == txscript.OP_ROT &&
+		script[3] == txscript.OP_IF &&
+		(script[4] == OP_CHECKCOLDSTAKEVERIFY || script[4] == OP_CHECKCOLDSTAKEVERIFY_LOF) &&
+		script[5] == txscript.OP_DATA_20 &&
+		script[26] == txscript.OP_ELSE &&
+		script[27] == txscript.OP_DATA_20 &&
+		script[48] == txscript.OP_ENDIF &&
+		script[49] == txscript.OP_EQUALVERIFY &&
+		script[50] == txscript.OP_CHECKSIG
+}
+
+func isStakerAddrDesc(addrDesc bchain.AddressDescriptor) bool {
+	return len(addrDesc) == stakerAddrDescLen && addrDesc[0] == OP_CHECKCOLDSTAKEVERIFY && addrDesc[1] == txscript.OP_DATA_20
+}
+
+func stakerAddrDesc(pkh []byte) bchain.AddressDescriptor {
+	ad := make(bchain.AddressDescriptor, 0, stakerAddrDescLen)
+	ad = append(ad, OP_CHECKCOLDSTAKEVERIFY, txscript.OP_DATA_20)
+	return append(ad, pkh...)
+}
+
+func ownerAddrDesc(pkh []byte) bchain.AddressDescriptor {
+	ad := make(bchain.AddressDescriptor, 0, 25)
+	ad = append(ad, txscript.OP_DUP, txscript.OP_HASH160, txscript.OP_DATA_20)
+	ad = append(ad, pkh...)
+	return append(ad, txscript.OP_EQUALVERIFY, txscript.OP_CHECKSIG)
+}
+
+func (p *PivXParser) stakerAddress(pkh []byte) (string, error) {
+	a, err := btcutil.NewAddressPubKeyHash(pkh, p.stakingParams)
+	if err != nil {
+		return "", err
+	}
+	return a.EncodeAddress(), nil
+}
+
+// p2csToAddresses returns owner and staker addresses of the P2CS script
+func (p *PivXParser) p2csToAddresses(script []byte) ([]string, bool, error) {
+	owner, err := btcutil.NewAddressPubKeyHash(script[p2csOwnerStart:p2csOwnerStart+20], p.Params)
+	if err != nil {
+		return nil, false, err
+	}
+	staker, err := p.stakerAddress(script[p2csStakerStart : p2csStakerStart+20])
+	if err != nil {
+		return nil, false, err
+	}
+	return []string{owner.EncodeAddress(), staker}, true, nil
+}
+
+// GetAddrDescFromAddress returns internal address representation (descriptor) of given address
+// staking addresses are converted to staker address descriptor
+func (p *PivXParser) GetAddrDescFromAddress(address string) (bchain.AddressDescriptor, error) {
+	if da, err := btcutil.DecodeAddress(address, p.stakingParams); err == nil {
+		if a, ok := da.(*btcutil.AddressPubKeyHash); ok {
+			return stakerAddrDesc(a.ScriptAddress()), nil
+		}
+	}
+	return p.BitcoinParser.GetAddrDescFromAddress(address)
+}
+
+// GetColdStakingAddrDescs returns owner and staker address descriptors of the P2CS address descriptor
+func (p *PivXParser) GetColdStakingAddrDescs(addrDesc bchain.AddressDescriptor) (bchain.AddressDescriptor, bchain.AddressDescriptor) {
+	if !isP2CSScript(addrDesc) {
+		return nil, nil
+	}
+	return ownerAddrDesc(addrDesc[p2csOwnerStart : p2csOwnerStart+20]), stakerAddrDesc(addrDesc[p2csStakerStart : p2csStakerStart+20])
+}
+
+// IsColdStakingStakerAddrDesc returns true if the address descriptor is the staker address descriptor
+func (p *PivXParser) IsColdStakingStakerAddrDesc(addrDesc bchain.AddressDescriptor) bool {
+	return isStakerAddrDesc(addrDesc)
+}
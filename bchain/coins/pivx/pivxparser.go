@@ -3,7 +3,6 @@ package pivx
 import (
 	"blockbook/bchain"
 	"blockbook/bchain/coins/btc"
-	"blockbook/bchain/coins/utils"
 	"bytes"
 	"io"
 
@@ -13,6 +12,7 @@ import (
 	"math/big"
 
 	"github.com/martinboehm/btcd/blockchain"
+	"github.com/martinboehm/btcd/chaincfg/chainhash"
 
 	"github.com/juju/errors"
 	"github.com/martinboehm/btcd/wire"
@@ -55,6 +55,7 @@ func init() {
 type PivXParser struct {
 	*btc.BitcoinParser
 	baseparser                         *bchain.BaseParser
+	stakingParams                      *chaincfg.Params
 	BitcoinOutputScriptToAddressesFunc btc.OutputScriptToAddressesFunc
 }
 
@@ -63,6 +64,7 @@ func NewPivXParser(params *chaincfg.Params, c *btc.Configuration) *PivXParser {
 	p := &PivXParser{
 		BitcoinParser: btc.NewBitcoinParser(params, c),
 		baseparser:    &bchain.BaseParser{},
+		stakingParams: newStakingParams(params),
 	}
 	p.BitcoinOutputScriptToAddressesFunc = p.OutputScriptToAddressesFunc
 	p.OutputScriptToAddressesFunc = p.outputScriptToAddresses
@@ -90,27 +92,65 @@ func GetChainParams(chain string) *chaincfg.Params {
 
 // ParseBlock parses raw block to our Block struct
 func (p *PivXParser) ParseBlock(b []byte) (*bchain.Block, error) {
+	block, _, err := p.parseBlock(b)
+	return block, err
+}
+
+// ParseBlockData parses PIVX specific data of the raw block
+func (p *PivXParser) ParseBlockData(b []byte) (*bchain.PosBlockData, error) {
+	_, bd, err := p.parseBlock(b)
+	return bd, err
+}
+
+func (p *PivXParser) parseBlock(b []byte) (*bchain.Block, *bchain.PosBlockData, error) {
 	r := bytes.NewReader(b)
-	w := wire.MsgBlock{}
 	h := wire.BlockHeader{}
 	err := h.Deserialize(r)
 	if err != nil {
-		return nil, errors.Annotatef(err, "Deserialize")
+		return nil, nil, errors.Annotatef(err, "Deserialize")
 	}
 
-	if h.Version > 3 {
-		// Skip past AccumulatorCheckpoint which was added in pivx block version 4
+	bd := &bchain.PosBlockData{}
+	if h.Version > 3 && h.Version < 7 {
+		// AccumulatorCheckpoint was added in pivx block version 4
+		var checkpoint chainhash.Hash
+		if _, err := io.ReadFull(r, checkpoint[:]); err != nil {
+			return nil, nil, errors.Annotatef(err, "AccumulatorCheckpoint")
+		}
+		bd.AccumulatorCheckpoint = checkpoint.String()
+	} else if h.Version >= 8 {
+		// Skip past hashFinalSaplingRoot which was added in pivx block version 8
 		r.Seek(32, io.SeekCurrent)
 	}
 
-	err = utils.DecodeTransactions(r, 0, wire.WitnessEncoding, &w)
+	txCount, err := wire.ReadVarInt(r, 0)
 	if err != nil {
-		return nil, errors.Annotatef(err, "DecodeTransactions")
+		return nil, nil, errors.Annotatef(err, "DecodeTransactions")
+	}
+	if txCount > uint64(r.Len()) {
+		return nil, nil, errors.Errorf("DecodeTransactions: too many transactions %d", txCount)
 	}
 
-	txs := make([]bchain.Tx, len(w.Transactions))
-	for ti, t := range w.Transactions {
-		txs[ti] = p.TxFromMsgTx(t, false)
+	txs := make([]bchain.Tx, txCount)
+	for ti := range txs {
+		t, err := decodeTx(b, r)
+		if err != nil {
+			return nil, nil, errors.Annotatef(err, "DecodeTransactions")
+		}
+		txs[ti] = p.txFromPivxTx(t, false)
+	}
+
+	// the block signature follows the transactions
+	if r.Len() > 0 {
+		sig, err := wire.ReadVarBytes(r, 0, maxBlockSignatureSize, "BlockSignature")
+		if err != nil {
+			return nil, nil, errors.Annotatef(err, "BlockSignature")
+		}
+		bd.BlockSignature = hex.EncodeToString(sig)
+	}
+	if len(txs) > 1 && p.IsCoinstakeTx(&txs[1]) {
+		bd.ProofOfStake = true
+		bd.Staker = p.coinstakeStaker(&txs[1])
 	}
 
 	return &bchain.Block{
@@ -119,7 +159,7 @@ func (p *PivXParser) ParseBlock(b []byte) (*bchain.Block, error) {
 			Time: h.Timestamp.Unix(),
 		},
 		Txs: txs,
-	}, nil
+	}, bd, nil
 }
 
 // PackTx packs transaction to byte array using protobuf
@@ -129,17 +169,28 @@ func (p *PivXParser) PackTx(tx *bchain.Tx, height uint32, blockTime int64) ([]by
 
 // UnpackTx unpacks transaction from protobuf byte array
 func (p *PivXParser) UnpackTx(buf []byte) (*bchain.Tx, uint32, error) {
-	return p.baseparser.UnpackTx(buf)
+	tx, height, err := p.baseparser.UnpackTx(buf)
+	if err != nil {
+		return nil, 0, err
+	}
+	// CoinSpecificData are not packed, restore the sapling and special transaction data from the transaction hex
+	td, err := p.txDataFromHex(tx.Hex)
+	if err != nil {
+		return nil, 0, errors.Annotatef(err, "txid %v", tx.Txid)
+	}
+	if td != nil {
+		tx.CoinSpecificData = td
+	}
+	return tx, height, nil
 }
 
 // ParseTx parses byte array containing transaction and returns Tx struct
 func (p *PivXParser) ParseTx(b []byte) (*bchain.Tx, error) {
-	t := wire.MsgTx{}
-	r := bytes.NewReader(b)
-	if err := t.Deserialize(r); err != nil {
+	t, err := decodeTx(b, bytes.NewReader(b))
+	if err != nil {
 		return nil, err
 	}
-	tx := p.TxFromMsgTx(&t, true)
+	tx := p.txFromPivxTx(t, true)
 	tx.Hex = hex.EncodeToString(b)
 	return &tx, nil
 }
@@ -232,18 +283,35 @@ func (p *PivXParser) ParseTxFromJson(msg json.RawMessage) (*bchain.Tx, error) {
 }
 
 // outputScriptToAddresses converts ScriptPubKey to bitcoin addresses
+// zerocoin scripts do not have any address, the zerocoins are tracked in the zerocoin pool
 func (p *PivXParser) outputScriptToAddresses(script []byte) ([]string, bool, error) {
-	if isZeroCoinSpendScript(script) {
-		return []string{"Zerocoin Spend"}, false, nil
+	if isZeroCoinSpendScript(script) || isZeroCoinMintScript(script) {
+		return nil, false, nil
 	}
-	if isZeroCoinMintScript(script) {
-		return []string{"Zerocoin Mint"}, false, nil
+	if isP2CSScript(script) {
+		return p.p2csToAddresses(script)
+	}
+	if isStakerAddrDesc(script) {
+		a, err := p.stakerAddress(script[2:])
+		if err != nil {
+			return nil, false, err
+		}
+		return []string{a}, true, nil
 	}
 
 	rv, s, _ := p.BitcoinOutputScriptToAddressesFunc(script)
 	return rv, s, nil
 }
 
+// IsAddrDescIndexable returns true if AddressDescriptor should be added to index
+// zerocoin mints and spends are not indexed, they are tracked in the zerocoin pool
+func (p *PivXParser) IsAddrDescIndexable(addrDesc bchain.AddressDescriptor) bool {
+	if isZeroCoinSpendScript(addrDesc) || isZeroCoinMintScript(addrDesc) {
+		return false
+	}
+	return p.BitcoinParser.IsAddrDescIndexable(addrDesc)
+}
+
 func (p *PivXParser) GetAddrDescForUnknownInput(tx *bchain.Tx, input int) bchain.AddressDescriptor {
 	if len(tx.Vin) > input {
 		scriptHex := tx.Vin[input].ScriptSig.Hex
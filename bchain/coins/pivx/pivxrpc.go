@@ -6,6 +6,7 @@ import (
 	"encoding/json"
 
 	"github.com/golang/glog"
+	"github.com/juju/errors"
 )
 
 // PivXRPC is an interface to JSON-RPC bitcoind service.
@@ -30,6 +31,48 @@ func NewPivXRPC(config json.RawMessage, pushHandler func(bchain.NotificationType
 	return s, nil
 }
 
+// getbudgetprojection
+
+type cmdGetBudgetProjection struct {
+	Method string `json:"method"`
+}
+
+type resGetBudgetProjection struct {
+	Error  *bchain.RPCError `json:"error"`
+	Result []struct {
+		Name           string      `json:"Name"`
+		URL            string      `json:"URL"`
+		Hash           string      `json:"Hash"`
+		PaymentAddress string      `json:"PaymentAddress"`
+		Yeas           int         `json:"Yeas"`
+		Nays           int         `json:"Nays"`
+		Abstains       int         `json:"Abstains"`
+		Alloted        json.Number `json:"Alloted"`
+	} `json:"result"`
+}
+
+// getbudgetinfo
+
+type cmdGetBudgetInfo struct {
+	Method string `json:"method"`
+}
+
+type resGetBudgetInfo struct {
+	Error  *bchain.RPCError `json:"error"`
+	Result []struct {
+		Name           string      `json:"Name"`
+		URL            string      `json:"URL"`
+		Hash           string      `json:"Hash"`
+		BlockStart     uint32      `json:"BlockStart"`
+		BlockEnd       uint32      `json:"BlockEnd"`
+		PaymentAddress string      `json:"PaymentAddress"`
+		Yeas           int         `json:"Yeas"`
+		Nays           int         `json:"Nays"`
+		Abstains       int         `json:"Abstains"`
+		MonthlyPayment json.Number `json:"MonthlyPayment"`
+	} `json:"result"`
+}
+
 // Initialize initializes PivXRPC instance.
 func (b *PivXRPC) Initialize() error {
 	ci, err := b.GetChainInfo()
@@ -57,3 +100,80 @@ func (b *PivXRPC) Initialize() error {
 
 	return nil
 }
+
+// GetBudgetProjection returns budget proposals projected to be paid in the next superblock
+func (b *PivXRPC) GetBudgetProjection() ([]bchain.BudgetProposal, error) {
+	glog.V(1).Info("rpc: getbudgetprojection")
+
+	res := resGetBudgetProjection{}
+	err := b.Call(&cmdGetBudgetProjection{Method: "getbudgetprojection"}, &res)
+	if err != nil {
+		return nil, err
+	}
+	if res.Error != nil {
+		return nil, res.Error
+	}
+	proposals := make([]bchain.BudgetProposal, len(res.Result))
+	for i := range res.Result {
+		r := &res.Result[i]
+		bp := &proposals[i]
+		bp.Name = r.Name
+		bp.URL = r.URL
+		bp.Hash = r.Hash
+		bp.PaymentAddress = r.PaymentAddress
+		bp.Yeas = r.Yeas
+		bp.Nays = r.Nays
+		bp.Abstains = r.Abstains
+		bp.PaymentSat, err = b.Parser.AmountToBigInt(r.Alloted)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return proposals, nil
+}
+
+// GetBudgetProposals returns budget proposals known by the backend with the monthly payment and the blocks of the payment period
+func (b *PivXRPC) GetBudgetProposals() ([]bchain.BudgetProposal, error) {
+	glog.V(1).Info("rpc: getbudgetinfo")
+
+	res := resGetBudgetInfo{}
+	err := b.Call(&cmdGetBudgetInfo{Method: "getbudgetinfo"}, &res)
+	if err != nil {
+		return nil, err
+	}
+	if res.Error != nil {
+		return nil, res.Error
+	}
+	proposals := make([]bchain.BudgetProposal, len(res.Result))
+	for i := range res.Result {
+		r := &res.Result[i]
+		bp := &proposals[i]
+		bp.Name = r.Name
+		bp.URL = r.URL
+		bp.Hash = r.Hash
+		bp.BlockStart = r.BlockStart
+		bp.BlockEnd = r.BlockEnd
+		bp.PaymentAddress = r.PaymentAddress
+		bp.Yeas = r.Yeas
+		bp.Nays = r.Nays
+		bp.Abstains = r.Abstains
+		bp.PaymentSat, err = b.Parser.AmountToBigInt(r.MonthlyPayment)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return proposals, nil
+}
+
+// GetPosBlockData returns PIVX specific data of the block, they are parsed from the raw block
+func (b *PivXRPC) GetPosBlockData(hash string) (*bchain.PosBlockData, error) {
+	data, err := b.GetBlockRaw(hash)
+	if err != nil {
+		return nil, err
+	}
+	bd, err := b.Parser.(*PivXParser).ParseBlockData(data)
+	if err != nil {
+		return nil, errors.Annotatef(err, "hash %v", hash)
+	}
+	return bd, nil
+}
@@ -0,0 +1,125 @@
+// +build unittest
+
+package pivx
+
+import (
+	"blockbook/bchain"
+	"blockbook/bchain/coins/btc"
+	"encoding/json"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// newTestPivXRPC returns PivXRPC connected to the test server which answers the requests of the method by the response
+func newTestPivXRPC(t *testing.T, method, response string) (*PivXRPC, func()) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		var req struct {
+			Method string `json:"method"`
+		}
+		if err := json.Unmarshal(body, &req); err != nil {
+			t.Error(err)
+			return
+		}
+		if req.Method != method {
+			t.Errorf("unexpected method %v", req.Method)
+		}
+		w.Write([]byte(response))
+	}))
+	config, err := json.Marshal(&btc.Configuration{RPCURL: ts.URL, RPCTimeout: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := NewPivXRPC(config, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rpc := b.(*PivXRPC)
+	rpc.Parser = NewPivXParser(GetChainParams("main"), rpc.ChainConfig)
+	return rpc, ts.Close
+}
+
+func Test_GetBudgetProjection(t *testing.T) {
+	rpc, done := newTestPivXRPC(t, "getbudgetprojection", `{"result":[
+		{"Name":"PIVX-Labs","URL":"https://forum.pivx.org/t/pivx-labs","Hash":"b5c3d2bb1f9ad0f5b6e2bbd45d1b0f6b8d7a4bba1d3e6f0c9b2a8f7e6d5c4b3a","FeeHash":"4ab3c2d1e0f9a8b7c6d5e4f3a2b1c0d9e8f7a6b5c4d3e2f1a0b9c8d7e6f5a4b3","BlockStart":2462400,"BlockEnd":2592001,"TotalPaymentCount":3,"RemainingPaymentCount":2,"PaymentAddress":"DRM8TaiY38qcHbgdytp8oETreobBLHtpeE","Ratio":0.9,"Yeas":72,"Nays":8,"Abstains":1,"TotalPayment":30000.0,"MonthlyPayment":10000.0,"IsEstablished":true,"IsValid":true,"Allotted":10000.0,"Alloted":10000.0,"TotalBudgetAlloted":10000.0},
+		{"Name":"Marketing","URL":"https://forum.pivx.org/t/marketing","Hash":"0c9b2a8f7e6d5c4b3ab5c3d2bb1f9ad0f5b6e2bbd45d1b0f6b8d7a4bba1d3e6f","PaymentAddress":"DKL3QzCbJqrHpRKAHvEqsomsDhkQPvVzZg","Yeas":40,"Nays":12,"Abstains":0,"Alloted":2500.5}
+	],"error":null,"id":"1"}`)
+	defer done()
+	got, err := rpc.GetBudgetProjection()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []bchain.BudgetProposal{
+		{
+			Name:           "PIVX-Labs",
+			Hash:           "b5c3d2bb1f9ad0f5b6e2bbd45d1b0f6b8d7a4bba1d3e6f0c9b2a8f7e6d5c4b3a",
+			URL:            "https://forum.pivx.org/t/pivx-labs",
+			PaymentAddress: "DRM8TaiY38qcHbgdytp8oETreobBLHtpeE",
+			Yeas:           72,
+			Nays:           8,
+			Abstains:       1,
+			PaymentSat:     *big.NewInt(1000000000000),
+		},
+		{
+			Name:           "Marketing",
+			Hash:           "0c9b2a8f7e6d5c4b3ab5c3d2bb1f9ad0f5b6e2bbd45d1b0f6b8d7a4bba1d3e6f",
+			URL:            "https://forum.pivx.org/t/marketing",
+			PaymentAddress: "DKL3QzCbJqrHpRKAHvEqsomsDhkQPvVzZg",
+			Yeas:           40,
+			Nays:           12,
+			PaymentSat:     *big.NewInt(250050000000),
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("GetBudgetProjection() = %+v, want %+v", got, want)
+	}
+}
+
+func Test_GetBudgetProjection_Error(t *testing.T) {
+	rpc, done := newTestPivXRPC(t, "getbudgetprojection", `{"result":null,"error":{"code":-32601,"message":"Method not found"},"id":"1"}`)
+	defer done()
+	got, err := rpc.GetBudgetProjection()
+	if err == nil || !strings.Contains(err.Error(), "Method not found") {
+		t.Errorf("GetBudgetProjection() error = %v, want Method not found", err)
+	}
+	if got != nil {
+		t.Errorf("GetBudgetProjection() = %+v, want nil", got)
+	}
+}
+
+func Test_GetBudgetProposals(t *testing.T) {
+	rpc, done := newTestPivXRPC(t, "getbudgetinfo", `{"result":[
+		{"Name":"PIVX-Labs","URL":"https://forum.pivx.org/t/pivx-labs","Hash":"b5c3d2bb1f9ad0f5b6e2bbd45d1b0f6b8d7a4bba1d3e6f0c9b2a8f7e6d5c4b3a","FeeHash":"4ab3c2d1e0f9a8b7c6d5e4f3a2b1c0d9e8f7a6b5c4d3e2f1a0b9c8d7e6f5a4b3","BlockStart":2462400,"BlockEnd":2592001,"TotalPaymentCount":3,"RemainingPaymentCount":2,"PaymentAddress":"DRM8TaiY38qcHbgdytp8oETreobBLHtpeE","Ratio":0.9,"Yeas":72,"Nays":8,"Abstains":1,"TotalPayment":30000.0,"MonthlyPayment":10000.0,"IsEstablished":true,"IsValid":true,"IsValidReason":"","fValid":true}
+	],"error":null,"id":"1"}`)
+	defer done()
+	got, err := rpc.GetBudgetProposals()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []bchain.BudgetProposal{
+		{
+			Name:           "PIVX-Labs",
+			Hash:           "b5c3d2bb1f9ad0f5b6e2bbd45d1b0f6b8d7a4bba1d3e6f0c9b2a8f7e6d5c4b3a",
+			URL:            "https://forum.pivx.org/t/pivx-labs",
+			PaymentAddress: "DRM8TaiY38qcHbgdytp8oETreobBLHtpeE",
+			Yeas:           72,
+			Nays:           8,
+			Abstains:       1,
+			PaymentSat:     *big.NewInt(1000000000000),
+			BlockStart:     2462400,
+			BlockEnd:       2592001,
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("GetBudgetProposals() = %+v, want %+v", got, want)
+	}
+}
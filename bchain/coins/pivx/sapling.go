@@ -0,0 +1,221 @@
+package pivx
+
+import (
+	"blockbook/bchain"
+	"blockbook/bchain/coins/utils"
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"io"
+
+	"github.com/juju/errors"
+	"github.com/martinboehm/btcd/chaincfg/chainhash"
+	"github.com/martinboehm/btcd/wire"
+)
+
+// PIVX transaction versions
+const (
+	LegacyTxVersion  = 1
+	SaplingTxVersion = 3
+)
+
+// sizes of the serialized sapling structures
+const (
+	// cv, anchor, nullifier, rk (4*32), zkproof (192), spendAuthSig (64)
+	saplingSpendDescriptionSize = 384
+	// cv, cmu, ephemeralKey (3*32), encCiphertext (580), outCiphertext (80), zkproof (192)
+	saplingOutputDescriptionSize = 948
+	saplingBindingSigSize        = 64
+	// maximum number of shielded spends or outputs, protects against memory exhaustion
+	maxSaplingDescriptions = wire.MaxBlockPayload / saplingSpendDescriptionSize
+)
+
+// SaplingTxData contains summary of the sapling (shielded) part of the PIVX transaction
+type SaplingTxData struct {
+	ValueBalance    int64
+	ShieldedSpends  int
+	ShieldedOutputs int
+}
+
+// PivxTxData contains the sapling part and the decoded special transaction payload of the PIVX transaction
+// it is stored in the Tx.CoinSpecificData of the transactions parsed by PivXParser
+type PivxTxData struct {
+	Sapling *SaplingTxData
+	Special *bchain.SpecialTxData
+}
+
+// pivxTx is transaction decoded from PIVX wire format
+type pivxTx struct {
+	msgTx   wire.MsgTx
+	version int32
+	txType  int32
+	sapling *SaplingTxData
+	payload []byte
+	raw     []byte
+}
+
+// decodeTx decodes one transaction from the reader r, which reads from the buffer b
+// in addition to the transparent part decoded by wire.MsgTx, the sapling payload of the v3 transactions is decoded
+func decodeTx(b []byte, r *bytes.Reader) (*pivxTx, error) {
+	start := len(b) - r.Len()
+	t := pivxTx{}
+	// PIVX does not support segwit, the witness encoding would confuse shielded transactions without transparent inputs
+	if err := t.msgTx.BtcDecode(r, 0, wire.BaseEncoding); err != nil {
+		return nil, err
+	}
+	t.version, t.txType = utils.SplitTxVersion(t.msgTx.Version)
+	if t.version >= SaplingTxVersion {
+		var err error
+		if t.sapling, err = decodeSaplingTxData(r); err != nil {
+			return nil, errors.Annotatef(err, "decodeSaplingTxData")
+		}
+		if t.txType != TxTypeNormal {
+			if t.payload, err = readOptionalVarBytes(r); err != nil {
+				return nil, errors.Annotatef(err, "extraPayload")
+			}
+		}
+	}
+	t.raw = b[start : len(b)-r.Len()]
+	return &t, nil
+}
+
+// decodeSaplingTxData decodes serialized Optional<SaplingTxData>
+func decodeSaplingTxData(r *bytes.Reader) (*SaplingTxData, error) {
+	present, err := r.ReadByte()
+	if err != nil {
+		return nil, err
+	}
+	if present == 0 {
+		return nil, nil
+	}
+	var valueBalance int64
+	if err = binary.Read(r, binary.LittleEndian, &valueBalance); err != nil {
+		return nil, err
+	}
+	spends, err := skipDescriptions(r, saplingSpendDescriptionSize)
+	if err != nil {
+		return nil, errors.Annotatef(err, "vShieldedSpend")
+	}
+	outputs, err := skipDescriptions(r, saplingOutputDescriptionSize)
+	if err != nil {
+		return nil, errors.Annotatef(err, "vShieldedOutput")
+	}
+	if err = utils.SkipBytes(r, saplingBindingSigSize); err != nil {
+		return nil, errors.Annotatef(err, "bindingSig")
+	}
+	return &SaplingTxData{
+		ValueBalance:    valueBalance,
+		ShieldedSpends:  spends,
+		ShieldedOutputs: outputs,
+	}, nil
+}
+
+// skipDescriptions skips vector of fixed size items and returns the number of items
+func skipDescriptions(r *bytes.Reader, size int) (int, error) {
+	count, err := wire.ReadVarInt(r, 0)
+	if err != nil {
+		return 0, err
+	}
+	if count > maxSaplingDescriptions {
+		return 0, errors.Errorf("too many descriptions %d", count)
+	}
+	if err = utils.SkipBytes(r, int(count)*size); err != nil {
+		return 0, err
+	}
+	return int(count), nil
+}
+
+// readOptionalVarBytes reads serialized Optional<std::vector<uint8_t>>
+func readOptionalVarBytes(r *bytes.Reader) ([]byte, error) {
+	present, err := r.ReadByte()
+	if err != nil {
+		return nil, err
+	}
+	if present == 0 {
+		return nil, nil
+	}
+	return wire.ReadVarBytes(r, 0, wire.MaxBlockPayload, "payload")
+}
+
+// txFromPivxTx converts decoded PIVX transaction to bchain.Tx
+func (p *PivXParser) txFromPivxTx(t *pivxTx, parseAddresses bool) bchain.Tx {
+	tx := p.TxFromMsgTx(&t.msgTx, parseAddresses)
+	// the hash of the transparent part is not the txid of the sapling transaction
+	tx.Txid = chainhash.DoubleHashH(t.raw).String()
+	tx.Version = t.version
+	if td := p.txData(t, tx.Txid); td != nil {
+		tx.CoinSpecificData = td
+	}
+	return tx
+}
+
+// txData returns the sapling part and the special transaction payload of the transaction or nil if it has none of them
+func (p *PivXParser) txData(t *pivxTx, txid string) *PivxTxData {
+	if t.sapling == nil && t.txType == TxTypeNormal {
+		return nil
+	}
+	td := PivxTxData{Sapling: t.sapling}
+	if t.txType != TxTypeNormal {
+		var err error
+		if td.Special, err = p.decodeSpecialTxData(t.txType, txid, t.payload); err != nil {
+			// the payload is verified by the backend, keep at least the type of the transaction
+			td.Special = &bchain.SpecialTxData{Type: t.txType, TypeName: specialTxTypeName(t.txType)}
+		}
+	}
+	return &td
+}
+
+// txDataFromHex decodes the sapling part and the special transaction payload from the hex representation of the transaction
+// returns nil if the transaction does not have any of them
+func (p *PivXParser) txDataFromHex(txHex string) (*PivxTxData, error) {
+	if txHex == "" {
+		return nil, nil
+	}
+	b, err := hex.DecodeString(txHex)
+	if err != nil {
+		return nil, err
+	}
+	// fast path, check the transaction version without decoding the whole transaction
+	if len(b) < 4 {
+		return nil, io.ErrUnexpectedEOF
+	}
+	if v, _ := utils.SplitTxVersion(int32(binary.LittleEndian.Uint32(b))); v < SaplingTxVersion {
+		return nil, nil
+	}
+	t, err := decodeTx(b, bytes.NewReader(b))
+	if err != nil {
+		return nil, err
+	}
+	return p.txData(t, chainhash.DoubleHashH(t.raw).String()), nil
+}
+
+// getTxData returns the PIVX specific data of the transaction
+// transactions from the backend json have the raw json in CoinSpecificData, the data are decoded from the hex
+func (p *PivXParser) getTxData(tx *bchain.Tx) (*PivxTxData, error) {
+	if td, ok := tx.CoinSpecificData.(*PivxTxData); ok {
+		return td, nil
+	}
+	td, err := p.txDataFromHex(tx.Hex)
+	if err != nil {
+		return nil, errors.Annotatef(err, "txid %v", tx.Txid)
+	}
+	return td, nil
+}
+
+// GetShieldedTxData returns summary of the shielded part of the transaction or nil if the transaction is not shielded
+func (p *PivXParser) GetShieldedTxData(tx *bchain.Tx) (*bchain.ShieldedTxData, error) {
+	td, err := p.getTxData(tx)
+	if err != nil {
+		return nil, err
+	}
+	if td == nil || td.Sapling == nil {
+		return nil, nil
+	}
+	sd := td.Sapling
+	r := bchain.ShieldedTxData{
+		ShieldedSpends:  sd.ShieldedSpends,
+		ShieldedOutputs: sd.ShieldedOutputs,
+	}
+	r.ValueBalanceSat.SetInt64(sd.ValueBalance)
+	return &r, nil
+}
@@ -0,0 +1,108 @@
+package pivx
+
+import (
+	"blockbook/bchain"
+	"blockbook/bchain/coins/utils"
+	"bytes"
+	"encoding/binary"
+
+	"github.com/juju/errors"
+)
+
+// PIVX special transaction types, the type is stored in the upper 16 bits of the transaction version
+const (
+	TxTypeNormal    = 0
+	TxTypeProReg    = 1
+	TxTypeProUpServ = 2
+	TxTypeProUpReg  = 3
+	TxTypeProUpRev  = 4
+	TxTypeLLMQComm  = 5
+)
+
+var specialTxTypeNames = map[int32]string{
+	TxTypeProReg:    "ProRegTx",
+	TxTypeProUpServ: "ProUpServTx",
+	TxTypeProUpReg:  "ProUpRegTx",
+	TxTypeProUpRev:  "ProUpRevTx",
+	TxTypeLLMQComm:  "LLMQCommTx",
+}
+
+func specialTxTypeName(txType int32) string {
+	if n, ok := specialTxTypeNames[txType]; ok {
+		return n
+	}
+	return "Unknown"
+}
+
+// decodeSpecialTxData decodes the extra payload of the special transaction with given txid
+func (p *PivXParser) decodeSpecialTxData(txType int32, txid string, payload []byte) (*bchain.SpecialTxData, error) {
+	sd := &bchain.SpecialTxData{
+		Type:     txType,
+		TypeName: specialTxTypeName(txType),
+	}
+	r := bytes.NewReader(payload)
+	var version uint16
+	if err := binary.Read(r, binary.LittleEndian, &version); err != nil {
+		return nil, errors.Annotatef(err, "nVersion")
+	}
+	var err error
+	switch txType {
+	case TxTypeProReg:
+		if sd.CollateralTxid, sd.CollateralVout, err = utils.ReadOutPoint(r); err != nil {
+			return nil, errors.Annotatef(err, "collateralOutpoint")
+		}
+		// null collateral outpoint means that the collateral is an output of the registration transaction
+		if sd.CollateralTxid == "" {
+			sd.CollateralTxid = txid
+		}
+		if sd.ServiceAddress, err = utils.ReadServiceAddress(r); err != nil {
+			return nil, errors.Annotatef(err, "addr")
+		}
+		if sd.OwnerAddress, err = utils.ReadKeyIDAddress(r, p); err != nil {
+			return nil, errors.Annotatef(err, "keyIDOwner")
+		}
+		if err = utils.SkipBytes(r, utils.BLSPublicKeySize); err != nil {
+			return nil, errors.Annotatef(err, "pubKeyOperator")
+		}
+		if sd.VotingAddress, err = utils.ReadKeyIDAddress(r, p); err != nil {
+			return nil, errors.Annotatef(err, "keyIDVoting")
+		}
+		if sd.PayoutAddress, err = utils.ReadScriptAddress(r, p); err != nil {
+			return nil, errors.Annotatef(err, "scriptPayout")
+		}
+	case TxTypeProUpServ:
+		if sd.ProTxHash, err = utils.ReadHash(r); err != nil {
+			return nil, errors.Annotatef(err, "proTxHash")
+		}
+		if sd.ServiceAddress, err = utils.ReadServiceAddress(r); err != nil {
+			return nil, errors.Annotatef(err, "addr")
+		}
+	case TxTypeProUpReg:
+		if sd.ProTxHash, err = utils.ReadHash(r); err != nil {
+			return nil, errors.Annotatef(err, "proTxHash")
+		}
+		if err = utils.SkipBytes(r, utils.BLSPublicKeySize); err != nil {
+			return nil, errors.Annotatef(err, "pubKeyOperator")
+		}
+		if sd.VotingAddress, err = utils.ReadKeyIDAddress(r, p); err != nil {
+			return nil, errors.Annotatef(err, "keyIDVoting")
+		}
+		if sd.PayoutAddress, err = utils.ReadScriptAddress(r, p); err != nil {
+			return nil, errors.Annotatef(err, "scriptPayout")
+		}
+	case TxTypeProUpRev:
+		if sd.ProTxHash, err = utils.ReadHash(r); err != nil {
+			return nil, errors.Annotatef(err, "proTxHash")
+		}
+	}
+	return sd, nil
+}
+
+// GetSpecialTxData returns decoded payload of the special (masternode provider) transaction or nil if the transaction is not special
+func (p *PivXParser) GetSpecialTxData(tx *bchain.Tx) (*bchain.SpecialTxData, error) {
+	td, err := p.getTxData(tx)
+	if err != nil || td == nil {
+		return nil, err
+	}
+	return td.Special, nil
+}
@@ -0,0 +1,47 @@
+package pivx
+
+import (
+	"blockbook/bchain"
+	"encoding/binary"
+	"encoding/hex"
+	"strings"
+)
+
+// zerocoin denominations are in whole coins
+const coinSat = 100000000
+
+// GetZerocoinMintsAndSpends returns denominations (in satoshis) of the zerocoin mints and spends of the transaction
+func (p *PivXParser) GetZerocoinMintsAndSpends(tx *bchain.Tx) ([]int64, []int64) {
+	var mints, spends []int64
+	for i := range tx.Vout {
+		if strings.HasPrefix(tx.Vout[i].ScriptPubKey.Hex, "c1") {
+			mints = append(mints, tx.Vout[i].ValueSat.Int64())
+		}
+	}
+	for i := range tx.Vin {
+		if strings.HasPrefix(tx.Vin[i].ScriptSig.Hex, "c2") {
+			script, err := hex.DecodeString(tx.Vin[i].ScriptSig.Hex)
+			if err != nil {
+				continue
+			}
+			if d := zerocoinSpendDenomination(script); d > 0 {
+				spends = append(spends, d*coinSat)
+			}
+		}
+	}
+	return mints, spends
+}
+
+// zerocoinSpendDenomination returns denomination of the zerocoin spend or 0 if the script is not valid
+// the script is OP_ZEROCOINSPEND <size of serialized CoinSpend> <serialized CoinSpend>, CoinSpend starts with the denomination
+func zerocoinSpendDenomination(script []byte) int64 {
+	if !isZeroCoinSpendScript(script) {
+		return 0
+	}
+	// the size is pushed as a number, the push opcode is the length of the number
+	l := int(script[1])
+	if l < 1 || l > 4 || len(script) < 2+l+4 {
+		return 0
+	}
+	return int64(int32(binary.LittleEndian.Uint32(script[2+l:])))
+}
@@ -0,0 +1,107 @@
+package utils
+
+import (
+	"blockbook/bchain"
+	"bytes"
+	"encoding/binary"
+	"io"
+	"net"
+	"strconv"
+
+	"github.com/martinboehm/btcd/chaincfg/chainhash"
+	"github.com/martinboehm/btcd/wire"
+)
+
+// helpers for decoding of the extra payload of the special transactions of the deterministic masternode chains (Dash DIP2, PIVX)
+
+// sizes of the serialized items of the special transaction payloads
+const (
+	KeyIDSize          = 20
+	BLSPublicKeySize   = 48
+	ServiceAddressSize = 18
+	MaxPayloadScript   = 10000
+)
+
+// SplitTxVersion splits 32bit version to 16bit transaction version and transaction type
+func SplitTxVersion(v int32) (int32, int32) {
+	return int32(int16(v & 0xffff)), int32(int16(v >> 16))
+}
+
+// SkipBytes skips n bytes of the reader, returns io.ErrUnexpectedEOF if there are not enough bytes
+func SkipBytes(r *bytes.Reader, n int) error {
+	if r.Len() < n {
+		return io.ErrUnexpectedEOF
+	}
+	_, err := r.Seek(int64(n), io.SeekCurrent)
+	return err
+}
+
+// ReadHash reads uint256, returns empty string for null hash
+func ReadHash(r *bytes.Reader) (string, error) {
+	var h chainhash.Hash
+	if _, err := io.ReadFull(r, h[:]); err != nil {
+		return "", err
+	}
+	if h == (chainhash.Hash{}) {
+		return "", nil
+	}
+	return h.String(), nil
+}
+
+// ReadOutPoint reads COutPoint, returns empty txid for null hash
+func ReadOutPoint(r *bytes.Reader) (string, uint32, error) {
+	txid, err := ReadHash(r)
+	if err != nil {
+		return "", 0, err
+	}
+	var n uint32
+	if err = binary.Read(r, binary.LittleEndian, &n); err != nil {
+		return "", 0, err
+	}
+	return txid, n, nil
+}
+
+// ReadServiceAddress reads CService, i.e. IPv6 (or IPv4 mapped) address and big endian port
+// returns empty string for unspecified address
+func ReadServiceAddress(r *bytes.Reader) (string, error) {
+	b := make([]byte, ServiceAddressSize)
+	if _, err := io.ReadFull(r, b); err != nil {
+		return "", err
+	}
+	ip := net.IP(b[:16])
+	if ip.IsUnspecified() {
+		return "", nil
+	}
+	return net.JoinHostPort(ip.String(), strconv.Itoa(int(binary.BigEndian.Uint16(b[16:])))), nil
+}
+
+// ReadKeyIDAddress reads key id (hash160 of the public key) and returns its P2PKH address
+func ReadKeyIDAddress(r *bytes.Reader, p bchain.BlockChainParser) (string, error) {
+	keyID := make([]byte, KeyIDSize)
+	if _, err := io.ReadFull(r, keyID); err != nil {
+		return "", err
+	}
+	script := make([]byte, 0, KeyIDSize+5)
+	script = append(script, 0x76, 0xa9, KeyIDSize)
+	script = append(script, keyID...)
+	script = append(script, 0x88, 0xac)
+	return AddressFromScript(script, p)
+}
+
+// ReadScriptAddress reads serialized script and returns its address
+func ReadScriptAddress(r *bytes.Reader, p bchain.BlockChainParser) (string, error) {
+	script, err := wire.ReadVarBytes(r, 0, MaxPayloadScript, "script")
+	if err != nil {
+		return "", err
+	}
+	return AddressFromScript(script, p)
+}
+
+// AddressFromScript returns the first address of the output script or empty string if the script does not have an address
+func AddressFromScript(script []byte, p bchain.BlockChainParser) (string, error) {
+	a, _, err := p.GetAddressesFromAddrDesc(script)
+	if err != nil || len(a) == 0 {
+		return "", err
+	}
+	return a[0], nil
+}
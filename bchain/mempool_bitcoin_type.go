@@ -91,10 +91,13 @@ func (m *MempoolBitcoinType) getTxAddrs(txid string, chanInput chan Outpoint, ch
 			continue
 		}
 		if len(addrDesc) > 0 {
-			io = append(io, addrIndex{string(addrDesc), int32(output.N)})
-		}
-		if m.OnNewTxAddr != nil {
-			m.OnNewTxAddr(tx, addrDesc)
+			l := len(io)
+			io = m.appendAddrIndex(io, addrDesc, int32(output.N))
+			if m.OnNewTxAddr != nil {
+				for _, ai := range io[l:] {
+					m.OnNewTxAddr(tx, AddressDescriptor(ai.addrDesc))
+				}
+			}
 		}
 	}
 	dispatched := 0
@@ -109,7 +112,7 @@ func (m *MempoolBitcoinType) getTxAddrs(txid string, chanInput chan Outpoint, ch
 			// store as many processed results as possible
 			case ai := <-chanResult:
 				if ai != nil {
-					io = append(io, *ai)
+					io = m.appendAddrIndex(io, AddressDescriptor(ai.addrDesc), ai.n)
 				}
 				dispatched--
 			// send input to be processed
@@ -122,12 +125,22 @@ func (m *MempoolBitcoinType) getTxAddrs(txid string, chanInput chan Outpoint, ch
 	for i := 0; i < dispatched; i++ {
 		ai := <-chanResult
 		if ai != nil {
-			io = append(io, *ai)
+			io = m.appendAddrIndex(io, AddressDescriptor(ai.addrDesc), ai.n)
 		}
 	}
 	return io, true
 }
 
+// appendAddrIndex appends addrDesc to the io the same way as the index stores it,
+// cold staking address descriptor is appended as its owner and staker instead of the descriptor itself
+func (m *MempoolBitcoinType) appendAddrIndex(io []addrIndex, addrDesc AddressDescriptor, n int32) []addrIndex {
+	owner, staker := m.chain.GetChainParser().GetColdStakingAddrDescs(addrDesc)
+	if owner != nil {
+		return append(io, addrIndex{string(owner), n}, addrIndex{string(staker), n})
+	}
+	return append(io, addrIndex{string(addrDesc), n})
+}
+
 // Resync gets mempool transactions and maps outputs to transactions.
 // Resync is not reentrant, it should be called from a single thread.
 // Read operations (GetTransactions) are safe.
@@ -90,6 +90,29 @@ type Tx struct {
 	CoinSpecificData interface{} `json:"-"`
 }
 
+// ShieldedTxData contains summary of the shielded (sapling) part of a transaction
+type ShieldedTxData struct {
+	// ValueBalanceSat is the net value moved from the shielded pool to the transparent part of the transaction
+	ValueBalanceSat big.Int
+	ShieldedSpends  int
+	ShieldedOutputs int
+}
+
+// SpecialTxData contains decoded payload of a special transaction of the chains with deterministic masternodes
+// the masternode (provider) transactions refer to the collateral and the keys of the masternode,
+// the update transactions refer to the registration transaction by ProTxHash
+type SpecialTxData struct {
+	Type           int32
+	TypeName       string
+	ProTxHash      string
+	CollateralTxid string
+	CollateralVout uint32
+	ServiceAddress string
+	OwnerAddress   string
+	VotingAddress  string
+	PayoutAddress  string
+}
+
 // Block is block header and list of transactions
 type Block struct {
 	BlockHeader
@@ -110,12 +133,21 @@ type BlockHeader struct {
 // BlockInfo contains extended block header data and a list of block txids
 type BlockInfo struct {
 	BlockHeader
-	Version    json.Number `json:"version"`
-	MerkleRoot string      `json:"merkleroot"`
-	Nonce      json.Number `json:"nonce"`
-	Bits       string      `json:"bits"`
-	Difficulty json.Number `json:"difficulty"`
-	Txids      []string    `json:"tx,omitempty"`
+	Version          json.Number `json:"version"`
+	MerkleRoot       string      `json:"merkleroot"`
+	Nonce            json.Number `json:"nonce"`
+	Bits             string      `json:"bits"`
+	Difficulty       json.Number `json:"difficulty"`
+	Txids            []string    `json:"tx,omitempty"`
+}
+
+// PosBlockData contains the data of the block of a proof of stake chain, which are not part of the block header returned by the backend
+// the staker is the address of the coinstake transaction, it is empty if it is not known
+type PosBlockData struct {
+	AccumulatorCheckpoint string
+	BlockSignature        string
+	ProofOfStake          bool
+	Staker                string
 }
 
 // MempoolEntry is used to get data about mempool entry
@@ -136,6 +168,21 @@ type MempoolEntry struct {
 	Depends         []string    `json:"depends"`
 }
 
+// BudgetProposal is a budget proposal of a masternode coin (PIVX) with its allotted payment in a superblock
+type BudgetProposal struct {
+	Name           string  `json:"name"`
+	Hash           string  `json:"hash"`
+	URL            string  `json:"url"`
+	PaymentAddress string  `json:"paymentAddress"`
+	Yeas           int     `json:"yeas"`
+	Nays           int     `json:"nays"`
+	Abstains       int     `json:"abstains"`
+	PaymentSat     big.Int `json:"payment"`
+	BlockStart     uint32  `json:"blockStart,omitempty"`
+	BlockEnd       uint32  `json:"blockEnd,omitempty"`
+	PaidSat        big.Int `json:"paid"`
+}
+
 // ChainInfo is used to get information about blockchain
 type ChainInfo struct {
 	Chain           string  `json:"chain"`
@@ -168,6 +215,18 @@ func (ad AddressDescriptor) String() string {
 	return "ad:" + hex.EncodeToString(ad)
 }
 
+// OutputDescriptor is a parsed output descriptor of an account with ranged extended public keys
+type OutputDescriptor struct {
+	// Descriptor is the descriptor without the checksum
+	Descriptor string
+	// BasePath is the derivation path of the first key of the descriptor up to the change index
+	BasePath string
+	// ChangeIndexes are the derivation indexes of the chains of the account, the receiving chain is the first
+	ChangeIndexes []uint32
+	// Data is the parser specific representation of the descriptor
+	Data interface{}
+}
+
 // EthereumType specific
 
 // Erc20Contract contains info about ERC20 contract
@@ -244,6 +303,12 @@ type BlockChain interface {
 	EthereumTypeEstimateGas(params map[string]interface{}) (uint64, error)
 	EthereumTypeGetErc20ContractInfo(contractDesc AddressDescriptor) (*Erc20Contract, error)
 	EthereumTypeGetErc20ContractBalance(addrDesc, contractDesc AddressDescriptor) (*big.Int, error)
+	// masternode specific, returns budget proposals projected to be paid in the next superblock
+	GetBudgetProjection() ([]BudgetProposal, error)
+	// masternode specific, returns budget proposals known by the backend together with their payment period
+	GetBudgetProposals() ([]BudgetProposal, error)
+	// proof of stake specific, returns the data of the block parsed from the raw block
+	GetPosBlockData(hash string) (*PosBlockData, error)
 }
 
 // BlockChainParser defines common interface to parsing and conversions of block chain data
@@ -285,8 +350,29 @@ type BlockChainParser interface {
 	DerivationBasePath(xpub string) (string, error)
 	DeriveAddressDescriptors(xpub string, change uint32, indexes []uint32) ([]AddressDescriptor, error)
 	DeriveAddressDescriptorsFromTo(xpub string, change uint32, fromIndex uint32, toIndex uint32) ([]AddressDescriptor, error)
+	// output descriptors, chain is the index to OutputDescriptor.ChangeIndexes
+	ParseOutputDescriptor(descriptor string) (*OutputDescriptor, error)
+	DeriveOutputDescriptorAddresses(descriptor *OutputDescriptor, chain int, fromIndex uint32, toIndex uint32) ([]AddressDescriptor, error)
 	// EthereumType specific
 	EthereumTypeGetErc20FromTx(tx *Tx) ([]Erc20Transfer, error)
+	// shielded transactions specific
+	GetShieldedTxData(tx *Tx) (*ShieldedTxData, error)
+	// deterministic masternodes specific, returns decoded payload of the special transaction or nil
+	GetSpecialTxData(tx *Tx) (*SpecialTxData, error)
+	// cold staking specific, the value of the cold staking outputs is attributed to the owner and to the staker as delegated
+	GetColdStakingAddrDescs(addrDesc AddressDescriptor) (AddressDescriptor, AddressDescriptor)
+	IsColdStakingStakerAddrDesc(addrDesc AddressDescriptor) bool
+	// OP_RETURN specific, returns the data carried by the OP_RETURN output or nil
+	GetOPReturnData(addrDesc AddressDescriptor) []byte
+	// proof of stake specific, coinstake transaction spends the staked outputs and emits them back together with the reward
+	SupportsCoinstake() bool
+	IsCoinstakeTx(tx *Tx) bool
+	// masternode specific, returns index of the masternode payment output of the coinbase or coinstake transaction or -1
+	GetMasternodePaymentVout(tx *Tx) int
+	// zerocoin specific, returns denominations of the zerocoin mints and spends of the transaction
+	GetZerocoinMintsAndSpends(tx *Tx) ([]int64, []int64)
+	// budget specific, returns number of blocks between superblocks or 0 if the chain does not have superblocks
+	SuperblockCycle() uint32
 }
 
 // Mempool defines common interface to mempool
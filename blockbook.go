@@ -13,6 +13,7 @@ import (
 	"flag"
 	"io/ioutil"
 	"log"
+	"math"
 	"math/rand"
 	"net/http"
 	_ "net/http/pprof"
@@ -52,9 +53,13 @@ var (
 	blockUntil     = flag.Int("blockuntil", -1, "height of the final block")
 	rollbackHeight = flag.Int("rollback", -1, "rollback to the given height and quit")
 
-	synchronize = flag.Bool("sync", false, "synchronizes until tip, if together with zeromq, keeps index synchronized")
-	repair      = flag.Bool("repair", false, "repair the database")
-	prof        = flag.String("prof", "", "http server binding [address]:port of the interface to profiling data /debug/pprof/ (default no profiling)")
+	synchronize  = flag.Bool("sync", false, "synchronizes until tip, if together with zeromq, keeps index synchronized")
+	repair       = flag.Bool("repair", false, "repair the database")
+	migrate      = flag.Bool("migrate", false, "migrate the database to the current version and exit (the migration from the version 5 replays all blocks from the backend, it takes about as long as a reindex, not supported with -prune)")
+	snapshotDir  = flag.String("snapshot", "", "create consistent snapshot of the database in given directory and exit")
+	snapshotBase = flag.String("snapshotbase", "", "directory in which the internal server creates the snapshots of the database (default snapshots by the internal server disabled)")
+	restoreDir   = flag.String("restore", "", "restore the database from the snapshot in given directory to empty datadir before the start")
+	prof         = flag.String("prof", "", "http server binding [address]:port of the interface to profiling data /debug/pprof/ (default no profiling)")
 
 	syncChunk   = flag.Int("chunk", 100, "block chunk size for processing in bulk mode")
 	syncWorkers = flag.Int("workers", 8, "number of workers to process blocks in bulk mode")
@@ -72,9 +77,16 @@ var (
 
 	noTxCache = flag.Bool("notxcache", false, "disable tx cache")
 
+	pruneDepth       = flag.Int("prune", 0, "prune the fully spent transactions and the tx cache older than given number of blocks, 0 disables pruning")
+	pruneTxCacheSize = flag.Int("prunetxcache", 0, "max size of the tx cache in MB in the pruned mode, 0 means not limited by size")
+
+	opReturnPrefixLength = flag.Int("opreturnprefix", 8, "length in bytes of the prefix of the OP_RETURN data by which the outputs are indexed, it cannot be changed without rebuilding the index")
+
 	computeColumnStats  = flag.Bool("computedbstats", false, "compute column stats and exit")
 	computeFeeStatsFlag = flag.Bool("computefeestats", false, "compute fee stats for blocks in blockheight-blockuntil range and exit")
 	dbStatsPeriodHours  = flag.Int("dbstatsperiod", 24, "period of db stats collection in hours, 0 disables stats collection")
+	checkDB             = flag.Bool("checkdb", false, "check consistency of the index for blocks in blockheight-blockuntil range and exit")
+	checkDBRepair       = flag.Bool("checkdbrepair", false, "together with checkdb, repair the balances of the addresses with discrepancies")
 
 	// resync index at least each resyncIndexPeriodMs (could be more often if invoked by message from ZeroMQ)
 	resyncIndexPeriodMs = flag.Int("resyncindexperiod", 935093, "resync index period in milliseconds")
@@ -171,12 +183,28 @@ func mainWithExitCode() int {
 		return exitCodeFatal
 	}
 
+	if *restoreDir != "" {
+		if _, err = db.RestoreSnapshot(*restoreDir, *dbPath, coin, chain.GetChainParser(), func(height uint32, hash string) error {
+			return verifyBlockHash(chain, height, hash)
+		}); err != nil {
+			glog.Error("restore: ", err)
+			return exitCodeFatal
+		}
+	}
+
 	index, err = db.NewRocksDB(*dbPath, *dbCache, *dbMaxOpenFiles, chain.GetChainParser(), metrics)
 	if err != nil {
 		glog.Error("rocksDB: ", err)
 		return exitCodeFatal
 	}
 	defer index.Close()
+	if *pruneDepth > 0 {
+		index.SetPruning(uint32(*pruneDepth), int64(*pruneTxCacheSize)<<20)
+	}
+	if err = index.SetOpReturnPrefixLength(*opReturnPrefixLength); err != nil {
+		glog.Error("rocksDB: ", err)
+		return exitCodeFatal
+	}
 
 	internalState, err = newInternalState(coin, coinShortcut, coinLabel, index)
 	if err != nil {
@@ -192,6 +220,55 @@ func mainWithExitCode() int {
 		glog.Warning("internalState: database was left in open state, possibly previous ungraceful shutdown")
 	}
 
+	if *migrate {
+		internalState.DbState = common.DbStateOpen
+		if err = index.Migrate(chain, chanOsSignal); err != nil {
+			if err == db.ErrOperationInterrupted {
+				glog.Info("migrate: interrupted, the migration will continue on the next run with -migrate")
+				return exitCodeOK
+			}
+			glog.Error("migrate: ", err)
+			return exitCodeFatal
+		}
+		return exitCodeOK
+	}
+	if index.MigrationRequired() {
+		glog.Error("internalState: database must be migrated to the current version, run blockbook with -migrate")
+		return exitCodeFatal
+	}
+
+	if *snapshotDir != "" {
+		if _, err = index.CreateSnapshot(*snapshotDir); err != nil {
+			glog.Error("snapshot: ", err)
+			return exitCodeFatal
+		}
+		return exitCodeOK
+	}
+
+	if *checkDB {
+		internalState.DbState = common.DbStateOpen
+		lower, higher := uint32(0), uint32(math.MaxUint32)
+		if *blockFrom >= 0 {
+			lower = uint32(*blockFrom)
+		}
+		if *blockUntil >= 0 {
+			higher = uint32(*blockUntil)
+		}
+		res, err := index.CheckDB(lower, higher, *checkDBRepair, chanOsSignal)
+		if err != nil {
+			if err == db.ErrOperationInterrupted {
+				return exitCodeOK
+			}
+			glog.Error("checkdb: ", err)
+			return exitCodeFatal
+		}
+		if res.Errors > 0 && !*checkDBRepair {
+			glog.Error("checkdb: found ", res.Errors, " discrepancies, run with -checkdbrepair to repair the balances")
+			return exitCodeFatal
+		}
+		return exitCodeOK
+	}
+
 	if *computeFeeStatsFlag {
 		internalState.DbState = common.DbStateOpen
 		err = computeFeeStats(chanOsSignal, *blockFrom, *blockUntil, index, chain, txCache, internalState, metrics)
@@ -213,6 +290,16 @@ func mainWithExitCode() int {
 		return exitCodeOK
 	}
 
+	internalState.DbState = common.DbStateOpen
+	if err = index.InitSpentTxs(chanOsSignal); err != nil {
+		if err == db.ErrOperationInterrupted {
+			glog.Info("rocksDB: interrupted, the sweep of the fully spent transactions will run again on the next start")
+			return exitCodeOK
+		}
+		glog.Error("rocksDB: ", err)
+		return exitCodeFatal
+	}
+
 	syncWorker, err = db.NewSyncWorker(index, chain, *syncWorkers, *syncChunk, *blockFrom, *dryRun, chanOsSignal, metrics, internalState)
 	if err != nil {
 		glog.Errorf("NewSyncWorker %v", err)
@@ -363,7 +450,7 @@ func getBlockChainWithRetry(coin string, configfile string, pushHandler func(bch
 }
 
 func startInternalServer() (*server.InternalServer, error) {
-	internalServer, err := server.NewInternalServer(*internalBinding, *certFiles, index, chain, mempool, txCache, internalState)
+	internalServer, err := server.NewInternalServer(*internalBinding, *certFiles, *snapshotBase, index, chain, mempool, txCache, internalState)
 	if err != nil {
 		return nil, err
 	}
@@ -428,6 +515,18 @@ func performRollback() error {
 	return nil
 }
 
+// verifyBlockHash checks that the backend has the block of given height and hash
+func verifyBlockHash(chain bchain.BlockChain, height uint32, hash string) error {
+	h, err := chain.GetBlockHash(height)
+	if err != nil {
+		return errors.Annotatef(err, "GetBlockHash %v", height)
+	}
+	if h != hash {
+		return errors.Errorf("backend has block %v at height %v", h, height)
+	}
+	return nil
+}
+
 func blockbookAppInfoMetric(db *db.RocksDB, chain bchain.BlockChain, txCache *db.TxCache, is *common.InternalState, metrics *common.Metrics) error {
 	api, err := api.NewWorker(db, chain, mempool, txCache, is)
 	if err != nil {
@@ -2,7 +2,6 @@ package common
 
 import (
 	"encoding/json"
-	"sort"
 	"sync"
 	"time"
 )
@@ -26,6 +25,20 @@ type InternalStateColumn struct {
 	Updated    time.Time `json:"updated"`
 }
 
+// InternalStateMigration contains the progress of the database migration, it allows to resume the interrupted migration
+type InternalStateMigration struct {
+	// Version is the version from which the database is being migrated
+	Version uint32 `json:"version"`
+	// Column is the name of the conversion of a column being done, the conversions preceding it in the migration are already done
+	Column string `json:"column"`
+	// LastKey is the last converted key of the column
+	LastKey []byte `json:"lastKey"`
+	Rows    int64  `json:"rows"`
+	// BuiltFrom is the height from which the columns rebuilt by the migration from the blocks were maintained by the index,
+	// the blocks below it are replayed, zero if there are no blocks to replay
+	BuiltFrom uint32 `json:"builtFrom,omitempty"`
+}
+
 // InternalState contains the data of the internal state
 type InternalState struct {
 	mux sync.Mutex
@@ -46,13 +59,16 @@ type InternalState struct {
 	IsSynchronized bool      `json:"isSynchronized"`
 	BestHeight     uint32    `json:"bestHeight"`
 	LastSync       time.Time `json:"lastSync"`
-	BlockTimes     []uint32  `json:"-"`
+	// PrunedHeight is the height up to which the fully spent transactions were removed from the pruned index
+	PrunedHeight uint32 `json:"-"`
 
 	IsMempoolSynchronized bool      `json:"isMempoolSynchronized"`
 	MempoolSize           int       `json:"mempoolSize"`
 	LastMempoolSync       time.Time `json:"lastMempoolSync"`
 
 	DbColumns []InternalStateColumn `json:"dbColumns"`
+
+	Migration *InternalStateMigration `json:"migration,omitempty"`
 }
 
 // StartedSync signals start of synchronization
@@ -166,52 +182,18 @@ func (is *InternalState) DBSizeTotal() int64 {
 	return total
 }
 
-// GetBlockTime returns block time if block found or 0
-func (is *InternalState) GetBlockTime(height uint32) uint32 {
-	is.mux.Lock()
-	defer is.mux.Unlock()
-	if int(height) < len(is.BlockTimes) {
-		return is.BlockTimes[height]
-	}
-	return 0
-}
-
-// AppendBlockTime appends block time to BlockTimes
-func (is *InternalState) AppendBlockTime(time uint32) {
+// GetPrunedHeight returns the height up to which the index was pruned, 0 if the index is not pruned
+func (is *InternalState) GetPrunedHeight() uint32 {
 	is.mux.Lock()
 	defer is.mux.Unlock()
-	is.BlockTimes = append(is.BlockTimes, time)
+	return is.PrunedHeight
 }
 
-// RemoveLastBlockTimes removes last times from BlockTimes
-func (is *InternalState) RemoveLastBlockTimes(count int) {
+// SetPrunedHeight sets the height up to which the index was pruned
+func (is *InternalState) SetPrunedHeight(height uint32) {
 	is.mux.Lock()
 	defer is.mux.Unlock()
-	if len(is.BlockTimes) < count {
-		count = len(is.BlockTimes)
-	}
-	is.BlockTimes = is.BlockTimes[:len(is.BlockTimes)-count]
-}
-
-// GetBlockHeightOfTime returns block height of the first block with time greater or equal to the given time or MaxUint32 if no such block
-func (is *InternalState) GetBlockHeightOfTime(time uint32) uint32 {
-	is.mux.Lock()
-	defer is.mux.Unlock()
-	height := sort.Search(len(is.BlockTimes), func(i int) bool { return time <= is.BlockTimes[i] })
-	if height == len(is.BlockTimes) {
-		return ^uint32(0)
-	}
-	// as the block times can sometimes be out of order try 20 blocks lower to locate a block with the time greater or equal to the given time
-	max, height := height, height-20
-	if height < 0 {
-		height = 0
-	}
-	for ; height <= max; height++ {
-		if time <= is.BlockTimes[height] {
-			break
-		}
-	}
-	return uint32(height)
+	is.PrunedHeight = height
 }
 
 // Pack marshals internal state to json
@@ -0,0 +1,116 @@
+package db
+
+import (
+	"blockbook/bchain"
+	"bytes"
+	"math"
+
+	"github.com/tecbot/gorocksdb"
+)
+
+// address activity
+// the balance of the address in the column addressBalance contains the heights of the first and the last block
+// with a transaction of the address, the heights are updated when the balance is modified by a connected block,
+// on disconnect the last height is found in the column addresses
+
+// setActivity records a transaction of the address in the block of given height, it must be called before Txs is incremented
+func (ab *AddrBalance) setActivity(height uint32) {
+	if ab.Txs == 0 || height < ab.FirstHeight {
+		ab.FirstHeight = height
+	}
+	if height > ab.LastHeight {
+		ab.LastHeight = height
+	}
+}
+
+// addrDescHeights returns the heights of the first and the last block with a transaction of the address, found is false if there is no such block
+// all rows of the address in the column addresses are read
+func (d *RocksDB) addrDescHeights(addrDesc bchain.AddressDescriptor) (first, last uint32, found bool) {
+	err := d.iterateAddrDescHeights(addrDesc, math.MaxUint32, func(height uint32) bool {
+		if !found {
+			last = height
+			found = true
+		}
+		first = height
+		return true
+	})
+	return first, last, found && err == nil
+}
+
+// lastAddrDescHeight returns the height of the last block not higher than higher with a transaction of the address
+func (d *RocksDB) lastAddrDescHeight(addrDesc bchain.AddressDescriptor, higher uint32) (last uint32, found bool) {
+	err := d.iterateAddrDescHeights(addrDesc, higher, func(height uint32) bool {
+		last = height
+		found = true
+		return false
+	})
+	return last, found && err == nil
+}
+
+// iterateAddrDescHeights passes the heights of the blocks not higher than higher with a transaction of the address to fn
+// from the newest to the oldest block, the iteration stops if fn returns false
+func (d *RocksDB) iterateAddrDescHeights(addrDesc bchain.AddressDescriptor, higher uint32, fn func(height uint32) bool) error {
+	it := d.db.NewIteratorCF(d.ro, d.cfh[cfAddresses])
+	defer it.Close()
+	for it.Seek(packAddressKey(addrDesc, higher)); it.Valid(); it.Next() {
+		key := it.Key().Data()
+		if !bytes.HasPrefix(key, addrDesc) {
+			break
+		}
+		// skip the keys of longer address descriptors with the same prefix
+		if len(key) != len(addrDesc)+packedHeightBytes {
+			continue
+		}
+		_, height, err := unpackAddressKey(key)
+		if err != nil {
+			return err
+		}
+		if !fn(height) {
+			break
+		}
+	}
+	return nil
+}
+
+// disconnectAddrActivity updates the heights of the activity of the addresses after the blocks from the height lower were disconnected
+// the rows of the column addresses of the disconnected blocks must be still present in the db, they are skipped
+func (d *RocksDB) disconnectAddrActivity(balances map[string]*AddrBalance, lower uint32) {
+	for addrDesc, ab := range balances {
+		if ab == nil || ab.LastHeight < lower {
+			continue
+		}
+		if ab.FirstHeight >= lower || lower == 0 {
+			ab.FirstHeight, ab.LastHeight = 0, 0
+			continue
+		}
+		if last, found := d.lastAddrDescHeight(bchain.AddressDescriptor(addrDesc), lower-1); found {
+			ab.LastHeight = last
+		} else {
+			ab.LastHeight = ab.FirstHeight
+		}
+	}
+}
+
+// migrateAddrActivity adds the heights of the first and the last block with a transaction of the address to the stored balance
+// the heights are inserted after the balance, before the utxos
+func migrateAddrActivity(d *RocksDB, wb *gorocksdb.WriteBatch, key, val []byte) error {
+	// the column addressBalance is used by the Bitcoin type coins only, it is shared with the column addressContracts
+	if d.chainParser.GetChainType() != bchain.ChainBitcoinType || len(val) < 3 {
+		return nil
+	}
+	_, l := unpackVaruint(val)
+	_, sl := unpackBigint(val[l:])
+	_, bl := unpackBigint(val[l+sl:])
+	l = l + sl + bl
+	first, last, _ := d.addrDescHeights(key)
+	varBuf := make([]byte, maxPackedBigintBytes)
+	buf := make([]byte, 0, len(val)+10)
+	buf = append(buf, val[:l]...)
+	fl := packVaruint(uint(first), varBuf)
+	buf = append(buf, varBuf[:fl]...)
+	ll := packVaruint(uint(last), varBuf)
+	buf = append(buf, varBuf[:ll]...)
+	buf = append(buf, val[l:]...)
+	wb.PutCF(d.cfh[cfAddressBalance], key, buf)
+	return nil
+}
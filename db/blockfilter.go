@@ -0,0 +1,370 @@
+package db
+
+import (
+	"blockbook/bchain"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"math/bits"
+	"sort"
+
+	"github.com/golang/glog"
+	"github.com/juju/errors"
+	"github.com/tecbot/gorocksdb"
+)
+
+// block filters
+// the column blockFilters contains the basic compact block filters defined by BIP158, the key is the height of the block,
+// the value is the filter header followed by the filter, the header is empty if the header of the previous block is not known,
+// i.e. the filters were not built from the genesis block
+// the filter contains the output scripts of the block except OP_RETURN and the scripts of the outputs spent by the block,
+// the scripts of the spent outputs are reconstructed from the address descriptors stored in txAddresses
+
+const (
+	// blockFilterP is the bit parameter of the Golomb-Rice coding of the basic filter
+	blockFilterP = 19
+	// blockFilterM is the inverse of the false positive rate of the basic filter
+	blockFilterM = 784931
+	// blockFilterHeaderLen is the length of the filter header
+	blockFilterHeaderLen = 32
+)
+
+// BlockFilter is the basic compact block filter of a block, the header and the filter are in the serialized form
+type BlockFilter struct {
+	Height uint32
+	Header []byte
+	Filter []byte
+}
+
+type blockFilter struct {
+	height uint32
+	header []byte
+	filter []byte
+}
+
+// sipHash24 computes SipHash-2-4 of the data with the key k0, k1
+func sipHash24(k0, k1 uint64, data []byte) uint64 {
+	v0 := k0 ^ 0x736f6d6570736575
+	v1 := k1 ^ 0x646f72616e646f6d
+	v2 := k0 ^ 0x6c7967656e657261
+	v3 := k1 ^ 0x7465646279746573
+	round := func() {
+		v0 += v1
+		v1 = bits.RotateLeft64(v1, 13)
+		v1 ^= v0
+		v0 = bits.RotateLeft64(v0, 32)
+		v2 += v3
+		v3 = bits.RotateLeft64(v3, 16)
+		v3 ^= v2
+		v0 += v3
+		v3 = bits.RotateLeft64(v3, 21)
+		v3 ^= v0
+		v2 += v1
+		v1 = bits.RotateLeft64(v1, 17)
+		v1 ^= v2
+		v2 = bits.RotateLeft64(v2, 32)
+	}
+	l := len(data)
+	for ; len(data) >= 8; data = data[8:] {
+		m := binary.LittleEndian.Uint64(data)
+		v3 ^= m
+		round()
+		round()
+		v0 ^= m
+	}
+	// the last block contains the remaining bytes and the length of the data in the most significant byte
+	var last [8]byte
+	copy(last[:], data)
+	last[7] = byte(l)
+	m := binary.LittleEndian.Uint64(last[:])
+	v3 ^= m
+	round()
+	round()
+	v0 ^= m
+	v2 ^= 0xff
+	round()
+	round()
+	round()
+	round()
+	return v0 ^ v1 ^ v2 ^ v3
+}
+
+// bitWriter writes the bits from the most significant bit of each byte
+type bitWriter struct {
+	buf  []byte
+	free uint
+}
+
+func (w *bitWriter) writeBit(bit bool) {
+	if w.free == 0 {
+		w.buf = append(w.buf, 0)
+		w.free = 8
+	}
+	w.free--
+	if bit {
+		w.buf[len(w.buf)-1] |= 1 << w.free
+	}
+}
+
+func (w *bitWriter) writeBits(v uint64, n uint) {
+	for n > 0 {
+		n--
+		w.writeBit(v&(1<<n) != 0)
+	}
+}
+
+// packCompactSize packs the number in the bitcoin CompactSize format
+func packCompactSize(n uint64) []byte {
+	switch {
+	case n < 0xfd:
+		return []byte{byte(n)}
+	case n <= 0xffff:
+		buf := []byte{0xfd, 0, 0}
+		binary.LittleEndian.PutUint16(buf[1:], uint16(n))
+		return buf
+	case n <= 0xffffffff:
+		buf := []byte{0xfe, 0, 0, 0, 0}
+		binary.LittleEndian.PutUint32(buf[1:], uint32(n))
+		return buf
+	}
+	buf := []byte{0xff, 0, 0, 0, 0, 0, 0, 0, 0}
+	binary.LittleEndian.PutUint64(buf[1:], n)
+	return buf
+}
+
+// buildGCSFilter builds the Golomb-coded set of the items with the parameters P and M, the key is the first 16 bytes of blockHash
+// the items must be unique, the filter is prefixed by the number of the items
+func buildGCSFilter(p uint, m uint64, key []byte, items [][]byte) []byte {
+	k0 := binary.LittleEndian.Uint64(key[0:8])
+	k1 := binary.LittleEndian.Uint64(key[8:16])
+	n := uint64(len(items))
+	f := n * m
+	values := make([]uint64, len(items))
+	for i, item := range items {
+		// map the hash uniformly to the range [0, f) as the high 64 bits of the 128 bit product
+		values[i], _ = bits.Mul64(sipHash24(k0, k1, item), f)
+	}
+	sort.Slice(values, func(i, j int) bool { return values[i] < values[j] })
+	w := bitWriter{buf: packCompactSize(n)}
+	var last uint64
+	for _, v := range values {
+		delta := v - last
+		last = v
+		for q := delta >> p; q > 0; q-- {
+			w.writeBit(true)
+		}
+		w.writeBit(false)
+		w.writeBits(delta, p)
+	}
+	return w.buf
+}
+
+func doubleSha256(data ...[]byte) []byte {
+	h := sha256.New()
+	for _, d := range data {
+		h.Write(d)
+	}
+	first := h.Sum(nil)
+	second := sha256.Sum256(first)
+	return second[:]
+}
+
+// reverseBytes returns the reversed copy of the bytes, the hashes are displayed in the reversed byte order
+func reverseBytes(b []byte) []byte {
+	r := make([]byte, len(b))
+	for i := range b {
+		r[len(b)-1-i] = b[i]
+	}
+	return r
+}
+
+// blockFilterHeader computes the header of the filter from the header of the filter of the previous block
+func blockFilterHeader(filter, prevHeader []byte) []byte {
+	return doubleSha256(doubleSha256(filter), prevHeader)
+}
+
+// blockFilterItems returns the unique scripts of the outputs and of the spent outputs of the block
+func (d *RocksDB) blockFilterItems(block *bchain.Block, txAddressesMap map[string]*TxAddresses) ([][]byte, error) {
+	var items [][]byte
+	seen := make(map[string]struct{})
+	add := func(script []byte) {
+		if len(script) == 0 {
+			return
+		}
+		if _, found := seen[string(script)]; !found {
+			seen[string(script)] = struct{}{}
+			items = append(items, script)
+		}
+	}
+	for i := range block.Txs {
+		tx := &block.Txs[i]
+		for j := range tx.Vout {
+			script, err := hex.DecodeString(tx.Vout[j].ScriptPubKey.Hex)
+			if err != nil {
+				return nil, errors.Annotatef(err, "tx %v, vout %v", tx.Txid, j)
+			}
+			// OP_RETURN outputs are not spendable and are not included in the filter
+			if len(script) > 0 && script[0] != 0x6a {
+				add(script)
+			}
+		}
+		btxID, err := d.chainParser.PackTxid(tx.Txid)
+		if err != nil {
+			return nil, err
+		}
+		ta := txAddressesMap[string(btxID)]
+		if ta == nil {
+			continue
+		}
+		for j := range ta.Inputs {
+			if len(ta.Inputs[j].AddrDesc) == 0 {
+				continue
+			}
+			script, err := d.chainParser.GetScriptFromAddrDesc(ta.Inputs[j].AddrDesc)
+			if err != nil {
+				glog.Warningf("rocksdb: block filter, height %d, tx %v, input %v, error %v", block.Height, tx.Txid, j, err)
+				continue
+			}
+			add(script)
+		}
+	}
+	return items, nil
+}
+
+// processBlockFilter builds the filter of the block, prevHeader is the header of the filter of the previous block or nil if not known
+func (d *RocksDB) processBlockFilter(block *bchain.Block, txAddressesMap map[string]*TxAddresses, prevHeader []byte) (*blockFilter, error) {
+	items, err := d.blockFilterItems(block, txAddressesMap)
+	if err != nil {
+		return nil, err
+	}
+	hash, err := hex.DecodeString(block.Hash)
+	if err != nil || len(hash) < 16 {
+		return nil, errors.Errorf("Invalid block hash %v", block.Hash)
+	}
+	bf := &blockFilter{
+		height: block.Height,
+		filter: buildGCSFilter(blockFilterP, blockFilterM, reverseBytes(hash), items),
+	}
+	if block.Height == 0 {
+		prevHeader = make([]byte, blockFilterHeaderLen)
+	}
+	if prevHeader != nil {
+		bf.header = blockFilterHeader(bf.filter, prevHeader)
+	}
+	return bf, nil
+}
+
+// getBlockFilterHeader returns the stored header of the filter of the block or nil if it is not known
+func (d *RocksDB) getBlockFilterHeader(height uint32) ([]byte, error) {
+	val, err := d.db.GetCF(d.ro, d.cfh[cfBlockFilters], packUint(height))
+	if err != nil {
+		return nil, err
+	}
+	defer val.Free()
+	if len(val.Data()) < 1+blockFilterHeaderLen || val.Data()[0] != blockFilterHeaderLen {
+		return nil, nil
+	}
+	return append([]byte(nil), val.Data()[1:1+blockFilterHeaderLen]...), nil
+}
+
+// connectBlockFilter builds the filter of the block from the header of the filter of the previous block stored in the db
+func (d *RocksDB) connectBlockFilter(wb *gorocksdb.WriteBatch, block *bchain.Block, txAddressesMap map[string]*TxAddresses) error {
+	var prevHeader []byte
+	if block.Height > 0 {
+		var err error
+		if prevHeader, err = d.getBlockFilterHeader(block.Height - 1); err != nil {
+			return err
+		}
+	}
+	bf, err := d.processBlockFilter(block, txAddressesMap, prevHeader)
+	if err != nil {
+		return err
+	}
+	d.storeBlockFilter(wb, bf)
+	return nil
+}
+
+func packBlockFilter(bf *blockFilter) []byte {
+	buf := make([]byte, 0, 1+len(bf.header)+len(bf.filter))
+	buf = append(buf, byte(len(bf.header)))
+	buf = append(buf, bf.header...)
+	return append(buf, bf.filter...)
+}
+
+func unpackBlockFilter(buf []byte) (*blockFilter, error) {
+	if len(buf) == 0 || len(buf) < 1+int(buf[0]) {
+		return nil, errors.Errorf("Inconsistent data in blockFilters %v", hex.EncodeToString(buf))
+	}
+	l := 1 + int(buf[0])
+	bf := &blockFilter{filter: append([]byte(nil), buf[l:]...)}
+	if l > 1 {
+		bf.header = append([]byte(nil), buf[1:l]...)
+	}
+	return bf, nil
+}
+
+func (d *RocksDB) storeBlockFilter(wb *gorocksdb.WriteBatch, bf *blockFilter) {
+	wb.PutCF(d.cfh[cfBlockFilters], packUint(bf.height), packBlockFilter(bf))
+}
+
+// rebaseBlockFilters computes the headers of the filters stored from the height builtFrom, which were stored without
+// the headers because the filters of the lower blocks were not known, from the headers of the filters of the replayed blocks
+func rebaseBlockFilters(d *RocksDB, builtFrom uint32) (migrateRowFunc, error) {
+	var prevHeader []byte
+	var prevHeight uint32
+	return func(d *RocksDB, wb *gorocksdb.WriteBatch, key, val []byte) error {
+		height := unpackUint(key)
+		if height < builtFrom {
+			return nil
+		}
+		// the header of the previous filter is read from the db at the start and after the resume of the migration
+		if prevHeader == nil || prevHeight+1 != height {
+			var err error
+			if prevHeader, err = d.getBlockFilterHeader(height - 1); err != nil {
+				return err
+			}
+		}
+		bf, err := unpackBlockFilter(val)
+		if err != nil {
+			return err
+		}
+		bf.height = height
+		bf.header = nil
+		if prevHeader != nil {
+			bf.header = blockFilterHeader(bf.filter, prevHeader)
+		}
+		d.storeBlockFilter(wb, bf)
+		prevHeader, prevHeight = bf.header, height
+		return nil
+	}, nil
+}
+
+// disconnectBlockFilter removes the filter of the disconnected block
+func (d *RocksDB) disconnectBlockFilter(wb *gorocksdb.WriteBatch, height uint32) {
+	wb.DeleteCF(d.cfh[cfBlockFilters], packUint(height))
+}
+
+// GetBlockFilter returns the basic filter of the block of given height or nil if the filter was not built
+// the header is nil if the filters were not built from the genesis block
+func (d *RocksDB) GetBlockFilter(height uint32) (*BlockFilter, error) {
+	if d.chainParser.GetChainType() != bchain.ChainBitcoinType {
+		return nil, errors.New("Block filters are supported only for Bitcoin type coins")
+	}
+	val, err := d.db.GetCF(d.ro, d.cfh[cfBlockFilters], packUint(height))
+	if err != nil {
+		return nil, err
+	}
+	defer val.Free()
+	if len(val.Data()) == 0 {
+		return nil, nil
+	}
+	bf, err := unpackBlockFilter(val.Data())
+	if err != nil {
+		return nil, err
+	}
+	return &BlockFilter{
+		Height: height,
+		Header: bf.header,
+		Filter: bf.filter,
+	}, nil
+}
@@ -0,0 +1,176 @@
+package db
+
+import (
+	"blockbook/bchain"
+
+	vlq "github.com/bsm/go-vlq"
+	"github.com/tecbot/gorocksdb"
+)
+
+// producers of the blocks of proof of stake chains are stored in the column blockStakers
+// key is the height of the block
+// value is the type of the block, the number of proof of stake blocks up to the block (inclusive)
+// and the address descriptor of the staker, which is empty for proof of work blocks and for stakes with unknown staker (zerocoin stakes)
+
+const (
+	blockTypePoW = 0
+	blockTypePoS = 1
+)
+
+// BlockStaker contains the producer of a block
+type BlockStaker struct {
+	Height       uint32
+	ProofOfStake bool
+	PosBlocks    uint32
+	Staker       bchain.AddressDescriptor
+}
+
+// processBlockStaker returns the producer of the block, posBlocks is the number of proof of stake blocks before the block
+func (d *RocksDB) processBlockStaker(block *bchain.Block, txAddressesMap map[string]*TxAddresses, posBlocks uint32) (*BlockStaker, error) {
+	bs := &BlockStaker{
+		Height:    block.Height,
+		PosBlocks: posBlocks,
+	}
+	if len(block.Txs) < 2 || !d.chainParser.IsCoinstakeTx(&block.Txs[1]) {
+		return bs, nil
+	}
+	bs.ProofOfStake = true
+	bs.PosBlocks++
+	btxID, err := d.chainParser.PackTxid(block.Txs[1].Txid)
+	if err != nil {
+		return nil, err
+	}
+	ta := txAddressesMap[string(btxID)]
+	if ta == nil || len(ta.Inputs) == 0 {
+		return bs, nil
+	}
+	tai := &ta.Inputs[0]
+	// the value of the zerocoin stake is not known and its address descriptor is the spend script
+	if len(tai.AddrDesc) == 0 || len(tai.AddrDesc) > maxAddrDescLen || tai.ValueSat.Sign() == 0 {
+		return bs, nil
+	}
+	// cold stake is produced by the staker
+	if _, staker := d.chainParser.GetColdStakingAddrDescs(tai.AddrDesc); staker != nil {
+		bs.Staker = staker
+	} else {
+		bs.Staker = tai.AddrDesc
+	}
+	return bs, nil
+}
+
+// connectBlockStaker stores the producer of the block, only for proof of stake chains
+func (d *RocksDB) connectBlockStaker(wb *gorocksdb.WriteBatch, block *bchain.Block, txAddressesMap map[string]*TxAddresses) error {
+	if !d.chainParser.SupportsCoinstake() {
+		return nil
+	}
+	var posBlocks uint32
+	if block.Height > 0 {
+		prev, err := d.GetBlockStaker(block.Height - 1)
+		if err != nil {
+			return err
+		}
+		if prev != nil {
+			posBlocks = prev.PosBlocks
+		}
+	}
+	bs, err := d.processBlockStaker(block, txAddressesMap, posBlocks)
+	if err != nil {
+		return err
+	}
+	d.storeBlockStaker(wb, bs)
+	return nil
+}
+
+func (d *RocksDB) storeBlockStaker(wb *gorocksdb.WriteBatch, bs *BlockStaker) {
+	wb.PutCF(d.cfh[cfBlockStakers], packUint(bs.Height), packBlockStaker(bs))
+}
+
+func packBlockStaker(bs *BlockStaker) []byte {
+	buf := make([]byte, 1+vlq.MaxLen32+len(bs.Staker))
+	if bs.ProofOfStake {
+		buf[0] = blockTypePoS
+	} else {
+		buf[0] = blockTypePoW
+	}
+	l := 1 + packVaruint(uint(bs.PosBlocks), buf[1:])
+	l += copy(buf[l:], bs.Staker)
+	return buf[:l]
+}
+
+func unpackBlockStaker(height uint32, buf []byte) *BlockStaker {
+	if len(buf) < 2 {
+		return nil
+	}
+	posBlocks, l := unpackVaruint(buf[1:])
+	bs := &BlockStaker{
+		Height:       height,
+		ProofOfStake: buf[0] == blockTypePoS,
+		PosBlocks:    uint32(posBlocks),
+	}
+	if len(buf) > 1+l {
+		bs.Staker = append(bchain.AddressDescriptor(nil), buf[1+l:]...)
+	}
+	return bs
+}
+
+// GetBlockStaker returns the producer of the block of given height or nil if it is not known
+func (d *RocksDB) GetBlockStaker(height uint32) (*BlockStaker, error) {
+	val, err := d.db.GetCF(d.ro, d.cfh[cfBlockStakers], packUint(height))
+	if err != nil {
+		return nil, err
+	}
+	defer val.Free()
+	return unpackBlockStaker(height, val.Data()), nil
+}
+
+// GetBlockStakersCallback is called by GetBlockStakers for each block
+type GetBlockStakersCallback func(bs *BlockStaker) error
+
+// GetBlockStakers calls fn for producers of the blocks between lower and higher height, in ascending order of height
+func (d *RocksDB) GetBlockStakers(lower uint32, higher uint32, fn GetBlockStakersCallback) error {
+	it := d.db.NewIteratorCF(d.ro, d.cfh[cfBlockStakers])
+	defer it.Close()
+	for it.Seek(packUint(lower)); it.Valid(); it.Next() {
+		height := unpackUint(it.Key().Data())
+		if height > higher {
+			break
+		}
+		bs := unpackBlockStaker(height, it.Value().Data())
+		if bs == nil {
+			continue
+		}
+		if err := fn(bs); err != nil {
+			if _, ok := err.(*StopIteration); ok {
+				return nil
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+// disconnectBlockStaker removes the producer of the disconnected block
+func (d *RocksDB) disconnectBlockStaker(wb *gorocksdb.WriteBatch, height uint32) {
+	wb.DeleteCF(d.cfh[cfBlockStakers], packUint(height))
+}
+
+// rebaseBlockStakers adds the number of the replayed proof of stake blocks to the producers stored from the height builtFrom
+func rebaseBlockStakers(d *RocksDB, builtFrom uint32) (migrateRowFunc, error) {
+	base, err := d.GetBlockStaker(builtFrom - 1)
+	if err != nil {
+		return nil, err
+	}
+	return func(d *RocksDB, wb *gorocksdb.WriteBatch, key, val []byte) error {
+		height := unpackUint(key)
+		if base == nil || base.PosBlocks == 0 || height < builtFrom {
+			return nil
+		}
+		bs := unpackBlockStaker(height, val)
+		if bs == nil {
+			return nil
+		}
+		bs.PosBlocks += base.PosBlocks
+		d.storeBlockStaker(wb, bs)
+		return nil
+	}, nil
+}
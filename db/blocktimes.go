@@ -0,0 +1,109 @@
+package db
+
+import (
+	"github.com/golang/glog"
+	"github.com/tecbot/gorocksdb"
+)
+
+// block times
+// the column blockTimes maps the time to the height of the block, the key is the maximum of the times of the block
+// and of all lower blocks followed by the height of the block, the value is empty
+// the block times are not monotonic, however the maximum is, therefore the keys are ordered by the height and
+// the first key with the time greater or equal to the given time belongs to the first block with such time
+
+// lastBlockTime caches the maximum time of the last stored block to avoid reading it from the db for each connected block
+type lastBlockTime struct {
+	height  uint32
+	maxTime uint32
+	valid   bool
+}
+
+func packBlockTimeKey(time, height uint32) []byte {
+	return append(packUint(time), packUint(height)...)
+}
+
+func unpackBlockTimeKey(key []byte) (uint32, uint32) {
+	return unpackUint(key[:4]), unpackUint(key[4:8])
+}
+
+// maxBlockTimeBelow returns the maximum time of the blocks lower than height
+func (d *RocksDB) maxBlockTimeBelow(height uint32) (uint32, error) {
+	if height == 0 {
+		return 0, nil
+	}
+	if d.lbt.valid && d.lbt.height+1 == height {
+		return d.lbt.maxTime, nil
+	}
+	it := d.db.NewIteratorCF(d.ro, d.cfh[cfBlockTimes])
+	defer it.Close()
+	for it.SeekToLast(); it.Valid(); it.Prev() {
+		t, h := unpackBlockTimeKey(it.Key().Data())
+		if h == height-1 {
+			return t, nil
+		}
+		if h < height-1 {
+			break
+		}
+	}
+	glog.Warning("rocksdb: block time of height ", height-1, " not found")
+	return 0, nil
+}
+
+// storeBlockTime stores the time of the block to the column blockTimes, the blocks must be stored in the order of the height
+func (d *RocksDB) storeBlockTime(wb *gorocksdb.WriteBatch, height, time uint32) error {
+	maxTime, err := d.maxBlockTimeBelow(height)
+	if err != nil {
+		return err
+	}
+	if time > maxTime {
+		maxTime = time
+	}
+	wb.PutCF(d.cfh[cfBlockTimes], packBlockTimeKey(maxTime, height), []byte{})
+	d.lbt = lastBlockTime{height: height, maxTime: maxTime, valid: true}
+	return nil
+}
+
+// disconnectBlockTimes removes the times of the blocks from the height lower up
+func (d *RocksDB) disconnectBlockTimes(wb *gorocksdb.WriteBatch, lower uint32) {
+	it := d.db.NewIteratorCF(d.ro, d.cfh[cfBlockTimes])
+	defer it.Close()
+	for it.SeekToLast(); it.Valid(); it.Prev() {
+		if _, h := unpackBlockTimeKey(it.Key().Data()); h < lower {
+			break
+		}
+		wb.DeleteCF(d.cfh[cfBlockTimes], append([]byte(nil), it.Key().Data()...))
+	}
+	d.lbt.valid = false
+}
+
+// migrateBlockTimes builds the column blockTimes from the column height, the rows are migrated in the order of the height
+func migrateBlockTimes(d *RocksDB, wb *gorocksdb.WriteBatch, key, val []byte) error {
+	info, err := d.unpackBlockInfo(val)
+	if err != nil {
+		return err
+	}
+	return d.storeBlockTime(wb, unpackUint(key), uint32(info.Time))
+}
+
+// GetBlockHeightOfTime returns the height of the first block with the time greater or equal to the given time
+// the second returned value is false if there is no such block
+func (d *RocksDB) GetBlockHeightOfTime(time uint32) (uint32, bool, error) {
+	it := d.db.NewIteratorCF(d.ro, d.cfh[cfBlockTimes])
+	defer it.Close()
+	it.Seek(packUint(time))
+	if !it.Valid() {
+		return 0, false, nil
+	}
+	_, height := unpackBlockTimeKey(it.Key().Data())
+	return height, true, nil
+}
+
+// GetBlockTime returns the time of the block as stored in the column height, 0 if the block is not found
+// the time is not monotonic, the ranges of times must be converted to the ranges of heights by GetBlockHeightOfTime
+func (d *RocksDB) GetBlockTime(height uint32) (uint32, error) {
+	bi, err := d.GetBlockInfo(height)
+	if err != nil || bi == nil {
+		return 0, err
+	}
+	return uint32(bi.Time), nil
+}
@@ -0,0 +1,190 @@
+package db
+
+import (
+	"blockbook/bchain"
+	"encoding/json"
+	"math/big"
+
+	"github.com/golang/glog"
+	"github.com/juju/errors"
+	"github.com/tecbot/gorocksdb"
+)
+
+// budget proposals paid in the superblocks are stored in the column superblocks
+// the proposals come from the backend, the projection of the next superblock is stored after each sync and the last one
+// stored before the superblock is kept, the proposals of the past superblocks are backfilled after the initial sync
+// from the proposals known by the backend, the proposals removed from the backend cannot be backfilled
+// the paid amounts come from the blocks, the budget payments are indexed as masternode payments to the proposal payees,
+// the payments of the proposals in the blocks of the budget cycle are summed after each sync
+// key is the height of the superblock, value is the list of the proposals in json
+
+// StoreSuperblockBudget stores budget proposals projected to be paid in the superblock of given height
+func (d *RocksDB) StoreSuperblockBudget(height uint32, proposals []bchain.BudgetProposal) error {
+	buf, err := json.Marshal(proposals)
+	if err != nil {
+		return err
+	}
+	return d.db.PutCF(d.wo, d.cfh[cfSuperblocks], packUint(height), buf)
+}
+
+func unpackSuperblockBudget(buf []byte) ([]bchain.BudgetProposal, error) {
+	var proposals []bchain.BudgetProposal
+	if err := json.Unmarshal(buf, &proposals); err != nil {
+		return nil, errors.Annotatef(err, "unpackSuperblockBudget")
+	}
+	return proposals, nil
+}
+
+// GetSuperblockBudget returns budget proposals of the superblock of given height or nil if the superblock is not known
+func (d *RocksDB) GetSuperblockBudget(height uint32) ([]bchain.BudgetProposal, error) {
+	val, err := d.db.GetCF(d.ro, d.cfh[cfSuperblocks], packUint(height))
+	if err != nil {
+		return nil, err
+	}
+	defer val.Free()
+	buf := val.Data()
+	if len(buf) == 0 {
+		return nil, nil
+	}
+	return unpackSuperblockBudget(buf)
+}
+
+// GetSuperblocksCallback is called by GetSuperblockBudgets for each stored superblock
+type GetSuperblocksCallback func(height uint32, proposals []bchain.BudgetProposal) error
+
+// GetSuperblockBudgets calls fn for all stored superblocks, newest first
+func (d *RocksDB) GetSuperblockBudgets(fn GetSuperblocksCallback) error {
+	it := d.db.NewIteratorCF(d.ro, d.cfh[cfSuperblocks])
+	defer it.Close()
+	for it.SeekToLast(); it.Valid(); it.Prev() {
+		proposals, err := unpackSuperblockBudget(it.Value().Data())
+		if err != nil {
+			return err
+		}
+		if err := fn(unpackUint(it.Key().Data()), proposals); err != nil {
+			if _, ok := err.(*StopIteration); ok {
+				return nil
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+// disconnectSuperblockBudgets removes the budget projections of the superblocks from the height lower
+// the projection of a disconnected superblock may not match the superblock which replaces it,
+// the projection of the next superblock is stored again after the sync
+func (d *RocksDB) disconnectSuperblockBudgets(wb *gorocksdb.WriteBatch, lower uint32) {
+	it := d.db.NewIteratorCF(d.ro, d.cfh[cfSuperblocks])
+	defer it.Close()
+	for it.Seek(packUint(lower)); it.Valid(); it.Next() {
+		wb.DeleteCF(d.cfh[cfSuperblocks], append([]byte(nil), it.Key().Data()...))
+	}
+}
+
+// updateSuperblockPayments sets the amounts paid to the proposals in the blocks of the budget cycle of the superblock
+// up to the height best and stores the proposals
+func (d *RocksDB) updateSuperblockPayments(superblock, cycle, best uint32, proposals []bchain.BudgetProposal) error {
+	higher := superblock + cycle - 1
+	if higher > best {
+		higher = best
+	}
+	for i := range proposals {
+		bp := &proposals[i]
+		bp.PaidSat.SetInt64(0)
+		addrDesc, err := d.chainParser.GetAddrDescFromAddress(bp.PaymentAddress)
+		if err != nil {
+			glog.Warning("rocksdb: superblock ", superblock, ", proposal ", bp.Name, " with invalid payment address ", bp.PaymentAddress)
+			continue
+		}
+		if err = d.GetAddrDescMasternodePayments(addrDesc, superblock, higher, func(height uint32, valueSat *big.Int) error {
+			bp.PaidSat.Add(&bp.PaidSat, valueSat)
+			return nil
+		}); err != nil {
+			return err
+		}
+	}
+	return d.StoreSuperblockBudget(superblock, proposals)
+}
+
+// storeSuperblockBudget stores the budget projection of the next superblock after the block of given height
+// and updates the payments of the superblock of the current budget cycle
+func (w *SyncWorker) storeSuperblockBudget(height, cycle uint32) {
+	superblock := height - height%cycle
+	if superblock > 0 {
+		proposals, err := w.db.GetSuperblockBudget(superblock)
+		if err != nil {
+			glog.Error("GetSuperblockBudget ", superblock, " error ", err)
+		} else if proposals != nil {
+			if err = w.db.updateSuperblockPayments(superblock, cycle, height, proposals); err != nil {
+				glog.Error("updateSuperblockPayments ", superblock, " error ", err)
+			}
+		}
+	}
+	proposals, err := w.chain.GetBudgetProjection()
+	if err != nil {
+		glog.Error("GetBudgetProjection error ", err)
+		return
+	}
+	next := superblock + cycle
+	if err = w.db.StoreSuperblockBudget(next, proposals); err != nil {
+		glog.Error("StoreSuperblockBudget ", next, " error ", err)
+	}
+}
+
+// backfillSuperblockBudgets adds the proposals known by the backend to the superblocks of their payment period
+// up to the block of given height and updates the payments of these superblocks
+func (w *SyncWorker) backfillSuperblockBudgets(height, cycle uint32) {
+	proposals, err := w.chain.GetBudgetProposals()
+	if err != nil {
+		glog.Error("GetBudgetProposals error ", err)
+		return
+	}
+	superblocks := make(map[uint32][]bchain.BudgetProposal)
+	for i := range proposals {
+		bp := &proposals[i]
+		// the payment period starts by the superblock, BlockEnd is after the last superblock of the period
+		for superblock := bp.BlockStart - bp.BlockStart%cycle; superblock < bp.BlockEnd && superblock <= height; superblock += cycle {
+			if superblock >= bp.BlockStart && superblock > 0 {
+				superblocks[superblock] = append(superblocks[superblock], *bp)
+			}
+		}
+	}
+	for superblock, backfill := range superblocks {
+		stored, err := w.db.GetSuperblockBudget(superblock)
+		if err != nil {
+			glog.Error("GetSuperblockBudget ", superblock, " error ", err)
+			continue
+		}
+		known := make(map[string]struct{}, len(stored))
+		for i := range stored {
+			known[stored[i].Hash] = struct{}{}
+		}
+		for i := range backfill {
+			if _, found := known[backfill[i].Hash]; !found {
+				stored = append(stored, backfill[i])
+			}
+		}
+		if err = w.db.updateSuperblockPayments(superblock, cycle, height, stored); err != nil {
+			glog.Error("updateSuperblockPayments ", superblock, " error ", err)
+		}
+	}
+	glog.Info("sync: budget proposals of ", len(superblocks), " superblocks backfilled")
+}
+
+// updateSuperblocks stores the budget data of the superblocks after the sync, after the initial sync the past superblocks are backfilled
+func (w *SyncWorker) updateSuperblocks(initialSync bool) {
+	cycle := w.chain.GetChainParser().SuperblockCycle()
+	if cycle == 0 {
+		return
+	}
+	height, _, err := w.db.GetBestBlock()
+	if err != nil {
+		glog.Error("GetBestBlock error ", err)
+		return
+	}
+	if initialSync {
+		w.backfillSuperblockBudgets(height, cycle)
+	}
+	w.storeSuperblockBudget(height, cycle)
+}
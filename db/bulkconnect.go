@@ -2,6 +2,8 @@ package db
 
 import (
 	"blockbook/bchain"
+	"math/big"
+	"sort"
 	"time"
 
 	"github.com/golang/glog"
@@ -19,6 +21,11 @@ type bulkAddresses struct {
 	addresses addressesMap
 }
 
+type bulkSpentTxs struct {
+	height uint32
+	btxIDs [][]byte
+}
+
 // BulkConnect is used to connect blocks in bulk, faster but if interrupted inconsistent way
 type BulkConnect struct {
 	d                  *RocksDB
@@ -27,6 +34,19 @@ type BulkConnect struct {
 	bulkAddressesCount int
 	txAddressesMap     map[string]*TxAddresses
 	balances           map[string]*AddrBalance
+	stakingRewards     map[string]*StakingRewards
+	masternodePayments map[string]*big.Int
+	zerocoinPool       *ZerocoinPool
+	zerocoinPools      []*ZerocoinPool
+	supply             *Supply
+	supplies           []*Supply
+	blockStaker        *BlockStaker
+	blockStakers       []*BlockStaker
+	spentTxs           []bulkSpentTxs
+	spentOutpoints     map[string]*spendingInput
+	opReturns          map[string][]byte
+	blockFilters       []*blockFilter
+	lastBlockFilter    *blockFilter
 	addressContracts   map[string]*AddrContracts
 	height             uint32
 }
@@ -44,11 +64,15 @@ const (
 // InitBulkConnect initializes bulk connect and switches DB to inconsistent state
 func (d *RocksDB) InitBulkConnect() (*BulkConnect, error) {
 	b := &BulkConnect{
-		d:                d,
-		chainType:        d.chainParser.GetChainType(),
-		txAddressesMap:   make(map[string]*TxAddresses),
-		balances:         make(map[string]*AddrBalance),
-		addressContracts: make(map[string]*AddrContracts),
+		d:                  d,
+		chainType:          d.chainParser.GetChainType(),
+		txAddressesMap:     make(map[string]*TxAddresses),
+		balances:           make(map[string]*AddrBalance),
+		stakingRewards:     make(map[string]*StakingRewards),
+		masternodePayments: make(map[string]*big.Int),
+		spentOutpoints:     make(map[string]*spendingInput),
+		opReturns:          make(map[string][]byte),
+		addressContracts:   make(map[string]*AddrContracts),
 	}
 	if err := d.SetInconsistentState(true); err != nil {
 		return nil, err
@@ -164,16 +188,225 @@ func (b *BulkConnect) storeBulkAddresses(wb *gorocksdb.WriteBatch) error {
 			return err
 		}
 	}
+	// staking rewards, masternode payments, zerocoin pool, supply and block stakers are stored together with the addresses, there are only few of them
+	if err := b.d.storeStakingRewards(wb, b.stakingRewards); err != nil {
+		return err
+	}
+	b.stakingRewards = make(map[string]*StakingRewards)
+	if err := b.d.storeMasternodePayments(wb, b.masternodePayments); err != nil {
+		return err
+	}
+	b.masternodePayments = make(map[string]*big.Int)
+	for _, zp := range b.zerocoinPools {
+		b.d.storeZerocoinPool(wb, zp)
+	}
+	b.zerocoinPools = b.zerocoinPools[:0]
+	for _, s := range b.supplies {
+		b.d.storeSupply(wb, s)
+	}
+	b.supplies = b.supplies[:0]
+	for _, bs := range b.blockStakers {
+		b.d.storeBlockStaker(wb, bs)
+	}
+	b.blockStakers = b.blockStakers[:0]
+	// spent outpoints and OP_RETURN outputs are stored together with the addresses, their number is similar to the number of the addresses
+	b.d.storeSpentOutpoints(wb, b.spentOutpoints)
+	b.spentOutpoints = make(map[string]*spendingInput)
+	b.d.storeOpReturns(wb, b.opReturns)
+	b.opReturns = make(map[string][]byte)
+	for _, bf := range b.blockFilters {
+		b.d.storeBlockFilter(wb, bf)
+	}
+	b.blockFilters = b.blockFilters[:0]
 	b.bulkAddressesCount = 0
 	b.bulkAddresses = b.bulkAddresses[:0]
 	return nil
 }
 
+// processBlockFilter builds the filter of the block, the header of the last filter is kept in memory, the filters are stored together with the addresses
+func (b *BulkConnect) processBlockFilter(block *bchain.Block) error {
+	var prevHeader []byte
+	if b.lastBlockFilter != nil && b.lastBlockFilter.height+1 == block.Height {
+		prevHeader = b.lastBlockFilter.header
+	} else if block.Height > 0 {
+		var err error
+		if prevHeader, err = b.d.getBlockFilterHeader(block.Height - 1); err != nil {
+			return err
+		}
+	}
+	bf, err := b.d.processBlockFilter(block, b.txAddressesMap, prevHeader)
+	if err != nil {
+		return err
+	}
+	b.blockFilters = append(b.blockFilters, bf)
+	b.lastBlockFilter = bf
+	return nil
+}
+
+// processZerocoinPool keeps the state of the zerocoin pool in memory, the snapshots are stored together with the addresses
+func (b *BulkConnect) processZerocoinPool(block *bchain.Block) error {
+	delta := b.d.zerocoinPoolDelta(block)
+	if delta == nil {
+		return nil
+	}
+	if b.zerocoinPool == nil {
+		zp, err := b.d.GetZerocoinPool(block.Height - 1)
+		if err != nil {
+			return err
+		}
+		if zp == nil {
+			zp = &ZerocoinPool{}
+		}
+		b.zerocoinPool = zp
+	}
+	b.zerocoinPool.add(delta)
+	b.zerocoinPools = append(b.zerocoinPools, b.zerocoinPool.copy())
+	return nil
+}
+
+// processSupply keeps the supply in memory, the snapshots are stored together with the addresses
+func (b *BulkConnect) processSupply(block *bchain.Block) error {
+	if b.supply == nil {
+		var s *Supply
+		if block.Height > 0 {
+			var err error
+			if s, err = b.d.GetSupply(block.Height - 1); err != nil {
+				return err
+			}
+		}
+		if s == nil {
+			s = &Supply{}
+		}
+		b.supply = s
+	}
+	if err := b.d.processSupply(block, b.txAddressesMap, b.supply); err != nil {
+		return err
+	}
+	s := &Supply{Height: b.supply.Height}
+	s.IssuedSat.Set(&b.supply.IssuedSat)
+	s.BurnedSat.Set(&b.supply.BurnedSat)
+	b.supplies = append(b.supplies, s)
+	return nil
+}
+
+// processBlockStaker keeps the producers of the blocks in memory, they are stored together with the addresses
+func (b *BulkConnect) processBlockStaker(block *bchain.Block) error {
+	if !b.d.chainParser.SupportsCoinstake() {
+		return nil
+	}
+	var posBlocks uint32
+	if b.blockStaker != nil {
+		posBlocks = b.blockStaker.PosBlocks
+	} else if block.Height > 0 {
+		prev, err := b.d.GetBlockStaker(block.Height - 1)
+		if err != nil {
+			return err
+		}
+		if prev != nil {
+			posBlocks = prev.PosBlocks
+		}
+	}
+	bs, err := b.d.processBlockStaker(block, b.txAddressesMap, posBlocks)
+	if err != nil {
+		return err
+	}
+	b.blockStaker = bs
+	b.blockStakers = append(b.blockStakers, bs)
+	return nil
+}
+
+// processSpentTxs keeps the transactions fully spent in the block in memory, they are pruned or stored when the bulk connect is closed
+func (b *BulkConnect) processSpentTxs(block *bchain.Block) {
+	if b.d.pruneDepth == 0 {
+		return
+	}
+	if btxIDs := b.d.fullySpentTxs(block, b.txAddressesMap); len(btxIDs) > 0 {
+		b.spentTxs = append(b.spentTxs, bulkSpentTxs{height: block.Height, btxIDs: btxIDs})
+	}
+}
+
+// pruneSpentTxs removes the transactions fully spent in the blocks deeper than the pruning depth
+// there are no disconnects in the bulk mode, the transactions stay fully spent and can be removed without checking
+func (b *BulkConnect) pruneSpentTxs(height uint32) error {
+	if b.d.pruneDepth == 0 || height <= b.d.pruneDepth {
+		return nil
+	}
+	prunedHeight := height - b.d.pruneDepth
+	n := sort.Search(len(b.spentTxs), func(i int) bool { return b.spentTxs[i].height > prunedHeight })
+	if n < bulkPruneRows {
+		return nil
+	}
+	start := time.Now()
+	wb := gorocksdb.NewWriteBatch()
+	defer wb.Destroy()
+	count := 0
+	for _, st := range b.spentTxs[:n] {
+		for _, btxID := range st.btxIDs {
+			// the transaction may not be stored yet
+			ta, found := b.txAddressesMap[string(btxID)]
+			if !found {
+				var err error
+				if ta, err = b.d.getTxAddresses(btxID); err != nil {
+					return err
+				}
+			}
+			outputs := 0
+			if ta != nil {
+				outputs = len(ta.Outputs)
+			}
+			delete(b.txAddressesMap, string(btxID))
+			for i := 0; i < outputs; i++ {
+				delete(b.spentOutpoints, string(packSpentOutpointKey(btxID, int32(i))))
+			}
+			b.d.pruneTx(wb, btxID, outputs)
+			count++
+		}
+	}
+	// the transactions spent before the bulk connect
+	if err := b.d.pruneSpentTxs(wb, prunedHeight, nil); err != nil {
+		return err
+	}
+	wb.PutCF(b.d.cfh[cfDefault], []byte(prunedHeightKey), packUint(prunedHeight))
+	if err := b.d.db.Write(b.d.wo, wb); err != nil {
+		return err
+	}
+	b.spentTxs = append(b.spentTxs[:0], b.spentTxs[n:]...)
+	b.d.is.SetPrunedHeight(prunedHeight)
+	glog.Info("rocksdb: height ", height, ", pruned ", count, " spent transactions, done in ", time.Since(start))
+	return nil
+}
+
 func (b *BulkConnect) connectBlockBitcoinType(block *bchain.Block, storeBlockTxs bool) error {
+	// prune before the txAddresses are modified, they are stored in parallel later
+	if err := b.pruneSpentTxs(block.Height); err != nil {
+		return err
+	}
 	addresses := make(addressesMap)
-	if err := b.d.processAddressesBitcoinType(block, addresses, b.txAddressesMap, b.balances); err != nil {
+	if err := b.d.processAddressesBitcoinType(block, addresses, b.txAddressesMap, b.balances, b.spentOutpoints); err != nil {
+		return err
+	}
+	if err := b.d.processOpReturns(block, b.txAddressesMap, b.opReturns); err != nil {
+		return err
+	}
+	if err := b.processBlockFilter(block); err != nil {
+		return err
+	}
+	if err := b.d.processStakingRewards(block, b.txAddressesMap, b.stakingRewards); err != nil {
+		return err
+	}
+	if err := b.d.processMasternodePayments(block, b.masternodePayments); err != nil {
 		return err
 	}
+	if err := b.processZerocoinPool(block); err != nil {
+		return err
+	}
+	if err := b.processSupply(block); err != nil {
+		return err
+	}
+	if err := b.processBlockStaker(block); err != nil {
+		return err
+	}
+	b.processSpentTxs(block)
 	var storeAddressesChan, storeBalancesChan chan error
 	var sa bool
 	if len(b.txAddressesMap) > maxBulkTxAddresses || len(b.balances) > maxBulkBalances {
@@ -362,6 +595,11 @@ func (b *BulkConnect) Close() error {
 	if err := b.storeBulkAddresses(wb); err != nil {
 		return err
 	}
+	// the remaining spent transactions are pruned by the following connected blocks
+	for _, st := range b.spentTxs {
+		b.d.storeSpentTxs(wb, st.height, st.btxIDs)
+	}
+	b.spentTxs = nil
 	if err := b.d.db.Write(b.d.wo, wb); err != nil {
 		return err
 	}
@@ -381,12 +619,6 @@ func (b *BulkConnect) Close() error {
 			return err
 		}
 	}
-	var err error
-	b.d.is.BlockTimes, err = b.d.loadBlockTimes()
-	if err != nil {
-		return err
-	}
-
 	if err := b.d.SetInconsistentState(false); err != nil {
 		return err
 	}
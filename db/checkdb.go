@@ -0,0 +1,441 @@
+package db
+
+import (
+	"blockbook/bchain"
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"math/big"
+	"os"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/golang/glog"
+	"github.com/juju/errors"
+	"github.com/tecbot/gorocksdb"
+)
+
+// CheckDBResult contains the summary of the check of the database
+type CheckDBResult struct {
+	Addresses    int
+	Balances     int
+	TxAddresses  int
+	BlockTxs     int
+	Errors       int
+	Repaired     int
+	Unverifiable int
+}
+
+// dbChecker holds the state of the check of the database
+type dbChecker struct {
+	d            *RocksDB
+	lower        uint32
+	higher       uint32
+	repair       bool
+	stop         chan os.Signal
+	prunedHeight uint32
+	ro           *gorocksdb.ReadOptions
+	res          CheckDBResult
+}
+
+// CheckDB checks the consistency of the index of Bitcoin type coins, the check is limited to the blocks from lower to higher
+// the columns addresses, addressBalance, txAddresses and blockTxs are walked and cross-checked, the discrepancies are logged
+// if repair is set, the balances of the addresses with discrepancies are recomputed from the address index and txAddresses,
+// the rest of the database is not modified
+func (d *RocksDB) CheckDB(lower, higher uint32, repair bool, stop chan os.Signal) (*CheckDBResult, error) {
+	if d.chainParser.GetChainType() != bchain.ChainBitcoinType {
+		return nil, errors.New("CheckDB is supported only for Bitcoin type coins")
+	}
+	start := time.Now()
+	glog.Info("checkdb: start, blocks ", lower, "-", higher, ", repair ", repair)
+	c := dbChecker{
+		d:      d,
+		lower:  lower,
+		higher: higher,
+		repair: repair,
+		stop:   stop,
+		// do not use cache
+		ro: gorocksdb.NewDefaultReadOptions(),
+	}
+	c.ro.SetFillCache(false)
+	defer c.ro.Destroy()
+	if d.is != nil {
+		c.prunedHeight = d.is.GetPrunedHeight()
+	}
+	steps := []struct {
+		name string
+		fn   func() error
+	}{
+		{"addresses", c.checkAddresses},
+		{"addressBalance", c.checkBalances},
+		{"txAddresses", c.checkTxAddresses},
+		{"blockTxs", c.checkBlockTxs},
+	}
+	for _, s := range steps {
+		t := time.Now()
+		errs := c.res.Errors
+		if err := s.fn(); err != nil {
+			return &c.res, err
+		}
+		glog.Info("checkdb: column ", s.name, " checked, ", c.res.Errors-errs, " discrepancies, done in ", time.Since(t))
+	}
+	glog.Infof("checkdb: finished in %v, %+v", time.Since(start), c.res)
+	return &c.res, nil
+}
+
+func (c *dbChecker) fullRange() bool {
+	return c.lower == 0 && c.higher == math.MaxUint32
+}
+
+func (c *dbChecker) inRange(height uint32) bool {
+	return height >= c.lower && height <= c.higher
+}
+
+func (c *dbChecker) stopped() bool {
+	select {
+	case <-c.stop:
+		return true
+	default:
+		return false
+	}
+}
+
+func (c *dbChecker) addrDescString(addrDesc bchain.AddressDescriptor) string {
+	if a, _, err := c.d.chainParser.GetAddressesFromAddrDesc(addrDesc); err == nil && len(a) > 0 {
+		return a[0]
+	}
+	return hex.EncodeToString(addrDesc)
+}
+
+func (c *dbChecker) discrepancy(format string, args ...interface{}) {
+	c.res.Errors++
+	glog.Warning("checkdb: ", fmt.Sprintf(format, args...))
+}
+
+// checkAddresses walks the address index and checks the balance of each address with transactions in the checked blocks
+func (c *dbChecker) checkAddresses() error {
+	it := c.d.db.NewIteratorCF(c.ro, c.d.cfh[cfAddresses])
+	defer it.Close()
+	var addrDesc []byte
+	var check bool
+	for it.SeekToFirst(); it.Valid(); it.Next() {
+		if c.stopped() {
+			return ErrOperationInterrupted
+		}
+		ad, height, err := unpackAddressKey(it.Key().Data())
+		if err != nil {
+			return err
+		}
+		if !bytes.Equal(ad, addrDesc) {
+			if check {
+				if err = c.checkAddress(addrDesc); err != nil {
+					return err
+				}
+			}
+			addrDesc = append(addrDesc[:0], ad...)
+			check = false
+		}
+		check = check || c.inRange(height)
+	}
+	if check {
+		return c.checkAddress(addrDesc)
+	}
+	return nil
+}
+
+// computeAddrBalance computes the balance of the address from the address index and txAddresses
+// the returned bool is false if the values cannot be computed because some transactions were pruned
+func (c *dbChecker) computeAddrBalance(addrDesc bchain.AddressDescriptor) (*AddrBalance, bool, error) {
+	ab := &AddrBalance{}
+	verifiable := true
+	err := c.d.GetAddrDescTransactions(addrDesc, 0, math.MaxUint32, func(txid string, height uint32, indexes []int32) error {
+		ab.setActivity(height)
+		ab.Txs++
+		btxID, err := c.d.chainParser.PackTxid(txid)
+		if err != nil {
+			return err
+		}
+		ta, err := c.d.getTxAddresses(btxID)
+		if err != nil {
+			return err
+		}
+		if ta == nil {
+			if height <= c.prunedHeight {
+				verifiable = false
+			} else {
+				c.discrepancy("address %v: tx %v at height %v not found in txAddresses", c.addrDescString(addrDesc), txid, height)
+			}
+			return nil
+		}
+		if ta.Height != height {
+			c.discrepancy("address %v: tx %v is indexed at height %v, txAddresses height %v", c.addrDescString(addrDesc), txid, height, ta.Height)
+		}
+		for _, index := range indexes {
+			if index >= 0 {
+				if int(index) >= len(ta.Outputs) {
+					c.discrepancy("address %v: tx %v output %v out of bounds of txAddresses", c.addrDescString(addrDesc), txid, index)
+					continue
+				}
+				o := &ta.Outputs[index]
+				ab.BalanceSat.Add(&ab.BalanceSat, &o.ValueSat)
+				if !o.Spent {
+					ab.Utxos = append(ab.Utxos, Utxo{
+						BtxID:    btxID,
+						Vout:     index,
+						Height:   ta.Height,
+						ValueSat: o.ValueSat,
+					})
+				}
+			} else {
+				index = ^index
+				if int(index) >= len(ta.Inputs) {
+					c.discrepancy("address %v: tx %v input %v out of bounds of txAddresses", c.addrDescString(addrDesc), txid, index)
+					continue
+				}
+				i := &ta.Inputs[index]
+				ab.BalanceSat.Sub(&ab.BalanceSat, &i.ValueSat)
+				ab.SentSat.Add(&ab.SentSat, &i.ValueSat)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, false, err
+	}
+	// utxos are stored ordered by height
+	sort.SliceStable(ab.Utxos, func(i, j int) bool {
+		return ab.Utxos[i].Height < ab.Utxos[j].Height
+	})
+	return ab, verifiable, nil
+}
+
+// checkAddress compares the stored balance of the address with the balance computed from the address index
+func (c *dbChecker) checkAddress(addrDesc bchain.AddressDescriptor) error {
+	c.res.Addresses++
+	errs := c.res.Errors
+	computed, verifiable, err := c.computeAddrBalance(addrDesc)
+	if err != nil {
+		return err
+	}
+	ab, err := c.d.getAddrDescBalanceForUpdate(addrDesc, AddressBalanceDetailUTXO)
+	if err != nil {
+		return err
+	}
+	a := c.addrDescString(addrDesc)
+	if ab == nil {
+		c.discrepancy("address %v: balance not found", a)
+		ab = &AddrBalance{}
+	}
+	if ab.Txs != computed.Txs {
+		c.discrepancy("address %v: txs %v, address index %v", a, ab.Txs, computed.Txs)
+	}
+	if ab.FirstHeight != computed.FirstHeight || ab.LastHeight != computed.LastHeight {
+		c.discrepancy("address %v: activity heights %v-%v, address index %v-%v", a, ab.FirstHeight, ab.LastHeight, computed.FirstHeight, computed.LastHeight)
+	}
+	if !verifiable {
+		c.res.Unverifiable++
+	} else {
+		if ab.SentSat.Cmp(&computed.SentSat) != 0 {
+			c.discrepancy("address %v: sent %v, address index %v", a, ab.SentSat.String(), computed.SentSat.String())
+		}
+		if ab.BalanceSat.Cmp(&computed.BalanceSat) != 0 {
+			c.discrepancy("address %v: balance %v, address index %v", a, ab.BalanceSat.String(), computed.BalanceSat.String())
+		}
+		c.compareUtxos(a, ab.Utxos, computed.Utxos)
+	}
+	if c.res.Errors > errs && c.repair && verifiable {
+		// the rich list entry of the stored balance is replaced by the computed balance
+		computed.storedBalanceSat = ab.storedBalanceSat
+		return c.repairBalance(addrDesc, computed)
+	}
+	return nil
+}
+
+func utxoKey(u *Utxo) string {
+	return string(u.BtxID) + strconv.Itoa(int(u.Vout))
+}
+
+func (c *dbChecker) compareUtxos(a string, stored, computed []Utxo) {
+	m := make(map[string]*Utxo, len(computed))
+	for i := range computed {
+		m[utxoKey(&computed[i])] = &computed[i]
+	}
+	var sum big.Int
+	for i := range stored {
+		u := &stored[i]
+		sum.Add(&sum, &u.ValueSat)
+		txid, _ := c.d.chainParser.UnpackTxid(u.BtxID)
+		k := utxoKey(u)
+		cu, found := m[k]
+		if !found {
+			c.discrepancy("address %v: utxo %v:%v is spent or not indexed", a, txid, u.Vout)
+			continue
+		}
+		delete(m, k)
+		if cu.Height != u.Height || cu.ValueSat.Cmp(&u.ValueSat) != 0 {
+			c.discrepancy("address %v: utxo %v:%v height %v value %v, txAddresses height %v value %v", a, txid, u.Vout, u.Height, u.ValueSat.String(), cu.Height, cu.ValueSat.String())
+		}
+	}
+	for _, cu := range m {
+		txid, _ := c.d.chainParser.UnpackTxid(cu.BtxID)
+		c.discrepancy("address %v: utxo %v:%v missing", a, txid, cu.Vout)
+	}
+}
+
+// repairBalance stores the balance computed from the address index, empty balance is removed
+func (c *dbChecker) repairBalance(addrDesc bchain.AddressDescriptor, ab *AddrBalance) error {
+	wb := gorocksdb.NewWriteBatch()
+	defer wb.Destroy()
+	if err := c.d.storeBalances(wb, map[string]*AddrBalance{string(addrDesc): ab}); err != nil {
+		return err
+	}
+	if err := c.d.db.Write(c.d.wo, wb); err != nil {
+		return err
+	}
+	c.res.Repaired++
+	glog.Info("checkdb: address ", c.addrDescString(addrDesc), ": balance repaired")
+	return nil
+}
+
+// checkBalances finds the balances of the addresses without any transaction in the address index
+// the check is done only if the whole database is checked
+func (c *dbChecker) checkBalances() error {
+	if !c.fullRange() {
+		return nil
+	}
+	it := c.d.db.NewIteratorCF(c.ro, c.d.cfh[cfAddressBalance])
+	defer it.Close()
+	ait := c.d.db.NewIteratorCF(c.ro, c.d.cfh[cfAddresses])
+	defer ait.Close()
+	for it.SeekToFirst(); it.Valid(); it.Next() {
+		if c.stopped() {
+			return ErrOperationInterrupted
+		}
+		c.res.Balances++
+		addrDesc := bchain.AddressDescriptor(it.Key().Data())
+		found := false
+		if ait.Seek(packAddressKey(addrDesc, math.MaxUint32)); ait.Valid() {
+			ad, _, err := unpackAddressKey(ait.Key().Data())
+			found = err == nil && bytes.Equal(ad, addrDesc)
+		}
+		if !found {
+			c.discrepancy("address %v: balance without transactions in the address index", c.addrDescString(addrDesc))
+			if c.repair {
+				if err := c.repairBalance(append(bchain.AddressDescriptor(nil), addrDesc...), &AddrBalance{}); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// checkTxAddresses checks that the outputs of the transactions from the checked blocks are in the address index
+func (c *dbChecker) checkTxAddresses() error {
+	bestHeight, _, err := c.d.GetBestBlock()
+	if err != nil {
+		return err
+	}
+	it := c.d.db.NewIteratorCF(c.ro, c.d.cfh[cfTxAddresses])
+	defer it.Close()
+	for it.SeekToFirst(); it.Valid(); it.Next() {
+		if c.stopped() {
+			return ErrOperationInterrupted
+		}
+		ta, err := unpackTxAddresses(it.Value().Data())
+		if err != nil {
+			return err
+		}
+		if !c.inRange(ta.Height) {
+			continue
+		}
+		c.res.TxAddresses++
+		txid, err := c.d.chainParser.UnpackTxid(it.Key().Data())
+		if err != nil {
+			return err
+		}
+		if ta.Height > bestHeight {
+			c.discrepancy("tx %v: height %v is above the best block %v", txid, ta.Height, bestHeight)
+			continue
+		}
+		for i := range ta.Outputs {
+			o := &ta.Outputs[i]
+			if !c.d.chainParser.IsAddrDescIndexable(o.AddrDesc) {
+				continue
+			}
+			for _, addrDesc := range c.d.balanceAddrDescs(o.AddrDesc) {
+				if addrDesc == nil {
+					continue
+				}
+				found, err := c.isInAddressIndex(addrDesc, txid, ta.Height, int32(i))
+				if err != nil {
+					return err
+				}
+				if !found {
+					c.discrepancy("address %v: tx %v output %v at height %v not found in the address index", c.addrDescString(addrDesc), txid, i, ta.Height)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func (c *dbChecker) isInAddressIndex(addrDesc bchain.AddressDescriptor, txid string, height uint32, index int32) (bool, error) {
+	found := false
+	err := c.d.GetAddrDescTransactions(addrDesc, height, height, func(t string, h uint32, indexes []int32) error {
+		if t == txid {
+			for _, i := range indexes {
+				if i == index {
+					found = true
+					return &StopIteration{}
+				}
+			}
+		}
+		return nil
+	})
+	return found, err
+}
+
+// checkBlockTxs checks that the transactions stored for the rollback of the checked blocks match txAddresses
+func (c *dbChecker) checkBlockTxs() error {
+	it := c.d.db.NewIteratorCF(c.ro, c.d.cfh[cfBlockTxs])
+	defer it.Close()
+	for it.SeekToFirst(); it.Valid(); it.Next() {
+		if c.stopped() {
+			return ErrOperationInterrupted
+		}
+		height := unpackUint(it.Key().Data())
+		if !c.inRange(height) {
+			continue
+		}
+		c.res.BlockTxs++
+		bt, err := c.d.getBlockTxs(height)
+		if err != nil {
+			c.discrepancy("block %v: %v", height, err)
+			continue
+		}
+		for i := range bt {
+			txid, err := c.d.chainParser.UnpackTxid(bt[i].btxID)
+			if err != nil {
+				return err
+			}
+			ta, err := c.d.getTxAddresses(bt[i].btxID)
+			if err != nil {
+				return err
+			}
+			if ta == nil {
+				c.discrepancy("block %v: tx %v not found in txAddresses", height, txid)
+				continue
+			}
+			if ta.Height != height {
+				c.discrepancy("block %v: tx %v has txAddresses height %v", height, txid, ta.Height)
+			}
+			if len(ta.Inputs) != len(bt[i].inputs) {
+				c.discrepancy("block %v: tx %v has %v inputs, txAddresses %v", height, txid, len(bt[i].inputs), len(ta.Inputs))
+			}
+		}
+	}
+	return nil
+}
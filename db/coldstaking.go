@@ -0,0 +1,174 @@
+package db
+
+import (
+	"blockbook/bchain"
+	"bytes"
+	"math/big"
+	"sort"
+
+	"github.com/juju/errors"
+	"github.com/tecbot/gorocksdb"
+)
+
+// cold staking
+// the outputs to the pay to cold staking (P2CS) scripts are indexed in the columns addresses and addressBalance
+// under the address descriptors of the owner and of the staker of the script (see balanceAddrDescs), the mempool uses the same attribution
+// the databases before version 6 indexed them under the P2CS script itself, the migration moves these rows to the owner and the staker
+
+// unpackTxIndexes unpacks the value of the column addresses to the transactions in the order expected by packTxIndexes
+func (d *RocksDB) unpackTxIndexes(val []byte) ([]txIndexes, error) {
+	pl := d.chainParser.PackedTxidLen()
+	var txi []txIndexes
+	for len(val) > pl {
+		t := txIndexes{btxID: append([]byte(nil), val[:pl]...)}
+		val = val[pl:]
+		for {
+			index, l := unpackVarint32(val)
+			t.indexes = append(t.indexes, index>>1)
+			val = val[l:]
+			if index&1 == 1 {
+				break
+			} else if len(val) == 0 {
+				return nil, errors.New("Inconsistent data in addresses")
+			}
+		}
+		txi = append(txi, t)
+	}
+	if len(val) != 0 {
+		return nil, errors.New("Inconsistent data in addresses")
+	}
+	// packTxIndexes stores the transactions in the reverse order
+	for i, j := 0, len(txi)-1; i < j; i, j = i+1, j-1 {
+		txi[i], txi[j] = txi[j], txi[i]
+	}
+	return txi, nil
+}
+
+// mergeTxIndexes adds the transactions of src to dst, returns the merged transactions and the number of the transactions added to dst
+// the position of the added transactions in the block is not known, they are put after the transactions of dst
+func mergeTxIndexes(dst, src []txIndexes) ([]txIndexes, uint32) {
+	var added uint32
+	for _, s := range src {
+		i := 0
+		for ; i < len(dst); i++ {
+			if bytes.Equal(dst[i].btxID, s.btxID) {
+				break
+			}
+		}
+		if i == len(dst) {
+			dst = append(dst, txIndexes{btxID: s.btxID, indexes: append([]int32(nil), s.indexes...)})
+			added++
+			continue
+		}
+	next:
+		for _, index := range s.indexes {
+			for _, di := range dst[i].indexes {
+				if di == index {
+					continue next
+				}
+			}
+			dst[i].indexes = append(dst[i].indexes, index)
+		}
+	}
+	return dst, added
+}
+
+// moveColdStakingAddresses moves the rows of the P2CS address descriptor in the column addresses to the owner and to the staker,
+// returns the numbers of the transactions which were not yet indexed under the owner and under the staker
+func (d *RocksDB) moveColdStakingAddresses(wb *gorocksdb.WriteBatch, addrDesc bchain.AddressDescriptor, targets [2]bchain.AddressDescriptor) ([2]uint32, error) {
+	var added [2]uint32
+	it := d.db.NewIteratorCF(d.ro, d.cfh[cfAddresses])
+	defer it.Close()
+	for it.Seek(addrDesc); it.Valid(); it.Next() {
+		key := it.Key().Data()
+		if !bytes.HasPrefix(key, addrDesc) {
+			break
+		}
+		// skip the keys of longer address descriptors with the same prefix
+		if len(key) != len(addrDesc)+packedHeightBytes {
+			continue
+		}
+		_, height, err := unpackAddressKey(key)
+		if err != nil {
+			return added, err
+		}
+		txi, err := d.unpackTxIndexes(it.Value().Data())
+		if err != nil {
+			return added, err
+		}
+		for i, target := range targets {
+			targetKey := packAddressKey(target, height)
+			val, err := d.db.GetCF(d.ro, d.cfh[cfAddresses], targetKey)
+			if err != nil {
+				return added, err
+			}
+			targetTxi, err := d.unpackTxIndexes(val.Data())
+			val.Free()
+			if err != nil {
+				return added, err
+			}
+			var n uint32
+			targetTxi, n = mergeTxIndexes(targetTxi, txi)
+			added[i] += n
+			wb.PutCF(d.cfh[cfAddresses], targetKey, d.packTxIndexes(targetTxi))
+		}
+		wb.DeleteCF(d.cfh[cfAddresses], append([]byte(nil), key...))
+	}
+	return added, nil
+}
+
+// migrateColdStakingBalances moves the balance of the P2CS address descriptor stored by the databases before version 6
+// together with its rows in the column addresses to the owner and to the staker
+// the conversion is written immediately in its own write batch, because the following rows can update the same owner or staker,
+// it is not repeated after the interruption of the migration because the converted rows are deleted in the same write
+func migrateColdStakingBalances(d *RocksDB, wb *gorocksdb.WriteBatch, key, val []byte) error {
+	// the column addressBalance is used by the Bitcoin type coins only, it is shared with the column addressContracts
+	if d.chainParser.GetChainType() != bchain.ChainBitcoinType || len(val) < 3 {
+		return nil
+	}
+	owner, staker := d.chainParser.GetColdStakingAddrDescs(key)
+	if owner == nil {
+		return nil
+	}
+	ab, err := unpackAddrBalance(val, d.chainParser.PackedTxidLen(), AddressBalanceDetailUTXO)
+	if err != nil {
+		return err
+	}
+	cwb := gorocksdb.NewWriteBatch()
+	defer cwb.Destroy()
+	targets := [2]bchain.AddressDescriptor{owner, staker}
+	added, err := d.moveColdStakingAddresses(cwb, key, targets)
+	if err != nil {
+		return err
+	}
+	// the balance of the P2CS address descriptor without transactions is deleted together with its rich list entry
+	raw := &AddrBalance{storedBalanceSat: new(big.Int).Set(&ab.BalanceSat)}
+	balances := map[string]*AddrBalance{string(key): raw}
+	for i, target := range targets {
+		tb, err := d.getAddrDescBalanceForUpdate(target, AddressBalanceDetailUTXO)
+		if err != nil {
+			return err
+		}
+		if tb == nil {
+			tb = &AddrBalance{}
+		}
+		if tb.Txs == 0 || ab.FirstHeight < tb.FirstHeight {
+			tb.FirstHeight = ab.FirstHeight
+		}
+		if ab.LastHeight > tb.LastHeight {
+			tb.LastHeight = ab.LastHeight
+		}
+		tb.Txs += added[i]
+		tb.SentSat.Add(&tb.SentSat, &ab.SentSat)
+		tb.BalanceSat.Add(&tb.BalanceSat, &ab.BalanceSat)
+		tb.Utxos = append(tb.Utxos, ab.Utxos...)
+		sort.SliceStable(tb.Utxos, func(i, j int) bool {
+			return tb.Utxos[i].Height < tb.Utxos[j].Height
+		})
+		balances[string(target)] = tb
+	}
+	if err := d.storeBalances(cwb, balances); err != nil {
+		return err
+	}
+	return d.db.Write(d.wo, cwb)
+}
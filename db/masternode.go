@@ -0,0 +1,95 @@
+package db
+
+import (
+	"blockbook/bchain"
+	"bytes"
+	"math/big"
+
+	"github.com/golang/glog"
+	"github.com/tecbot/gorocksdb"
+)
+
+// masternode payments are stored in the column masternodePayments
+// key is the address descriptor of the payee followed by the height of the block (in binary complement, newest first)
+// value is the paid amount
+// the payment is detected by the parser (GetMasternodePaymentVout) from the layout of the outputs of the coinstake or coinbase transaction,
+// the block does not contain any explicit marking of the payment, therefore:
+// - a masternode paid to the same script as the first staker output is not detected
+// - a stake paid to more scripts than the first staker output has the last staker output reported as the masternode payment
+// - budget payments in the superblocks are reported as masternode payments to the budget proposal payees
+
+// GetMasternodePaymentsCallback is called by GetAddrDescMasternodePayments for each payment
+type GetMasternodePaymentsCallback func(height uint32, valueSat *big.Int) error
+
+// processMasternodePayments finds masternode payments in the block and adds them to payments, mapped by the packed key
+func (d *RocksDB) processMasternodePayments(block *bchain.Block, payments map[string]*big.Int) error {
+	for i := range block.Txs {
+		tx := &block.Txs[i]
+		n := d.chainParser.GetMasternodePaymentVout(tx)
+		if n < 0 || n >= len(tx.Vout) {
+			continue
+		}
+		addrDesc, err := d.chainParser.GetAddrDescFromVout(&tx.Vout[n])
+		if err != nil || len(addrDesc) == 0 || len(addrDesc) > maxAddrDescLen {
+			glog.Warningf("rocksdb: height %d, tx %v, masternode payment vout %v without address, error %v", block.Height, tx.Txid, n, err)
+			continue
+		}
+		key := string(packAddressKey(addrDesc, block.Height))
+		v, e := payments[key]
+		if !e {
+			v = &big.Int{}
+			payments[key] = v
+		}
+		v.Add(v, &tx.Vout[n].ValueSat)
+	}
+	return nil
+}
+
+func (d *RocksDB) storeMasternodePayments(wb *gorocksdb.WriteBatch, payments map[string]*big.Int) error {
+	buf := make([]byte, maxPackedBigintBytes)
+	for key, v := range payments {
+		l := packBigint(v, buf)
+		wb.PutCF(d.cfh[cfMasternodePayments], []byte(key), buf[:l])
+	}
+	return nil
+}
+
+// disconnectMasternodePayments removes possible masternode payments to the outputs of the transaction in block of given height
+// the payments can be only in the coinbase or coinstake transaction, which are the first transactions of the block
+func (d *RocksDB) disconnectMasternodePayments(wb *gorocksdb.WriteBatch, height uint32, txa *TxAddresses) {
+	for i := range txa.Outputs {
+		if len(txa.Outputs[i].AddrDesc) > 0 {
+			wb.DeleteCF(d.cfh[cfMasternodePayments], packAddressKey(txa.Outputs[i].AddrDesc, height))
+		}
+	}
+}
+
+// GetAddrDescMasternodePayments calls fn for all masternode payments to addrDesc between lower and higher height, newest first
+func (d *RocksDB) GetAddrDescMasternodePayments(addrDesc bchain.AddressDescriptor, lower uint32, higher uint32, fn GetMasternodePaymentsCallback) error {
+	startKey := packAddressKey(addrDesc, higher)
+	stopKey := packAddressKey(addrDesc, lower)
+	it := d.db.NewIteratorCF(d.ro, d.cfh[cfMasternodePayments])
+	defer it.Close()
+	for it.Seek(startKey); it.Valid(); it.Next() {
+		key := it.Key().Data()
+		if bytes.Compare(key, stopKey) > 0 {
+			break
+		}
+		// skip payments of other address descriptors starting with addrDesc
+		if len(key) != len(addrDesc)+packedHeightBytes {
+			continue
+		}
+		_, height, err := unpackAddressKey(key)
+		if err != nil {
+			return err
+		}
+		v, _ := unpackBigint(it.Value().Data())
+		if err := fn(height, &v); err != nil {
+			if _, ok := err.(*StopIteration); ok {
+				return nil
+			}
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,431 @@
+package db
+
+import (
+	"blockbook/bchain"
+	"blockbook/common"
+	"bytes"
+	"math/big"
+	"os"
+
+	"github.com/golang/glog"
+	"github.com/juju/errors"
+	"github.com/tecbot/gorocksdb"
+)
+
+// migrateRowFunc converts one row of a column to the new format, the converted data must be written to the write batch
+// the converted row can be stored under the same key or under a key lower than the converted key, otherwise it would be converted again
+type migrateRowFunc func(d *RocksDB, wb *gorocksdb.WriteBatch, key, val []byte) error
+
+// migrationColumn is one conversion of the rows of the column cf
+// the progress of the migration is stored under the name, one column can be converted by several conversions with different names
+type migrationColumn struct {
+	name    string
+	cf      int
+	migrate migrateRowFunc
+}
+
+// migrateBlockFunc stores the data of one replayed block, the data must be written to the write batch
+// txAddressesMap contains the stored transaction addresses of the transactions of the block
+type migrateBlockFunc func(d *RocksDB, wb *gorocksdb.WriteBatch, block *bchain.Block, txAddressesMap map[string]*TxAddresses) error
+
+// rebaseColumnFunc returns the conversion of the column, which adds the state of the replayed blocks
+// to the cumulative rows stored by ConnectBlock from the height builtFrom
+type rebaseColumnFunc func(d *RocksDB, builtFrom uint32) (migrateRowFunc, error)
+
+// the columns marked as coinstake are used only by the proof of stake coins, they are not rebased for the other coins
+type rebaseColumn struct {
+	cf        int
+	rebase    rebaseColumnFunc
+	coinstake bool
+}
+
+// migrationBlocks builds the columns introduced to an existing database from the blocks connected before the introduction,
+// the blocks are fetched from the backend and their transaction addresses are loaded from the index
+type migrationBlocks struct {
+	// builtFrom returns the height of the first block from which ConnectBlock maintains the columns
+	builtFrom func(d *RocksDB) (uint32, error)
+	connect   migrateBlockFunc
+	rebase    []rebaseColumn
+}
+
+// migration converts the database from the version from to the version from+1
+// the blocks are replayed before the conversion of the columns
+// the columns not listed in the migration do not change their format
+type migration struct {
+	from        uint32
+	description string
+	blocks      *migrationBlocks
+	columns     []migrationColumn
+}
+
+// migrations are the registered migration steps, a step must be added for each change of dbVersion
+// to allow the existing databases to be converted in place instead of a full reindex
+// the changes of the format made before the release of a version are added to the same step
+var migrations = []migration{
+	{
+		from:        5,
+		description: "build the columns introduced after the version 5 from the blocks and convert the changed columns",
+		blocks: &migrationBlocks{
+			builtFrom: supplyBuiltFrom,
+			connect:   migrateBlockColumns,
+			rebase: []rebaseColumn{
+				{cf: cfZerocoinPool, rebase: rebaseZerocoinPool, coinstake: true},
+				{cf: cfSupply, rebase: rebaseSupply},
+				{cf: cfBlockStakers, rebase: rebaseBlockStakers, coinstake: true},
+				{cf: cfBlockFilters, rebase: rebaseBlockFilters},
+			},
+		},
+		columns: []migrationColumn{
+			{name: "addressActivity", cf: cfAddressBalance, migrate: migrateAddrActivity},
+			{name: "coldStakingBalances", cf: cfAddressBalance, migrate: migrateColdStakingBalances},
+			{name: "zerocoinBalances", cf: cfAddressBalance, migrate: migrateZerocoinBalances},
+			{name: "richList", cf: cfAddressBalance, migrate: migrateRichList},
+			{name: "blockTimes", cf: cfHeight, migrate: migrateBlockTimes},
+			{name: "opReturns", cf: cfTxAddresses, migrate: migrateOpReturns},
+		},
+	},
+}
+
+// supplyBuiltFrom returns the height of the first supply snapshot
+// the supply is stored for each block, the columns introduced together with it are stored from the same block
+func supplyBuiltFrom(d *RocksDB) (uint32, error) {
+	it := d.db.NewIteratorCF(d.ro, d.cfh[cfSupply])
+	defer it.Close()
+	// heights are stored in binary complement, the last key is the lowest height
+	if it.SeekToLast(); it.Valid() {
+		return ^unpackUint(it.Key().Data()), nil
+	}
+	// no snapshot, all blocks must be replayed
+	height, _, err := d.GetBestBlock()
+	return height + 1, err
+}
+
+// migrateBlockColumns stores the data of the replayed block in the columns built by the migration from the version 5
+// the columns of the proof of stake data are built only for the coins supporting coinstake
+func migrateBlockColumns(d *RocksDB, wb *gorocksdb.WriteBatch, block *bchain.Block, txAddressesMap map[string]*TxAddresses) error {
+	if d.chainParser.SupportsCoinstake() {
+		if err := migrateCoinstakeColumns(d, wb, block, txAddressesMap); err != nil {
+			return err
+		}
+	}
+	spentOutpoints := make(map[string]*spendingInput)
+	if err := d.processSpentOutpoints(block, txAddressesMap, spentOutpoints); err != nil {
+		return err
+	}
+	d.storeSpentOutpoints(wb, spentOutpoints)
+	if err := d.connectBlockFilter(wb, block, txAddressesMap); err != nil {
+		return err
+	}
+	return d.connectSupply(wb, block, txAddressesMap)
+}
+
+// migrateCoinstakeColumns stores the proof of stake data of the replayed block
+func migrateCoinstakeColumns(d *RocksDB, wb *gorocksdb.WriteBatch, block *bchain.Block, txAddressesMap map[string]*TxAddresses) error {
+	stakingRewards := make(map[string]*StakingRewards)
+	if err := d.processStakingRewards(block, txAddressesMap, stakingRewards); err != nil {
+		return err
+	}
+	if err := d.storeStakingRewards(wb, stakingRewards); err != nil {
+		return err
+	}
+	masternodePayments := make(map[string]*big.Int)
+	if err := d.processMasternodePayments(block, masternodePayments); err != nil {
+		return err
+	}
+	if err := d.storeMasternodePayments(wb, masternodePayments); err != nil {
+		return err
+	}
+	if err := d.connectZerocoinPool(wb, block); err != nil {
+		return err
+	}
+	return d.connectBlockStaker(wb, block, txAddressesMap)
+}
+
+// number of rows converted and stored in one write batch together with the progress of the migration
+var migrationBatchRows = 100000
+
+func findMigration(from uint32) *migration {
+	for i := range migrations {
+		if migrations[i].from == from {
+			return &migrations[i]
+		}
+	}
+	return nil
+}
+
+// canMigrate checks if there are migration steps converting the database from given version to the current version
+func canMigrate(version uint32) bool {
+	for v := version; v < dbVersion; v++ {
+		if findMigration(v) == nil {
+			return false
+		}
+	}
+	return version < dbVersion
+}
+
+// dbColumnsVersion returns the lowest version of the columns of the database
+func (d *RocksDB) dbColumnsVersion() uint32 {
+	version := uint32(dbVersion)
+	for _, c := range d.is.GetAllDBColumnStats() {
+		if c.Version < version {
+			version = c.Version
+		}
+	}
+	return version
+}
+
+// MigrationRequired returns true if the database must be migrated to the current version before it can be used
+func (d *RocksDB) MigrationRequired() bool {
+	return d.dbColumnsVersion() < dbVersion
+}
+
+// Migrate converts the database to the current version by the registered migration steps
+// the blocks replayed by the migration are fetched from the chain
+// the migration can be interrupted by the stop signal and resumed later
+func (d *RocksDB) Migrate(chain bchain.BlockChain, stop chan os.Signal) error {
+	if d.is == nil {
+		return errors.New("Internal state not created")
+	}
+	for {
+		version := d.dbColumnsVersion()
+		if version >= dbVersion {
+			break
+		}
+		m := findMigration(version)
+		if m == nil {
+			return errors.Errorf("Missing migration from DB version %v", version)
+		}
+		if err := d.runMigration(chain, m, stop); err != nil {
+			return err
+		}
+	}
+	glog.Info("db: database is at version ", dbVersion)
+	return nil
+}
+
+func (d *RocksDB) runMigration(chain bchain.BlockChain, m *migration, stop chan os.Signal) error {
+	ms := d.is.Migration
+	if ms == nil || ms.Version != m.from {
+		ms = &common.InternalStateMigration{Version: m.from}
+		d.is.Migration = ms
+	}
+	glog.Info("db: migration from version ", m.from, " to ", m.from+1, ": ", m.description)
+	columns := m.columns
+	// the blocks are replayed only for the bitcoin type chains, the replayed columns exist only for them
+	if m.blocks != nil && d.chainParser.GetChainType() == bchain.ChainBitcoinType {
+		if ms.Column == "" || ms.Column == migrationBlocksStage {
+			if err := d.migrateBlocks(chain, m.blocks, ms, stop); err != nil {
+				return err
+			}
+		}
+		if ms.BuiltFrom > 0 {
+			rebase := make([]migrationColumn, 0, len(m.blocks.rebase)+len(m.columns))
+			for _, rc := range m.blocks.rebase {
+				if rc.coinstake && !d.chainParser.SupportsCoinstake() {
+					continue
+				}
+				migrate, err := rc.rebase(d, ms.BuiltFrom)
+				if err != nil {
+					return err
+				}
+				rebase = append(rebase, migrationColumn{name: cfNames[rc.cf], cf: rc.cf, migrate: migrate})
+			}
+			columns = append(rebase, m.columns...)
+		}
+		if ms.Column == migrationBlocksStage {
+			ms.Column = ""
+		}
+	}
+	// skip the columns converted before the interruption of the migration
+	skip := ms.Column != ""
+	for i := range columns {
+		mc := &columns[i]
+		// the type specific column does not exist for the other chain types
+		if mc.cf >= len(cfNames) {
+			continue
+		}
+		name := mc.name
+		if skip {
+			if name != ms.Column {
+				continue
+			}
+			skip = false
+		} else {
+			ms.Column = name
+			ms.LastKey = nil
+			ms.Rows = 0
+		}
+		if err := d.migrateColumn(mc, ms, stop); err != nil {
+			return err
+		}
+		glog.Info("db: migration ", name, " of column ", cfNames[mc.cf], " finished, ", ms.Rows, " rows converted")
+	}
+	for i := range d.is.DbColumns {
+		if d.is.DbColumns[i].Version == m.from {
+			d.is.DbColumns[i].Version = m.from + 1
+		}
+	}
+	d.is.Migration = nil
+	return d.storeState(d.is)
+}
+
+// migrationBlocksStage is the name of the replay of the blocks in the progress of the migration
+const migrationBlocksStage = "blocks"
+
+// migrateBlocks replays the blocks connected before the columns of the migration were introduced
+// each block is written in a separate write batch together with the progress of the migration
+func (d *RocksDB) migrateBlocks(chain bchain.BlockChain, mb *migrationBlocks, ms *common.InternalStateMigration, stop chan os.Signal) error {
+	var lowest uint32
+	it := d.db.NewIteratorCF(d.ro, d.cfh[cfHeight])
+	it.SeekToFirst()
+	empty := !it.Valid()
+	if !empty {
+		lowest = unpackUint(it.Key().Data())
+	}
+	it.Close()
+	if ms.Column == "" {
+		ms.BuiltFrom = 0
+		if !empty {
+			builtFrom, err := mb.builtFrom(d)
+			if err != nil {
+				return err
+			}
+			if builtFrom > lowest {
+				ms.BuiltFrom = builtFrom
+			}
+		}
+		ms.Column = migrationBlocksStage
+		ms.LastKey = nil
+		ms.Rows = 0
+		if err := d.storeState(d.is); err != nil {
+			return err
+		}
+	}
+	if ms.BuiltFrom == 0 {
+		glog.Info("db: no blocks to replay")
+		return nil
+	}
+	// the transactions spent in the pruned blocks were removed from the index, their inputs cannot be replayed
+	if d.IsPruned() || d.is.GetPrunedHeight() > 0 {
+		return errors.Errorf("The blocks %v-%v cannot be replayed in the pruned mode, run the migration without -prune before the index is pruned or reindex the database", lowest, ms.BuiltFrom-1)
+	}
+	height := lowest
+	if ms.LastKey != nil {
+		height = unpackUint(ms.LastKey) + 1
+	}
+	glog.Info("db: replaying blocks ", height, "-", ms.BuiltFrom-1)
+	for ; height < ms.BuiltFrom; height++ {
+		select {
+		case <-stop:
+			return ErrOperationInterrupted
+		default:
+		}
+		if err := d.migrateBlock(chain, mb, ms, height); err != nil {
+			return errors.Annotatef(err, "height %v", height)
+		}
+		if height%1000 == 0 {
+			glog.Info("db: replayed block ", height, ", in progress...")
+		}
+	}
+	glog.Info("db: replay of blocks finished, ", ms.Rows, " blocks replayed")
+	return nil
+}
+
+func (d *RocksDB) migrateBlock(chain bchain.BlockChain, mb *migrationBlocks, ms *common.InternalStateMigration, height uint32) error {
+	hash, err := d.GetBlockHash(height)
+	if err != nil {
+		return err
+	}
+	if hash == "" {
+		return errors.New("Block not found in the index")
+	}
+	block, err := chain.GetBlock(hash, height)
+	if err != nil {
+		return err
+	}
+	block.Height = height
+	txAddressesMap := make(map[string]*TxAddresses, len(block.Txs))
+	for i := range block.Txs {
+		btxID, err := d.chainParser.PackTxid(block.Txs[i].Txid)
+		if err != nil {
+			return err
+		}
+		ta, err := d.getTxAddresses(btxID)
+		if err != nil {
+			return err
+		}
+		if ta == nil {
+			return errors.Errorf("TxAddresses for txid %v not found, the database must be reindexed", block.Txs[i].Txid)
+		}
+		txAddressesMap[string(btxID)] = ta
+	}
+	wb := gorocksdb.NewWriteBatch()
+	defer wb.Destroy()
+	if err = mb.connect(d, wb, block, txAddressesMap); err != nil {
+		return err
+	}
+	ms.LastKey = packUint(height)
+	ms.Rows++
+	buf, err := d.is.Pack()
+	if err != nil {
+		return err
+	}
+	wb.PutCF(d.cfh[cfDefault], []byte(internalStateKey), buf)
+	return d.db.Write(d.wo, wb)
+}
+
+// migrateColumn converts the rows of the column in batches, each batch is written together with the progress of the migration
+func (d *RocksDB) migrateColumn(mc *migrationColumn, ms *common.InternalStateMigration, stop chan os.Signal) error {
+	// do not use cache
+	ro := gorocksdb.NewDefaultReadOptions()
+	ro.SetFillCache(false)
+	defer ro.Destroy()
+	for {
+		select {
+		case <-stop:
+			return ErrOperationInterrupted
+		default:
+		}
+		wb := gorocksdb.NewWriteBatch()
+		it := d.db.NewIteratorCF(ro, d.cfh[mc.cf])
+		if ms.LastKey == nil {
+			it.SeekToFirst()
+		} else {
+			it.Seek(ms.LastKey)
+			if it.Valid() && bytes.Equal(it.Key().Data(), ms.LastKey) {
+				it.Next()
+			}
+		}
+		rows := 0
+		var lastKey []byte
+		var err error
+		for ; it.Valid() && rows < migrationBatchRows; it.Next() {
+			key := append([]byte(nil), it.Key().Data()...)
+			if err = mc.migrate(d, wb, key, it.Value().Data()); err != nil {
+				err = errors.Annotatef(err, "column %v, key %x", cfNames[mc.cf], key)
+				break
+			}
+			lastKey = key
+			rows++
+		}
+		done := !it.Valid()
+		it.Close()
+		if err == nil && rows > 0 {
+			ms.LastKey = lastKey
+			ms.Rows += int64(rows)
+			var buf []byte
+			if buf, err = d.is.Pack(); err == nil {
+				wb.PutCF(d.cfh[cfDefault], []byte(internalStateKey), buf)
+				err = d.db.Write(d.wo, wb)
+			}
+		}
+		wb.Destroy()
+		if err != nil {
+			return err
+		}
+		if done {
+			return nil
+		}
+		glog.Info("db: column ", cfNames[mc.cf], ", ", ms.Rows, " rows converted, in progress...")
+	}
+}
@@ -0,0 +1,271 @@
+package db
+
+import (
+	"blockbook/bchain"
+	"bytes"
+	"encoding/hex"
+	"sort"
+
+	"github.com/golang/glog"
+	"github.com/juju/errors"
+	"github.com/tecbot/gorocksdb"
+)
+
+// OP_RETURN data
+// the column opReturns indexes the outputs carrying OP_RETURN data by the prefix of the data, the key is the prefix
+// of the configured length padded by zeros, followed by the height of the block, the packed txid and the packed vout,
+// the value is the whole data of the output
+// the prefix length is stored in the default column when it is set for the first time, the index must be rebuilt to change it
+
+const opReturnPrefixLengthKey = "opReturnPrefixLength"
+
+// defaultOpReturnPrefixLength is the prefix length used if it is not set
+const defaultOpReturnPrefixLength = 8
+
+// maxOpReturnPrefixLength is the maximum prefix length, the standard OP_RETURN outputs carry at most 80 bytes
+const maxOpReturnPrefixLength = 80
+
+// OpReturnOutput is an output carrying OP_RETURN data
+type OpReturnOutput struct {
+	Txid   string
+	Vout   int32
+	Height uint32
+	Data   []byte
+}
+
+// SetOpReturnPrefixLength sets the length of the prefix of the OP_RETURN data by which the outputs are indexed
+// if the index was already built with a different length, the stored length is kept
+func (d *RocksDB) SetOpReturnPrefixLength(length int) error {
+	if d.chainParser.GetChainType() != bchain.ChainBitcoinType {
+		return nil
+	}
+	if length < 1 || length > maxOpReturnPrefixLength {
+		return errors.Errorf("OP_RETURN prefix length %v is out of range 1-%v", length, maxOpReturnPrefixLength)
+	}
+	stored, err := d.loadOpReturnPrefixLength()
+	if err != nil {
+		return err
+	}
+	if stored == 0 {
+		if err = d.db.PutCF(d.wo, d.cfh[cfDefault], []byte(opReturnPrefixLengthKey), packUint(uint32(length))); err != nil {
+			return err
+		}
+	} else if stored != length {
+		glog.Warning("rocksdb: index was built with OP_RETURN prefix length ", stored, ", using it, the index must be rebuilt to change it to ", length)
+		length = stored
+	}
+	d.opReturnPrefixLength = length
+	glog.Info("rocksdb: OP_RETURN prefix length ", length)
+	return nil
+}
+
+// loadOpReturnPrefixLength returns the stored length of the OP_RETURN prefix, 0 if it was not stored yet
+func (d *RocksDB) loadOpReturnPrefixLength() (int, error) {
+	val, err := d.db.GetCF(d.ro, d.cfh[cfDefault], []byte(opReturnPrefixLengthKey))
+	if err != nil {
+		return 0, err
+	}
+	defer val.Free()
+	if len(val.Data()) != 4 {
+		return 0, nil
+	}
+	return int(unpackUint(val.Data())), nil
+}
+
+// packOpReturnPrefix returns the prefix of the data padded by zeros to the prefix length
+func (d *RocksDB) packOpReturnPrefix(data []byte) []byte {
+	buf := make([]byte, d.opReturnPrefixLength)
+	copy(buf, data)
+	return buf
+}
+
+func (d *RocksDB) packOpReturnKey(data []byte, height uint32, btxID []byte, vout int32) []byte {
+	varBuf := make([]byte, maxPackedBigintBytes)
+	l := packVaruint(uint(vout), varBuf)
+	buf := make([]byte, 0, d.opReturnPrefixLength+4+len(btxID)+l)
+	buf = append(buf, d.packOpReturnPrefix(data)...)
+	buf = append(buf, packUint(height)...)
+	buf = append(buf, btxID...)
+	return append(buf, varBuf[:l]...)
+}
+
+func (d *RocksDB) unpackOpReturnKey(key []byte) (uint32, []byte, int32, error) {
+	pl := d.chainParser.PackedTxidLen()
+	o := d.opReturnPrefixLength
+	if len(key) < o+4+pl+1 {
+		return 0, nil, 0, errors.Errorf("Inconsistent data in opReturns %v", hex.EncodeToString(key))
+	}
+	height := unpackUint(key[o : o+4])
+	btxID := append([]byte(nil), key[o+4:o+4+pl]...)
+	vout, _ := unpackVaruint(key[o+4+pl:])
+	return height, btxID, int32(vout), nil
+}
+
+// processOpReturns collects the outputs of the transactions of the block carrying OP_RETURN data
+func (d *RocksDB) processOpReturns(block *bchain.Block, txAddressesMap map[string]*TxAddresses, opReturns map[string][]byte) error {
+	for i := range block.Txs {
+		btxID, err := d.chainParser.PackTxid(block.Txs[i].Txid)
+		if err != nil {
+			return err
+		}
+		ta := txAddressesMap[string(btxID)]
+		if ta == nil {
+			continue
+		}
+		for vout := range ta.Outputs {
+			if data := d.chainParser.GetOPReturnData(ta.Outputs[vout].AddrDesc); len(data) > 0 {
+				opReturns[string(d.packOpReturnKey(data, block.Height, btxID, int32(vout)))] = data
+			}
+		}
+	}
+	return nil
+}
+
+func (d *RocksDB) storeOpReturns(wb *gorocksdb.WriteBatch, opReturns map[string][]byte) {
+	for key, data := range opReturns {
+		wb.PutCF(d.cfh[cfOpReturns], []byte(key), data)
+	}
+}
+
+// disconnectOpReturns removes the outputs of the disconnected transaction from the index
+func (d *RocksDB) disconnectOpReturns(wb *gorocksdb.WriteBatch, height uint32, btxID []byte, txa *TxAddresses) {
+	for vout := range txa.Outputs {
+		if data := d.chainParser.GetOPReturnData(txa.Outputs[vout].AddrDesc); len(data) > 0 {
+			wb.DeleteCF(d.cfh[cfOpReturns], d.packOpReturnKey(data, height, btxID, int32(vout)))
+		}
+	}
+}
+
+// migrateOpReturns indexes the OP_RETURN outputs of the stored transaction, the transactions pruned from the index cannot be indexed
+func migrateOpReturns(d *RocksDB, wb *gorocksdb.WriteBatch, key, val []byte) error {
+	ta, err := unpackTxAddresses(val)
+	if err != nil {
+		return err
+	}
+	for vout := range ta.Outputs {
+		if data := d.chainParser.GetOPReturnData(ta.Outputs[vout].AddrDesc); len(data) > 0 {
+			wb.PutCF(d.cfh[cfOpReturns], d.packOpReturnKey(data, ta.Height, key, int32(vout)), data)
+		}
+	}
+	return nil
+}
+
+// OpReturnPrefixLength returns the length of the prefix of the OP_RETURN data by which the outputs are indexed
+func (d *RocksDB) OpReturnPrefixLength() int {
+	return d.opReturnPrefixLength
+}
+
+// seekBefore positions the iterator to the last key lower than key, nil key means the last key of the column
+func seekBefore(it *gorocksdb.Iterator, key []byte) {
+	if key == nil {
+		it.SeekToLast()
+		return
+	}
+	if it.Seek(key); it.Valid() {
+		it.Prev()
+	} else {
+		it.SeekToLast()
+	}
+}
+
+// nextPrefix returns the lowest key greater than all keys starting with prefix, nil if there is no such key
+func nextPrefix(prefix []byte) []byte {
+	next := append([]byte(nil), prefix...)
+	for i := len(next) - 1; i >= 0; i-- {
+		if next[i] < 0xff {
+			next[i]++
+			return next[:i+1]
+		}
+	}
+	return nil
+}
+
+// GetOpReturnOutputs returns at most count outputs with the OP_RETURN data starting with prefix in the blocks lower-higher,
+// skipping the first skip outputs, the outputs are ordered from the highest block
+// the prefix can be longer than the indexed prefix length, then the data of the outputs with the indexed prefix are checked
+// the rows of an indexed prefix are ordered by the height, they are read backwards from the block higher until skip+count outputs are found,
+// a prefix shorter than the indexed prefix length matches several indexed prefixes, their outputs are read in the same way and merged
+func (d *RocksDB) GetOpReturnOutputs(prefix []byte, lower, higher uint32, skip, count int) ([]OpReturnOutput, error) {
+	if d.chainParser.GetChainType() != bchain.ChainBitcoinType {
+		return nil, errors.New("OP_RETURN index is supported only for Bitcoin type coins")
+	}
+	need := skip + count
+	if need <= 0 || lower > higher {
+		return nil, nil
+	}
+	seek := prefix
+	if len(seek) > d.opReturnPrefixLength {
+		seek = seek[:d.opReturnPrefixLength]
+	}
+	it := d.db.NewIteratorCF(d.ro, d.cfh[cfOpReturns])
+	defer it.Close()
+	var outputs []OpReturnOutput
+	// the indexed prefixes matching the searched prefix are processed from the highest one
+	seekBefore(it, nextPrefix(seek))
+	for it.Valid() {
+		key := it.Key().Data()
+		if !bytes.HasPrefix(key, seek) || len(key) < d.opReturnPrefixLength {
+			break
+		}
+		indexed := append([]byte(nil), key[:d.opReturnPrefixLength]...)
+		// when the page is full, only the outputs in the blocks higher than the last output of the page can get to the page
+		minHeight, full := lower, false
+		if len(outputs) == need {
+			if last := outputs[need-1].Height; last < higher {
+				minHeight = last + 1
+			} else {
+				full = true
+			}
+		}
+		if !full {
+			var end []byte
+			if higher == ^uint32(0) {
+				end = nextPrefix(indexed)
+			} else {
+				end = append(append([]byte(nil), indexed...), packUint(higher+1)...)
+			}
+			l := len(outputs)
+			for seekBefore(it, end); it.Valid() && len(outputs)-l < need; it.Prev() {
+				key := it.Key().Data()
+				if !bytes.HasPrefix(key, indexed) {
+					break
+				}
+				height, btxID, vout, err := d.unpackOpReturnKey(key)
+				if err != nil {
+					return nil, err
+				}
+				if height < minHeight {
+					break
+				}
+				// the indexed prefix is padded by zeros, the data must be checked
+				data := it.Value().Data()
+				if !bytes.HasPrefix(data, prefix) {
+					continue
+				}
+				txid, err := d.chainParser.UnpackTxid(btxID)
+				if err != nil {
+					return nil, err
+				}
+				outputs = append(outputs, OpReturnOutput{
+					Txid:   txid,
+					Vout:   vout,
+					Height: height,
+					Data:   append([]byte(nil), data...),
+				})
+			}
+			// the outputs of the processed indexed prefixes with the same height stay before the outputs of this one
+			sort.SliceStable(outputs, func(i, j int) bool {
+				return outputs[i].Height > outputs[j].Height
+			})
+			if len(outputs) > need {
+				outputs = outputs[:need]
+			}
+		}
+		// continue by the last key of the lower indexed prefix
+		seekBefore(it, indexed)
+	}
+	if len(outputs) <= skip {
+		return nil, nil
+	}
+	return outputs[skip:], nil
+}
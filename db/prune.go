@@ -0,0 +1,370 @@
+package db
+
+import (
+	"blockbook/bchain"
+	"bytes"
+	"encoding/hex"
+	"os"
+
+	"github.com/golang/glog"
+	"github.com/juju/errors"
+	"github.com/tecbot/gorocksdb"
+)
+
+// pruned index
+// in the pruned mode the txAddresses and the cached transactions of the fully spent transactions are removed from the index
+// when the block, in which the transaction became fully spent, is deeper than the pruning depth
+// the transactions which became fully spent in a block are stored in the column spentTxs under the height of the block
+// the rows of the column are removed when the transactions are pruned
+// the column is maintained only in the pruned mode, the transactions fully spent in the blocks connected before the pruning
+// was enabled are found by a sweep of the column txAddresses, the key spentTxsSwept in the column default marks the sweep as done
+// and is removed when the index is opened in the not pruned mode
+
+const prunedHeightKey = "prunedHeight"
+
+const spentTxsSweptKey = "spentTxsSwept"
+
+// txCachePruneInterval is the number of blocks between the checks of the age and the size of the transactions cache
+const txCachePruneInterval = 100
+
+// bulkPruneRows is the number of the rows of spent transactions collected in the bulk mode before they are pruned
+const bulkPruneRows = 1000
+
+// SetPruning switches the index to the pruned mode, depth is the number of blocks after which the fully spent transactions are pruned
+// txCacheMaxBytes limits the size of the transactions cache, 0 means that the cached transactions are limited only by the depth
+// the depth cannot be lower than the number of blocks kept for rollback
+func (d *RocksDB) SetPruning(depth uint32, txCacheMaxBytes int64) {
+	if d.chainParser.GetChainType() != bchain.ChainBitcoinType {
+		glog.Warning("rocksdb: pruning is supported only for Bitcoin type coins")
+		return
+	}
+	if keep := uint32(d.chainParser.KeepBlockAddresses()); depth < keep {
+		glog.Warning("rocksdb: pruning depth ", depth, " is lower than the number of blocks kept for rollback, using ", keep)
+		depth = keep
+	}
+	d.pruneDepth = depth
+	d.txCacheMaxBytes = txCacheMaxBytes
+	glog.Info("rocksdb: pruned mode, depth ", depth, " blocks, max tx cache size ", txCacheMaxBytes)
+}
+
+// IsPruned returns true if the index is in the pruned mode
+func (d *RocksDB) IsPruned() bool {
+	return d.pruneDepth > 0
+}
+
+// txFullySpent returns true if all spendable outputs of the transaction are spent
+func (d *RocksDB) txFullySpent(ta *TxAddresses) bool {
+	if len(ta.Outputs) == 0 {
+		return false
+	}
+	for i := range ta.Outputs {
+		o := &ta.Outputs[i]
+		if !o.Spent && d.chainParser.IsAddrDescIndexable(o.AddrDesc) {
+			return false
+		}
+	}
+	return true
+}
+
+// fullySpentTxs returns the transactions which became fully spent in the block
+// an input spends the last unspent output of a transaction only in the block in which the transaction becomes fully spent
+func (d *RocksDB) fullySpentTxs(block *bchain.Block, txAddressesMap map[string]*TxAddresses) [][]byte {
+	var btxIDs [][]byte
+	seen := make(map[string]struct{})
+	for i := range block.Txs {
+		for _, vin := range block.Txs[i].Vin {
+			if vin.Txid == "" {
+				continue
+			}
+			btxID, err := d.chainParser.PackTxid(vin.Txid)
+			if err != nil {
+				continue
+			}
+			s := string(btxID)
+			if _, found := seen[s]; found {
+				continue
+			}
+			seen[s] = struct{}{}
+			if ta := txAddressesMap[s]; ta != nil && d.txFullySpent(ta) {
+				btxIDs = append(btxIDs, btxID)
+			}
+		}
+	}
+	return btxIDs
+}
+
+func packSpentTxs(btxIDs [][]byte) []byte {
+	return bytes.Join(btxIDs, nil)
+}
+
+func (d *RocksDB) unpackSpentTxs(buf []byte) ([][]byte, error) {
+	pl := d.chainParser.PackedTxidLen()
+	if len(buf)%pl != 0 {
+		return nil, errors.Errorf("Inconsistent data in spentTxs %v", hex.EncodeToString(buf))
+	}
+	btxIDs := make([][]byte, 0, len(buf)/pl)
+	for i := 0; i < len(buf); i += pl {
+		btxIDs = append(btxIDs, append([]byte(nil), buf[i:i+pl]...))
+	}
+	return btxIDs, nil
+}
+
+func (d *RocksDB) storeSpentTxs(wb *gorocksdb.WriteBatch, height uint32, btxIDs [][]byte) {
+	if len(btxIDs) > 0 {
+		wb.PutCF(d.cfh[cfSpentTxs], packUint(height), packSpentTxs(btxIDs))
+	}
+}
+
+// connectSpentTxs stores the transactions fully spent in the block and prunes the transactions spent in the block at the pruning depth
+// returns the new pruned height or 0 if the index is not pruned
+func (d *RocksDB) connectSpentTxs(wb *gorocksdb.WriteBatch, block *bchain.Block, txAddressesMap map[string]*TxAddresses) (uint32, error) {
+	if d.pruneDepth == 0 {
+		return 0, nil
+	}
+	d.storeSpentTxs(wb, block.Height, d.fullySpentTxs(block, txAddressesMap))
+	if block.Height <= d.pruneDepth {
+		return 0, nil
+	}
+	prunedHeight := block.Height - d.pruneDepth
+	if err := d.pruneSpentTxs(wb, prunedHeight, txAddressesMap); err != nil {
+		return 0, err
+	}
+	wb.PutCF(d.cfh[cfDefault], []byte(prunedHeightKey), packUint(prunedHeight))
+	return prunedHeight, nil
+}
+
+// pruneSpentTxs removes the transactions fully spent in the blocks up to the height higher
+// the transactions modified by the connected block (in txAddressesMap) are not fully spent anymore and are kept
+func (d *RocksDB) pruneSpentTxs(wb *gorocksdb.WriteBatch, higher uint32, txAddressesMap map[string]*TxAddresses) error {
+	it := d.db.NewIteratorCF(d.ro, d.cfh[cfSpentTxs])
+	defer it.Close()
+	for it.SeekToFirst(); it.Valid(); it.Next() {
+		key := it.Key().Data()
+		if unpackUint(key) > higher {
+			break
+		}
+		btxIDs, err := d.unpackSpentTxs(it.Value().Data())
+		if err != nil {
+			return err
+		}
+		for _, btxID := range btxIDs {
+			if _, found := txAddressesMap[string(btxID)]; found {
+				continue
+			}
+			// the transaction could have been unspent by a disconnected block
+			ta, err := d.getTxAddresses(btxID)
+			if err != nil {
+				return err
+			}
+			if ta != nil && d.txFullySpent(ta) {
+				d.pruneTx(wb, btxID, len(ta.Outputs))
+			}
+		}
+		wb.DeleteCF(d.cfh[cfSpentTxs], append([]byte(nil), key...))
+	}
+	return nil
+}
+
+func (d *RocksDB) pruneTx(wb *gorocksdb.WriteBatch, btxID []byte, outputs int) {
+	wb.DeleteCF(d.cfh[cfTxAddresses], btxID)
+	d.deleteSpentOutpoints(wb, btxID, outputs)
+	d.internalDeleteTx(wb, btxID)
+}
+
+// disconnectSpentTxs removes the transactions fully spent in the disconnected block
+func (d *RocksDB) disconnectSpentTxs(wb *gorocksdb.WriteBatch, height uint32) {
+	if d.pruneDepth > 0 {
+		wb.DeleteCF(d.cfh[cfSpentTxs], packUint(height))
+	}
+}
+
+// InitSpentTxs prepares the column spentTxs for the current mode of the index, it must be called before the blocks are connected
+// in the pruned mode the transactions fully spent before the pruning was enabled are swept, the sweep can be interrupted and it is run again
+func (d *RocksDB) InitSpentTxs(stop chan os.Signal) error {
+	val, err := d.db.GetCF(d.ro, d.cfh[cfDefault], []byte(spentTxsSweptKey))
+	if err != nil {
+		return err
+	}
+	swept := len(val.Data()) > 0
+	val.Free()
+	if !d.IsPruned() {
+		if swept {
+			return d.db.DeleteCF(d.wo, d.cfh[cfDefault], []byte(spentTxsSweptKey))
+		}
+		return nil
+	}
+	if swept {
+		return nil
+	}
+	if err := d.sweepSpentTxs(stop); err != nil {
+		return err
+	}
+	return d.db.PutCF(d.wo, d.cfh[cfDefault], []byte(spentTxsSweptKey), []byte{1})
+}
+
+// spentTxHeight returns the height of the block in which the fully spent transaction became fully spent
+// it is the highest height of the inputs spending its outputs, the best height if some spending input is not known
+func (d *RocksDB) spentTxHeight(btxID []byte, ta *TxAddresses, bestHeight uint32) (uint32, error) {
+	var height uint32
+	for i := range ta.Outputs {
+		if !ta.Outputs[i].Spent {
+			continue
+		}
+		val, err := d.db.GetCF(d.ro, d.cfh[cfSpentOutpoints], packSpentOutpointKey(btxID, int32(i)))
+		if err != nil {
+			return 0, err
+		}
+		if len(val.Data()) == 0 {
+			val.Free()
+			return bestHeight, nil
+		}
+		si, err := d.unpackSpendingInput(val.Data())
+		val.Free()
+		if err != nil {
+			return 0, err
+		}
+		if si.height > height {
+			height = si.height
+		}
+	}
+	return height, nil
+}
+
+// sweepSpentTxs prunes the fully spent transactions of the blocks deeper than the pruning depth
+// and stores the other fully spent transactions in the column spentTxs under the height in which they became fully spent
+func (d *RocksDB) sweepSpentTxs(stop chan os.Signal) error {
+	bestHeight, _, err := d.GetBestBlock()
+	if err != nil {
+		return err
+	}
+	var prunedHeight uint32
+	if bestHeight > d.pruneDepth {
+		prunedHeight = bestHeight - d.pruneDepth
+	}
+	glog.Info("rocksdb: sweep of the fully spent transactions, pruning up to height ", prunedHeight)
+	spentTxs := make(map[uint32][][]byte)
+	wb := gorocksdb.NewWriteBatch()
+	defer wb.Destroy()
+	ro := gorocksdb.NewDefaultReadOptions()
+	ro.SetFillCache(false)
+	defer ro.Destroy()
+	it := d.db.NewIteratorCF(ro, d.cfh[cfTxAddresses])
+	defer it.Close()
+	pruned, rows := 0, 0
+	for it.SeekToFirst(); it.Valid(); it.Next() {
+		select {
+		case <-stop:
+			return ErrOperationInterrupted
+		default:
+		}
+		ta, err := unpackTxAddresses(it.Value().Data())
+		if err != nil {
+			return err
+		}
+		if !d.txFullySpent(ta) {
+			continue
+		}
+		btxID := append([]byte(nil), it.Key().Data()...)
+		height, err := d.spentTxHeight(btxID, ta, bestHeight)
+		if err != nil {
+			return err
+		}
+		if height > prunedHeight {
+			spentTxs[height] = append(spentTxs[height], btxID)
+			continue
+		}
+		d.pruneTx(wb, btxID, len(ta.Outputs))
+		pruned++
+		if rows++; rows >= bulkPruneRows {
+			if err := d.db.Write(d.wo, wb); err != nil {
+				return err
+			}
+			wb.Clear()
+			rows = 0
+		}
+	}
+	for height, btxIDs := range spentTxs {
+		val, err := d.db.GetCF(d.ro, d.cfh[cfSpentTxs], packUint(height))
+		if err != nil {
+			return err
+		}
+		stored, err := d.unpackSpentTxs(val.Data())
+		val.Free()
+		if err != nil {
+			return err
+		}
+		d.storeSpentTxs(wb, height, append(stored, btxIDs...))
+	}
+	if prunedHeight > d.is.GetPrunedHeight() {
+		wb.PutCF(d.cfh[cfDefault], []byte(prunedHeightKey), packUint(prunedHeight))
+	}
+	if err := d.db.Write(d.wo, wb); err != nil {
+		return err
+	}
+	if prunedHeight > d.is.GetPrunedHeight() {
+		d.is.SetPrunedHeight(prunedHeight)
+	}
+	glog.Info("rocksdb: sweep of the fully spent transactions finished, ", pruned, " transactions pruned, ", len(spentTxs), " blocks of spent transactions stored")
+	return nil
+}
+
+// loadPrunedHeight returns the height up to which the fully spent transactions were pruned, 0 if the index was never pruned
+func (d *RocksDB) loadPrunedHeight() (uint32, error) {
+	val, err := d.db.GetCF(d.ro, d.cfh[cfDefault], []byte(prunedHeightKey))
+	if err != nil {
+		return 0, err
+	}
+	defer val.Free()
+	if len(val.Data()) != 4 {
+		return 0, nil
+	}
+	return unpackUint(val.Data()), nil
+}
+
+// pruneTxCache removes from the transactions cache the transactions from blocks deeper than the pruning depth
+// and if the cache is still larger than the limit, other transactions until the size of the cache is within the limit
+func (d *RocksDB) pruneTxCache(bestHeight uint32) error {
+	if d.pruneDepth == 0 || bestHeight <= d.pruneDepth {
+		return nil
+	}
+	wb := gorocksdb.NewWriteBatch()
+	defer wb.Destroy()
+	_, keyBytes, valueBytes := d.is.GetDBColumnStatValues(cfTransactions)
+	size := keyBytes + valueBytes
+	ro := gorocksdb.NewDefaultReadOptions()
+	ro.SetFillCache(false)
+	defer ro.Destroy()
+	it := d.db.NewIteratorCF(ro, d.cfh[cfTransactions])
+	defer it.Close()
+	var young [][]byte
+	pruned := 0
+	for it.SeekToFirst(); it.Valid(); it.Next() {
+		key := append([]byte(nil), it.Key().Data()...)
+		_, height, err := d.chainParser.UnpackTx(it.Value().Data())
+		if err != nil || height+d.pruneDepth < bestHeight {
+			size -= int64(len(key) + len(it.Value().Data()))
+			d.internalDeleteTx(wb, key)
+			pruned++
+		} else if d.txCacheMaxBytes > 0 {
+			young = append(young, key)
+		}
+	}
+	for i := 0; i < len(young) && d.txCacheMaxBytes > 0 && size > d.txCacheMaxBytes; i++ {
+		val, err := d.db.GetCF(ro, d.cfh[cfTransactions], young[i])
+		if err != nil {
+			return err
+		}
+		size -= int64(len(young[i]) + len(val.Data()))
+		val.Free()
+		d.internalDeleteTx(wb, young[i])
+		pruned++
+	}
+	if pruned == 0 {
+		return nil
+	}
+	if err := d.db.Write(d.wo, wb); err != nil {
+		return err
+	}
+	glog.Info("rocksdb: pruned ", pruned, " cached transactions")
+	return nil
+}
@@ -0,0 +1,104 @@
+package db
+
+import (
+	"blockbook/bchain"
+	"math/big"
+
+	"github.com/juju/errors"
+	"github.com/tecbot/gorocksdb"
+)
+
+// rich list
+// the column richList contains the addresses with positive balance ordered by the balance, the key is the packed balance
+// followed by the address descriptor, the value is empty
+// the packed bigint starts with its length and is big endian without leading zeros, therefore the keys are ordered by the balance
+// the rows are maintained by storeBalances, the staker addresses of cold staking are not included, they do not own the coins
+// the balances of the Ethereum type coins are not indexed, the column exists only for the Bitcoin type coins
+
+// RichListAddress is an address in the rich list
+type RichListAddress struct {
+	AddrDesc   bchain.AddressDescriptor
+	BalanceSat big.Int
+}
+
+func packRichListKey(balance *big.Int, addrDesc bchain.AddressDescriptor) []byte {
+	buf := make([]byte, maxPackedBigintBytes+len(addrDesc))
+	l := packBigint(balance, buf)
+	return append(buf[:l], addrDesc...)
+}
+
+func unpackRichListKey(key []byte) (*RichListAddress, error) {
+	if len(key) == 0 || len(key) < int(key[0])+1 {
+		return nil, errors.New("Inconsistent data in richList")
+	}
+	balance, l := unpackBigint(key)
+	return &RichListAddress{
+		AddrDesc:   append(bchain.AddressDescriptor(nil), key[l:]...),
+		BalanceSat: balance,
+	}, nil
+}
+
+func (d *RocksDB) inRichList(addrDesc bchain.AddressDescriptor, balance *big.Int) bool {
+	return balance != nil && balance.Sign() > 0 && !d.chainParser.IsColdStakingStakerAddrDesc(addrDesc)
+}
+
+// updateRichList moves the address in the rich list from the stored balance to the new balance
+// nil stored balance means that the address is not stored in the db, nil balance removes the address
+func (d *RocksDB) updateRichList(wb *gorocksdb.WriteBatch, addrDesc bchain.AddressDescriptor, stored, balance *big.Int) {
+	if stored != nil && balance != nil && stored.Cmp(balance) == 0 {
+		return
+	}
+	if d.inRichList(addrDesc, stored) {
+		wb.DeleteCF(d.cfh[cfRichList], packRichListKey(stored, addrDesc))
+	}
+	if d.inRichList(addrDesc, balance) {
+		wb.PutCF(d.cfh[cfRichList], packRichListKey(balance, addrDesc), []byte{})
+	}
+}
+
+// migrateRichList adds the address of the stored balance to the rich list, the balance itself is not changed
+func migrateRichList(d *RocksDB, wb *gorocksdb.WriteBatch, key, val []byte) error {
+	// the column addressBalance is used by the Bitcoin type coins only, it is shared with the column addressContracts
+	if d.chainParser.GetChainType() != bchain.ChainBitcoinType || len(val) < 3 {
+		return nil
+	}
+	ab, err := unpackAddrBalance(val, d.chainParser.PackedTxidLen(), AddressBalanceDetailNoUTXO)
+	if err != nil {
+		return err
+	}
+	if d.inRichList(key, &ab.BalanceSat) {
+		wb.PutCF(d.cfh[cfRichList], packRichListKey(&ab.BalanceSat, key), []byte{})
+	}
+	return nil
+}
+
+// GetRichList returns the addresses with the highest balance, skipping the first from addresses, at most count addresses
+func (d *RocksDB) GetRichList(from, count int) ([]RichListAddress, error) {
+	it := d.db.NewIteratorCF(d.ro, d.cfh[cfRichList])
+	defer it.Close()
+	rl := make([]RichListAddress, 0, count)
+	i := 0
+	for it.SeekToLast(); it.Valid() && len(rl) < count; it.Prev() {
+		if i < from {
+			i++
+			continue
+		}
+		a, err := unpackRichListKey(it.Key().Data())
+		if err != nil {
+			return nil, err
+		}
+		rl = append(rl, *a)
+	}
+	return rl, nil
+}
+
+// GetRichListSize returns the number of the addresses in the rich list, at most max
+func (d *RocksDB) GetRichListSize(max int) (int, error) {
+	it := d.db.NewIteratorCF(d.ro, d.cfh[cfRichList])
+	defer it.Close()
+	n := 0
+	for it.SeekToLast(); it.Valid() && n < max; it.Prev() {
+		n++
+	}
+	return n, nil
+}
@@ -22,7 +22,7 @@ import (
 	"github.com/tecbot/gorocksdb"
 )
 
-const dbVersion = 5
+const dbVersion = 6
 
 const packedHeightBytes = 4
 const maxAddrDescLen = 1024
@@ -87,17 +87,22 @@ const (
 
 // RocksDB handle
 type RocksDB struct {
-	path         string
-	db           *gorocksdb.DB
-	wo           *gorocksdb.WriteOptions
-	ro           *gorocksdb.ReadOptions
-	cfh          []*gorocksdb.ColumnFamilyHandle
-	chainParser  bchain.BlockChainParser
-	is           *common.InternalState
-	metrics      *common.Metrics
-	cache        *gorocksdb.Cache
-	maxOpenFiles int
-	cbs          connectBlockStats
+	path            string
+	db              *gorocksdb.DB
+	wo              *gorocksdb.WriteOptions
+	ro              *gorocksdb.ReadOptions
+	cfh             []*gorocksdb.ColumnFamilyHandle
+	chainParser     bchain.BlockChainParser
+	is              *common.InternalState
+	metrics         *common.Metrics
+	cache           *gorocksdb.Cache
+	maxOpenFiles    int
+	cbs             connectBlockStats
+	pruneDepth      uint32
+	txCacheMaxBytes int64
+	lbt             lastBlockTime
+	// length of the prefix of the OP_RETURN data by which the outputs are indexed
+	opReturnPrefixLength int
 }
 
 const (
@@ -107,19 +112,31 @@ const (
 	cfBlockTxs
 	cfTransactions
 	cfFiatRates
+	cfBlockTimes
 	// BitcoinType
 	cfAddressBalance
 	cfTxAddresses
+	cfStakingRewards
+	cfMasternodePayments
+	cfZerocoinPool
+	cfSupply
+	cfSuperblocks
+	cfBlockStakers
+	cfSpentTxs
+	cfSpentOutpoints
+	cfRichList
+	cfOpReturns
+	cfBlockFilters
 	// EthereumType
 	cfAddressContracts = cfAddressBalance
 )
 
 // common columns
 var cfNames []string
-var cfBaseNames = []string{"default", "height", "addresses", "blockTxs", "transactions", "fiatRates"}
+var cfBaseNames = []string{"default", "height", "addresses", "blockTxs", "transactions", "fiatRates", "blockTimes"}
 
 // type specific columns
-var cfNamesBitcoinType = []string{"addressBalance", "txAddresses"}
+var cfNamesBitcoinType = []string{"addressBalance", "txAddresses", "stakingRewards", "masternodePayments", "zerocoinPool", "supply", "superblocks", "blockStakers", "spentTxs", "spentOutpoints", "richList", "opReturns", "blockFilters"}
 var cfNamesEthereumType = []string{"addressContracts"}
 
 func openDB(path string, c *gorocksdb.Cache, openFiles int) (*gorocksdb.DB, []*gorocksdb.ColumnFamilyHandle, error) {
@@ -164,7 +181,7 @@ func NewRocksDB(path string, cacheSize, maxOpenFiles int, parser bchain.BlockCha
 	}
 	wo := gorocksdb.NewDefaultWriteOptions()
 	ro := gorocksdb.NewDefaultReadOptions()
-	return &RocksDB{path, db, wo, ro, cfh, parser, nil, metrics, c, maxOpenFiles, connectBlockStats{}}, nil
+	return &RocksDB{path, db, wo, ro, cfh, parser, nil, metrics, c, maxOpenFiles, connectBlockStats{}, 0, 0, lastBlockTime{}, defaultOpReturnPrefixLength}, nil
 }
 
 func (d *RocksDB) closeDB() error {
@@ -443,10 +460,12 @@ func (d *RocksDB) ConnectBlock(block *bchain.Block) error {
 		return err
 	}
 	addresses := make(addressesMap)
+	var prunedHeight uint32
 	if chainType == bchain.ChainBitcoinType {
 		txAddressesMap := make(map[string]*TxAddresses)
 		balances := make(map[string]*AddrBalance)
-		if err := d.processAddressesBitcoinType(block, addresses, txAddressesMap, balances); err != nil {
+		spentOutpoints := make(map[string]*spendingInput)
+		if err := d.processAddressesBitcoinType(block, addresses, txAddressesMap, balances, spentOutpoints); err != nil {
 			return err
 		}
 		if err := d.storeTxAddresses(wb, txAddressesMap); err != nil {
@@ -455,6 +474,42 @@ func (d *RocksDB) ConnectBlock(block *bchain.Block) error {
 		if err := d.storeBalances(wb, balances); err != nil {
 			return err
 		}
+		d.storeSpentOutpoints(wb, spentOutpoints)
+		opReturns := make(map[string][]byte)
+		if err := d.processOpReturns(block, txAddressesMap, opReturns); err != nil {
+			return err
+		}
+		d.storeOpReturns(wb, opReturns)
+		if err := d.connectBlockFilter(wb, block, txAddressesMap); err != nil {
+			return err
+		}
+		stakingRewards := make(map[string]*StakingRewards)
+		if err := d.processStakingRewards(block, txAddressesMap, stakingRewards); err != nil {
+			return err
+		}
+		if err := d.storeStakingRewards(wb, stakingRewards); err != nil {
+			return err
+		}
+		masternodePayments := make(map[string]*big.Int)
+		if err := d.processMasternodePayments(block, masternodePayments); err != nil {
+			return err
+		}
+		if err := d.storeMasternodePayments(wb, masternodePayments); err != nil {
+			return err
+		}
+		if err := d.connectZerocoinPool(wb, block); err != nil {
+			return err
+		}
+		if err := d.connectSupply(wb, block, txAddressesMap); err != nil {
+			return err
+		}
+		if err := d.connectBlockStaker(wb, block, txAddressesMap); err != nil {
+			return err
+		}
+		var err error
+		if prunedHeight, err = d.connectSpentTxs(wb, block, txAddressesMap); err != nil {
+			return err
+		}
 		if err := d.storeAndCleanupBlockTxs(wb, block); err != nil {
 			return err
 		}
@@ -479,7 +534,15 @@ func (d *RocksDB) ConnectBlock(block *bchain.Block) error {
 	if err := d.db.Write(d.wo, wb); err != nil {
 		return err
 	}
-	d.is.AppendBlockTime(uint32(block.Time))
+	if prunedHeight > 0 {
+		d.is.SetPrunedHeight(prunedHeight)
+		if block.Height%txCachePruneInterval == 0 {
+			// the transactions cache is not part of the index, do not fail the block because of it
+			if err := d.pruneTxCache(block.Height); err != nil {
+				glog.Error("rocksdb: pruneTxCache error ", err)
+			}
+		}
+	}
 	return nil
 }
 
@@ -543,8 +606,14 @@ type AddrBalance struct {
 	Txs        uint32
 	SentSat    big.Int
 	BalanceSat big.Int
-	Utxos      []Utxo
-	utxosMap   map[string]int
+	// FirstHeight and LastHeight are the heights of the first and the last block with a transaction of the address
+	FirstHeight uint32
+	LastHeight  uint32
+	Utxos       []Utxo
+	utxosMap    map[string]int
+	// storedBalanceSat is the balance stored in the db when the AddrBalance was loaded for the update, nil if it is not stored,
+	// storeBalances moves the address in the rich list from it
+	storedBalanceSat *big.Int
 }
 
 // ReceivedSat computes received amount from total balance and sent amount
@@ -630,7 +699,7 @@ func (d *RocksDB) GetAndResetConnectBlockStats() string {
 	return s
 }
 
-func (d *RocksDB) processAddressesBitcoinType(block *bchain.Block, addresses addressesMap, txAddressesMap map[string]*TxAddresses, balances map[string]*AddrBalance) error {
+func (d *RocksDB) processAddressesBitcoinType(block *bchain.Block, addresses addressesMap, txAddressesMap map[string]*TxAddresses, balances map[string]*AddrBalance, spentOutpoints map[string]*spendingInput) error {
 	blockTxIDs := make([][]byte, len(block.Txs))
 	blockTxAddresses := make([]*TxAddresses, len(block.Txs))
 	// first process all outputs so that inputs can refer to txs in this block
@@ -662,31 +731,37 @@ func (d *RocksDB) processAddressesBitcoinType(block *bchain.Block, addresses add
 			}
 			tao.AddrDesc = addrDesc
 			if d.chainParser.IsAddrDescIndexable(addrDesc) {
-				strAddrDesc := string(addrDesc)
-				balance, e := balances[strAddrDesc]
-				if !e {
-					balance, err = d.GetAddrDescBalance(addrDesc, addressBalanceDetailUTXOIndexed)
-					if err != nil {
-						return err
+				for _, balanceAddrDesc := range d.balanceAddrDescs(addrDesc) {
+					if balanceAddrDesc == nil {
+						continue
 					}
-					if balance == nil {
-						balance = &AddrBalance{}
+					strAddrDesc := string(balanceAddrDesc)
+					balance, e := balances[strAddrDesc]
+					if !e {
+						balance, err = d.getAddrDescBalanceForUpdate(balanceAddrDesc, addressBalanceDetailUTXOIndexed)
+						if err != nil {
+							return err
+						}
+						if balance == nil {
+							balance = &AddrBalance{}
+						}
+						balances[strAddrDesc] = balance
+						d.cbs.balancesMiss++
+					} else {
+						d.cbs.balancesHit++
+					}
+					balance.setActivity(block.Height)
+					balance.BalanceSat.Add(&balance.BalanceSat, &output.ValueSat)
+					balance.addUtxo(&Utxo{
+						BtxID:    btxID,
+						Vout:     int32(i),
+						Height:   block.Height,
+						ValueSat: output.ValueSat,
+					})
+					counted := addToAddressesMap(addresses, strAddrDesc, btxID, int32(i))
+					if !counted {
+						balance.Txs++
 					}
-					balances[strAddrDesc] = balance
-					d.cbs.balancesMiss++
-				} else {
-					d.cbs.balancesHit++
-				}
-				balance.BalanceSat.Add(&balance.BalanceSat, &output.ValueSat)
-				balance.addUtxo(&Utxo{
-					BtxID:    btxID,
-					Vout:     int32(i),
-					Height:   block.Height,
-					ValueSat: output.ValueSat,
-				})
-				counted := addToAddressesMap(addresses, strAddrDesc, btxID, int32(i))
-				if !counted {
-					balance.Txs++
 				}
 			}
 		}
@@ -737,6 +812,11 @@ func (d *RocksDB) processAddressesBitcoinType(block *bchain.Block, addresses add
 			tai.ValueSat = spentOutput.ValueSat
 			// mark the output as spent in tx
 			spentOutput.Spent = true
+			addSpentOutpoint(spentOutpoints, btxID, int32(input.Vout), &spendingInput{
+				btxID:  spendingTxid,
+				index:  int32(i),
+				height: block.Height,
+			})
 			if len(spentOutput.AddrDesc) == 0 {
 				if !logged {
 					glog.V(1).Infof("rocksdb: height %d, tx %v, input tx %v vout %v skipping empty address", block.Height, tx.Txid, input.Txid, input.Vout)
@@ -745,37 +825,54 @@ func (d *RocksDB) processAddressesBitcoinType(block *bchain.Block, addresses add
 				continue
 			}
 			if d.chainParser.IsAddrDescIndexable(spentOutput.AddrDesc) {
-				strAddrDesc := string(spentOutput.AddrDesc)
-				balance, e := balances[strAddrDesc]
-				if !e {
-					balance, err = d.GetAddrDescBalance(spentOutput.AddrDesc, addressBalanceDetailUTXOIndexed)
-					if err != nil {
-						return err
+				for _, balanceAddrDesc := range d.balanceAddrDescs(spentOutput.AddrDesc) {
+					if balanceAddrDesc == nil {
+						continue
 					}
-					if balance == nil {
-						balance = &AddrBalance{}
+					strAddrDesc := string(balanceAddrDesc)
+					balance, e := balances[strAddrDesc]
+					if !e {
+						balance, err = d.getAddrDescBalanceForUpdate(balanceAddrDesc, addressBalanceDetailUTXOIndexed)
+						if err != nil {
+							return err
+						}
+						if balance == nil {
+							balance = &AddrBalance{}
+						}
+						balances[strAddrDesc] = balance
+						d.cbs.balancesMiss++
+					} else {
+						d.cbs.balancesHit++
 					}
-					balances[strAddrDesc] = balance
-					d.cbs.balancesMiss++
-				} else {
-					d.cbs.balancesHit++
-				}
-				counted := addToAddressesMap(addresses, strAddrDesc, spendingTxid, ^int32(i))
-				if !counted {
-					balance.Txs++
-				}
-				balance.BalanceSat.Sub(&balance.BalanceSat, &spentOutput.ValueSat)
-				balance.markUtxoAsSpent(btxID, int32(input.Vout))
-				if balance.BalanceSat.Sign() < 0 {
-					d.resetValueSatToZero(&balance.BalanceSat, spentOutput.AddrDesc, "balance")
+					balance.setActivity(block.Height)
+					counted := addToAddressesMap(addresses, strAddrDesc, spendingTxid, ^int32(i))
+					if !counted {
+						balance.Txs++
+					}
+					balance.BalanceSat.Sub(&balance.BalanceSat, &spentOutput.ValueSat)
+					balance.markUtxoAsSpent(btxID, int32(input.Vout))
+					if balance.BalanceSat.Sign() < 0 {
+						d.resetValueSatToZero(&balance.BalanceSat, balanceAddrDesc, "balance")
+					}
+					balance.SentSat.Add(&balance.SentSat, &spentOutput.ValueSat)
 				}
-				balance.SentSat.Add(&balance.SentSat, &spentOutput.ValueSat)
 			}
 		}
 	}
 	return nil
 }
 
+// balanceAddrDescs returns address descriptors to which the value of an output with addrDesc is attributed
+// the value of cold staking outputs is attributed to the owner and to the staker (as delegated value),
+// otherwise it is attributed to the addrDesc itself and the second returned item is nil
+func (d *RocksDB) balanceAddrDescs(addrDesc bchain.AddressDescriptor) [2]bchain.AddressDescriptor {
+	owner, staker := d.chainParser.GetColdStakingAddrDescs(addrDesc)
+	if owner == nil {
+		return [2]bchain.AddressDescriptor{addrDesc, nil}
+	}
+	return [2]bchain.AddressDescriptor{owner, staker}
+}
+
 // addToAddressesMap maintains mapping between addresses and transactions in one block
 // the method assumes that outpus in the block are processed before the inputs
 // the return value is true if the tx was processed before, to not to count the tx multiple times
@@ -826,8 +923,14 @@ func (d *RocksDB) storeBalances(wb *gorocksdb.WriteBatch, abm map[string]*AddrBa
 	for addrDesc, ab := range abm {
 		// balance with 0 transactions is removed from db - happens on disconnect
 		if ab == nil || ab.Txs <= 0 {
+			if ab != nil {
+				d.updateRichList(wb, bchain.AddressDescriptor(addrDesc), ab.storedBalanceSat, nil)
+				ab.storedBalanceSat = nil
+			}
 			wb.DeleteCF(d.cfh[cfAddressBalance], bchain.AddressDescriptor(addrDesc))
 		} else {
+			d.updateRichList(wb, bchain.AddressDescriptor(addrDesc), ab.storedBalanceSat, &ab.BalanceSat)
+			ab.storedBalanceSat = new(big.Int).Set(&ab.BalanceSat)
 			buf = packAddrBalance(ab, buf, varBuf)
 			wb.PutCF(d.cfh[cfAddressBalance], bchain.AddressDescriptor(addrDesc), buf)
 		}
@@ -922,6 +1025,16 @@ func (d *RocksDB) getBlockTxs(height uint32) ([]blockTxs, error) {
 	return bt, nil
 }
 
+// getAddrDescBalanceForUpdate returns AddrBalance for given addrDesc like GetAddrDescBalance,
+// the returned AddrBalance remembers the stored balance so that storeBalances does not have to read it again
+func (d *RocksDB) getAddrDescBalanceForUpdate(addrDesc bchain.AddressDescriptor, detail AddressBalanceDetail) (*AddrBalance, error) {
+	ab, err := d.GetAddrDescBalance(addrDesc, detail)
+	if ab != nil {
+		ab.storedBalanceSat = new(big.Int).Set(&ab.BalanceSat)
+	}
+	return ab, err
+}
+
 // GetAddrDescBalance returns AddrBalance for given addrDesc
 func (d *RocksDB) GetAddrDescBalance(addrDesc bchain.AddressDescriptor, detail AddressBalanceDetail) (*AddrBalance, error) {
 	val, err := d.db.GetCF(d.ro, d.cfh[cfAddressBalance], addrDesc)
@@ -1033,10 +1146,15 @@ func unpackAddrBalance(buf []byte, txidUnpackedLen int, detail AddressBalanceDet
 	sentSat, sl := unpackBigint(buf[l:])
 	balanceSat, bl := unpackBigint(buf[l+sl:])
 	l = l + sl + bl
+	firstHeight, fl := unpackVaruint(buf[l:])
+	lastHeight, ll := unpackVaruint(buf[l+fl:])
+	l = l + fl + ll
 	ab := &AddrBalance{
-		Txs:        uint32(txs),
-		SentSat:    sentSat,
-		BalanceSat: balanceSat,
+		Txs:         uint32(txs),
+		SentSat:     sentSat,
+		BalanceSat:  balanceSat,
+		FirstHeight: uint32(firstHeight),
+		LastHeight:  uint32(lastHeight),
 	}
 	if detail != AddressBalanceDetailNoUTXO {
 		// estimate the size of utxos to avoid reallocation
@@ -1075,6 +1193,10 @@ func packAddrBalance(ab *AddrBalance, buf, varBuf []byte) []byte {
 	buf = append(buf, varBuf[:l]...)
 	l = packBigint(&ab.BalanceSat, varBuf)
 	buf = append(buf, varBuf[:l]...)
+	l = packVaruint(uint(ab.FirstHeight), varBuf)
+	buf = append(buf, varBuf[:l]...)
+	l = packVaruint(uint(ab.LastHeight), varBuf)
+	buf = append(buf, varBuf[:l]...)
 	for _, utxo := range ab.Utxos {
 		// if Vout < 0, utxo is marked as spent
 		if utxo.Vout >= 0 {
@@ -1294,9 +1416,13 @@ func (d *RocksDB) writeHeight(wb *gorocksdb.WriteBatch, height uint32, bi *Block
 			return err
 		}
 		wb.PutCF(d.cfh[cfHeight], key, val)
+		if err := d.storeBlockTime(wb, height, uint32(bi.Time)); err != nil {
+			return err
+		}
 		d.is.UpdateBestHeight(height)
 	case opDelete:
 		wb.DeleteCF(d.cfh[cfHeight], key)
+		d.disconnectBlockTimes(wb, height)
 		d.is.UpdateBestHeight(height - 1)
 	}
 	return nil
@@ -1314,7 +1440,7 @@ func (d *RocksDB) disconnectTxAddresses(wb *gorocksdb.WriteBatch, height uint32,
 		s := string(addrDesc)
 		b, fb := balances[s]
 		if !fb {
-			b, err = d.GetAddrDescBalance(addrDesc, addressBalanceDetailUTXOIndexed)
+			b, err = d.getAddrDescBalanceForUpdate(addrDesc, addressBalanceDetailUTXOIndexed)
 			if err != nil {
 				return nil, err
 			}
@@ -1325,12 +1451,7 @@ func (d *RocksDB) disconnectTxAddresses(wb *gorocksdb.WriteBatch, height uint32,
 	for i, t := range txa.Inputs {
 		if len(t.AddrDesc) > 0 {
 			input := &inputs[i]
-			s := string(t.AddrDesc)
-			_, exist := addresses[s]
-			if !exist {
-				addresses[s] = struct{}{}
-			}
-			s = string(input.btxID)
+			s := string(input.btxID)
 			sa, found := txAddressesToUpdate[s]
 			if !found {
 				sa, err = d.getTxAddresses(input.btxID)
@@ -1346,59 +1467,74 @@ func (d *RocksDB) disconnectTxAddresses(wb *gorocksdb.WriteBatch, height uint32,
 				sa.Outputs[input.index].Spent = false
 				inputHeight = sa.Height
 			}
-			if d.chainParser.IsAddrDescIndexable(t.AddrDesc) {
-				balance, err = getAddressBalance(t.AddrDesc)
-				if err != nil {
-					return err
+			for _, balanceAddrDesc := range d.balanceAddrDescs(t.AddrDesc) {
+				if balanceAddrDesc == nil {
+					continue
 				}
-				if balance != nil {
-					// subtract number of txs only once
-					if !exist {
-						balance.Txs--
+				s := string(balanceAddrDesc)
+				_, exist := addresses[s]
+				if !exist {
+					addresses[s] = struct{}{}
+				}
+				if d.chainParser.IsAddrDescIndexable(t.AddrDesc) {
+					balance, err = getAddressBalance(balanceAddrDesc)
+					if err != nil {
+						return err
 					}
-					balance.SentSat.Sub(&balance.SentSat, &t.ValueSat)
-					if balance.SentSat.Sign() < 0 {
-						d.resetValueSatToZero(&balance.SentSat, t.AddrDesc, "sent amount")
+					if balance != nil {
+						// subtract number of txs only once
+						if !exist {
+							balance.Txs--
+						}
+						balance.SentSat.Sub(&balance.SentSat, &t.ValueSat)
+						if balance.SentSat.Sign() < 0 {
+							d.resetValueSatToZero(&balance.SentSat, balanceAddrDesc, "sent amount")
+						}
+						balance.BalanceSat.Add(&balance.BalanceSat, &t.ValueSat)
+						balance.Utxos = append(balance.Utxos, Utxo{
+							BtxID:    input.btxID,
+							Vout:     input.index,
+							Height:   inputHeight,
+							ValueSat: t.ValueSat,
+						})
+					} else {
+						ad, _, _ := d.chainParser.GetAddressesFromAddrDesc(balanceAddrDesc)
+						glog.Warningf("Balance for address %s (%s) not found", ad, balanceAddrDesc)
 					}
-					balance.BalanceSat.Add(&balance.BalanceSat, &t.ValueSat)
-					balance.Utxos = append(balance.Utxos, Utxo{
-						BtxID:    input.btxID,
-						Vout:     input.index,
-						Height:   inputHeight,
-						ValueSat: t.ValueSat,
-					})
-				} else {
-					ad, _, _ := d.chainParser.GetAddressesFromAddrDesc(t.AddrDesc)
-					glog.Warningf("Balance for address %s (%s) not found", ad, t.AddrDesc)
 				}
 			}
 		}
 	}
 	for i, t := range txa.Outputs {
 		if len(t.AddrDesc) > 0 {
-			s := string(t.AddrDesc)
-			_, exist := addresses[s]
-			if !exist {
-				addresses[s] = struct{}{}
-			}
-			if d.chainParser.IsAddrDescIndexable(t.AddrDesc) {
-				balance, err := getAddressBalance(t.AddrDesc)
-				if err != nil {
-					return err
+			for _, balanceAddrDesc := range d.balanceAddrDescs(t.AddrDesc) {
+				if balanceAddrDesc == nil {
+					continue
 				}
-				if balance != nil {
-					// subtract number of txs only once
-					if !exist {
-						balance.Txs--
+				s := string(balanceAddrDesc)
+				_, exist := addresses[s]
+				if !exist {
+					addresses[s] = struct{}{}
+				}
+				if d.chainParser.IsAddrDescIndexable(t.AddrDesc) {
+					balance, err := getAddressBalance(balanceAddrDesc)
+					if err != nil {
+						return err
 					}
-					balance.BalanceSat.Sub(&balance.BalanceSat, &t.ValueSat)
-					if balance.BalanceSat.Sign() < 0 {
-						d.resetValueSatToZero(&balance.BalanceSat, t.AddrDesc, "balance")
+					if balance != nil {
+						// subtract number of txs only once
+						if !exist {
+							balance.Txs--
+						}
+						balance.BalanceSat.Sub(&balance.BalanceSat, &t.ValueSat)
+						if balance.BalanceSat.Sign() < 0 {
+							d.resetValueSatToZero(&balance.BalanceSat, balanceAddrDesc, "balance")
+						}
+						balance.markUtxoAsSpent(btxID, int32(i))
+					} else {
+						ad, _, _ := d.chainParser.GetAddressesFromAddrDesc(balanceAddrDesc)
+						glog.Warningf("Balance for address %s (%s) not found", ad, balanceAddrDesc)
 					}
-					balance.markUtxoAsSpent(btxID, int32(i))
-				} else {
-					ad, _, _ := d.chainParser.GetAddressesFromAddrDesc(t.AddrDesc)
-					glog.Warningf("Balance for address %s (%s) not found", ad, t.AddrDesc)
 				}
 			}
 		}
@@ -1429,6 +1565,7 @@ func (d *RocksDB) DisconnectBlockRangeBitcoinType(lower uint32, higher uint32) e
 	txAddressesToUpdate := make(map[string]*TxAddresses)
 	txsToDelete := make(map[string]struct{})
 	balances := make(map[string]*AddrBalance)
+	stakingRewards := make(map[string]*StakingRewards)
 	for height := higher; height >= lower; height-- {
 		blockTxs := blocks[height-lower]
 		glog.Info("Disconnecting block ", height, " containing ", len(blockTxs), " transactions")
@@ -1451,13 +1588,30 @@ func (d *RocksDB) DisconnectBlockRangeBitcoinType(lower uint32, higher uint32) e
 			if err := d.disconnectTxAddresses(wb, height, btxID, blockTxs[i].inputs, txa, txAddressesToUpdate, balances); err != nil {
 				return err
 			}
+			d.disconnectSpentOutpoints(wb, blockTxs[i].inputs)
+			d.disconnectOpReturns(wb, height, btxID, txa)
+			if err := d.disconnectStakingRewards(txa, stakingRewards); err != nil {
+				return err
+			}
+			if i < 2 {
+				d.disconnectMasternodePayments(wb, height, txa)
+			}
 		}
 		key := packUint(height)
 		wb.DeleteCF(d.cfh[cfBlockTxs], key)
 		wb.DeleteCF(d.cfh[cfHeight], key)
-	}
+		d.disconnectZerocoinPool(wb, height)
+		d.disconnectSupply(wb, height)
+		d.disconnectBlockStaker(wb, height)
+		d.disconnectSpentTxs(wb, height)
+		d.disconnectBlockFilter(wb, height)
+	}
+	d.disconnectSuperblockBudgets(wb, lower)
+	d.disconnectBlockTimes(wb, lower)
+	d.disconnectAddrActivity(balances, lower)
 	d.storeTxAddresses(wb, txAddressesToUpdate)
 	d.storeBalancesDisconnect(wb, balances)
+	d.storeStakingRewards(wb, stakingRewards)
 	for s := range txsToDelete {
 		b := []byte(s)
 		wb.DeleteCF(d.cfh[cfTransactions], b)
@@ -1465,7 +1619,6 @@ func (d *RocksDB) DisconnectBlockRangeBitcoinType(lower uint32, higher uint32) e
 	}
 	err := d.db.Write(d.wo, wb)
 	if err == nil {
-		d.is.RemoveLastBlockTimes(int(higher-lower) + 1)
 		glog.Infof("rocksdb: blocks %d-%d disconnected", lower, higher)
 	}
 	return err
@@ -1580,32 +1733,6 @@ func (d *RocksDB) internalDeleteTx(wb *gorocksdb.WriteBatch, key []byte) {
 // internal state
 const internalStateKey = "internalState"
 
-func (d *RocksDB) loadBlockTimes() ([]uint32, error) {
-	var times []uint32
-	it := d.db.NewIteratorCF(d.ro, d.cfh[cfHeight])
-	defer it.Close()
-	counter := uint32(0)
-	time := uint32(0)
-	for it.SeekToFirst(); it.Valid(); it.Next() {
-		height := unpackUint(it.Key().Data())
-		if height > counter {
-			glog.Warning("gap in cfHeight: expecting ", counter, ", got ", height)
-			for ; counter < height; counter++ {
-				times = append(times, time)
-			}
-		}
-		counter++
-		info, err := d.unpackBlockInfo(it.Value().Data())
-		if err != nil {
-			return nil, err
-		}
-		time = uint32(info.Time)
-		times = append(times, time)
-	}
-	glog.Info("loaded ", len(times), " block times")
-	return times, nil
-}
-
 // LoadInternalState loads from db internal state or initializes a new one if not yet stored
 func (d *RocksDB) LoadInternalState(rpcCoin string) (*common.InternalState, error) {
 	val, err := d.db.GetCF(d.ro, d.cfh[cfDefault], []byte(internalStateKey))
@@ -1632,16 +1759,27 @@ func (d *RocksDB) LoadInternalState(rpcCoin string) (*common.InternalState, erro
 	}
 	// make sure that column stats match the columns
 	sc := is.DbColumns
+	// the column missing in an existing database is created empty, it has the version of the database
+	// so that the migration builds its content
+	version := uint32(dbVersion)
+	for j := range sc {
+		if sc[j].Version < version {
+			version = sc[j].Version
+		}
+	}
 	nc := make([]common.InternalStateColumn, len(cfNames))
 	for i := 0; i < len(nc); i++ {
 		nc[i].Name = cfNames[i]
-		nc[i].Version = dbVersion
+		nc[i].Version = version
 		for j := 0; j < len(sc); j++ {
 			if sc[j].Name == nc[i].Name {
-				// check the version of the column, if it does not match, the db is not compatible
+				// check the version of the column, if it does not match, the db is not compatible unless it can be migrated
 				if sc[j].Version != dbVersion {
-					return nil, errors.Errorf("DB version %v of column '%v' does not match the required version %v. DB is not compatible.", sc[j].Version, sc[j].Name, dbVersion)
+					if !canMigrate(sc[j].Version) {
+						return nil, errors.Errorf("DB version %v of column '%v' does not match the required version %v. DB is not compatible.", sc[j].Version, sc[j].Name, dbVersion)
+					}
 				}
+				nc[i].Version = sc[j].Version
 				nc[i].Rows = sc[j].Rows
 				nc[i].KeyBytes = sc[j].KeyBytes
 				nc[i].ValueBytes = sc[j].ValueBytes
@@ -1651,7 +1789,7 @@ func (d *RocksDB) LoadInternalState(rpcCoin string) (*common.InternalState, erro
 		}
 	}
 	is.DbColumns = nc
-	is.BlockTimes, err = d.loadBlockTimes()
+	is.PrunedHeight, err = d.loadPrunedHeight()
 	if err != nil {
 		return nil, err
 	}
@@ -167,9 +167,7 @@ func TestRocksDB_Index_EthereumType(t *testing.T) {
 	})
 	defer closeAndDestroyRocksDB(t, d)
 
-	if len(d.is.BlockTimes) != 0 {
-		t.Fatal("Expecting is.BlockTimes 0, got ", len(d.is.BlockTimes))
-	}
+	verifyBlockTime(t, d, 4321000, 0)
 
 	// connect 1st block
 	block1 := dbtestdata.GetTestEthereumTypeBlock1(d.chainParser)
@@ -178,9 +176,8 @@ func TestRocksDB_Index_EthereumType(t *testing.T) {
 	}
 	verifyAfterEthereumTypeBlock1(t, d, false)
 
-	if len(d.is.BlockTimes) != 1 {
-		t.Fatal("Expecting is.BlockTimes 1, got ", len(d.is.BlockTimes))
-	}
+	verifyBlockTime(t, d, 4321000, block1.Time)
+	verifyBlockTime(t, d, 4321001, 0)
 
 	// connect 2nd block
 	block2 := dbtestdata.GetTestEthereumTypeBlock2(d.chainParser)
@@ -189,9 +186,7 @@ func TestRocksDB_Index_EthereumType(t *testing.T) {
 	}
 	verifyAfterEthereumTypeBlock2(t, d)
 
-	if len(d.is.BlockTimes) != 2 {
-		t.Fatal("Expecting is.BlockTimes 2, got ", len(d.is.BlockTimes))
-	}
+	verifyBlockTime(t, d, 4321001, block2.Time)
 
 	// get transactions for various addresses / low-high ranges
 	verifyGetTransactions(t, d, "0x"+dbtestdata.EthAddr55, 0, 10000000, []txidIndex{
@@ -287,9 +282,8 @@ func TestRocksDB_Index_EthereumType(t *testing.T) {
 		}
 	}
 
-	if len(d.is.BlockTimes) != 1 {
-		t.Fatal("Expecting is.BlockTimes 1, got ", len(d.is.BlockTimes))
-	}
+	verifyBlockTime(t, d, 4321000, block1.Time)
+	verifyBlockTime(t, d, 4321001, 0)
 
 	// connect block again and verify the state of db
 	if err := d.ConnectBlock(block2); err != nil {
@@ -297,8 +291,6 @@ func TestRocksDB_Index_EthereumType(t *testing.T) {
 	}
 	verifyAfterEthereumTypeBlock2(t, d)
 
-	if len(d.is.BlockTimes) != 2 {
-		t.Fatal("Expecting is.BlockTimes 2, got ", len(d.is.BlockTimes))
-	}
+	verifyBlockTime(t, d, 4321001, block2.Time)
 
 }
@@ -0,0 +1,603 @@
+// +build unittest
+
+package db
+
+import (
+	"blockbook/bchain"
+	"blockbook/bchain/coins/btc"
+	"blockbook/bchain/coins/pivx"
+	"blockbook/tests/dbtestdata"
+	"math/big"
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/juju/errors"
+	"github.com/tecbot/gorocksdb"
+)
+
+func pivxTestnetParser() *pivx.PivXParser {
+	return pivx.NewPivXParser(pivx.GetChainParams("test"), &btc.Configuration{BlockAddressesToKeep: 4})
+}
+
+func stakingRewardsHex(stakes uint, reward *big.Int) string {
+	return varuintToHex(stakes) + bigintToHex(reward)
+}
+
+func connectPivxBlocks(t *testing.T, d *RocksDB, blocks ...*bchain.Block) {
+	for _, b := range blocks {
+		if err := d.ConnectBlock(b); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func Test_StakingRewards_PivX(t *testing.T) {
+	d := setupRocksDB(t, pivxTestnetParser())
+	defer closeAndDestroyRocksDB(t, d)
+	connectPivxBlocks(t, d,
+		dbtestdata.GetTestPivxBlock1(d.chainParser),
+		dbtestdata.GetTestPivxBlock2(d.chainParser),
+		dbtestdata.GetTestPivxBlock3(d.chainParser),
+	)
+
+	// the reward of the split coinstake goes to the staker, the reward of the cold stake only to the owner,
+	// the masternode payments are not part of the rewards
+	if err := checkColumn(d, cfStakingRewards, []keyPair{
+		{dbtestdata.PivxScriptA, stakingRewardsHex(1, dbtestdata.PivxSatReward), nil},
+		{dbtestdata.PivxScriptOwner, stakingRewardsHex(1, dbtestdata.PivxSatReward), nil},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	// rollback removes the rewards of the disconnected coinstakes
+	if err := d.DisconnectBlockRangeBitcoinType(1002, 1002); err != nil {
+		t.Fatal(err)
+	}
+	if err := checkColumn(d, cfStakingRewards, []keyPair{
+		{dbtestdata.PivxScriptA, stakingRewardsHex(1, dbtestdata.PivxSatReward), nil},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.DisconnectBlockRangeBitcoinType(1001, 1001); err != nil {
+		t.Fatal(err)
+	}
+	if err := checkColumn(d, cfStakingRewards, []keyPair{}); err != nil {
+		t.Fatal(err)
+	}
+
+	// connecting the blocks again stores the same rewards
+	connectPivxBlocks(t, d,
+		dbtestdata.GetTestPivxBlock2(d.chainParser),
+		dbtestdata.GetTestPivxBlock3(d.chainParser),
+	)
+	if err := checkColumn(d, cfStakingRewards, []keyPair{
+		{dbtestdata.PivxScriptA, stakingRewardsHex(1, dbtestdata.PivxSatReward), nil},
+		{dbtestdata.PivxScriptOwner, stakingRewardsHex(1, dbtestdata.PivxSatReward), nil},
+	}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func Test_MasternodePayments_PivX(t *testing.T) {
+	d := setupRocksDB(t, pivxTestnetParser())
+	defer closeAndDestroyRocksDB(t, d)
+	connectPivxBlocks(t, d,
+		dbtestdata.GetTestPivxBlock1(d.chainParser),
+		dbtestdata.GetTestPivxBlock2(d.chainParser),
+		dbtestdata.GetTestPivxBlock3(d.chainParser),
+		dbtestdata.GetTestPivxBlock4(d.chainParser),
+	)
+
+	// the block 2 pays the masternode after the split coinstake, the block 3 after the cold stake
+	// the block 4 has only one staker output and no masternode payment, the block 1 does not have coinbase or coinstake
+	wantPayments := []keyPair{
+		{dbtestdata.PivxScriptMN1 + uintToHex(^uint32(1001)), bigintToHex(dbtestdata.PivxSatB2T2MN1), nil},
+		{dbtestdata.PivxScriptMN2 + uintToHex(^uint32(1002)), bigintToHex(dbtestdata.PivxSatB3T2MN2), nil},
+	}
+	if err := checkColumn(d, cfMasternodePayments, wantPayments); err != nil {
+		t.Fatal(err)
+	}
+	if err := checkColumn(d, cfStakingRewards, []keyPair{
+		{dbtestdata.PivxScriptA, stakingRewardsHex(2, new(big.Int).Mul(dbtestdata.PivxSatReward, big.NewInt(2))), nil},
+		{dbtestdata.PivxScriptOwner, stakingRewardsHex(1, dbtestdata.PivxSatReward), nil},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	var heights []uint32
+	if err := d.GetAddrDescMasternodePayments(hexToBytes(dbtestdata.PivxScriptMN1), 0, 2000, func(height uint32, valueSat *big.Int) error {
+		if valueSat.Cmp(dbtestdata.PivxSatB2T2MN1) != 0 {
+			t.Errorf("GetAddrDescMasternodePayments() value = %v, want %v", valueSat, dbtestdata.PivxSatB2T2MN1)
+		}
+		heights = append(heights, height)
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if len(heights) != 1 || heights[0] != 1001 {
+		t.Errorf("GetAddrDescMasternodePayments() heights = %v, want [1001]", heights)
+	}
+
+	// the block without masternode payment does not change the payments on disconnect
+	if err := d.DisconnectBlockRangeBitcoinType(1003, 1003); err != nil {
+		t.Fatal(err)
+	}
+	if err := checkColumn(d, cfMasternodePayments, wantPayments); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.DisconnectBlockRangeBitcoinType(1002, 1002); err != nil {
+		t.Fatal(err)
+	}
+	if err := checkColumn(d, cfMasternodePayments, wantPayments[:1]); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func Test_MigrateBlocks_PivX(t *testing.T) {
+	d := setupRocksDB(t, pivxTestnetParser())
+	defer closeAndDestroyRocksDB(t, d)
+	testMigrateBlocks(t, d, []*bchain.Block{
+		dbtestdata.GetTestPivxBlock1(d.chainParser),
+		dbtestdata.GetTestPivxBlock2(d.chainParser),
+		dbtestdata.GetTestPivxBlock3(d.chainParser),
+		dbtestdata.GetTestPivxBlock4(d.chainParser),
+		dbtestdata.GetTestPivxBlock5(d.chainParser),
+	})
+}
+
+func checkAddrDescBalance(t *testing.T, d *RocksDB, addrDesc string, want *AddrBalance) {
+	ab, err := d.GetAddrDescBalance(hexToBytes(addrDesc), AddressBalanceDetailUTXO)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(ab, want) {
+		t.Errorf("GetAddrDescBalance(%v) = %+v, want %+v", addrDesc, ab, want)
+	}
+}
+
+func checkAddrDescTransactions(t *testing.T, d *RocksDB, addrDesc string, want []txidIndex) {
+	got := make([]txidIndex, 0)
+	if err := d.GetAddrDescTransactions(hexToBytes(addrDesc), 0, ^uint32(0), func(txid string, height uint32, indexes []int32) error {
+		for _, index := range indexes {
+			got = append(got, txidIndex{txid, index})
+		}
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("GetAddrDescTransactions(%v) = %v, want %v", addrDesc, got, want)
+	}
+}
+
+func Test_ColdStaking_PivX(t *testing.T) {
+	d := setupRocksDB(t, pivxTestnetParser())
+	defer closeAndDestroyRocksDB(t, d)
+	connectPivxBlocks(t, d,
+		dbtestdata.GetTestPivxBlock1(d.chainParser),
+		dbtestdata.GetTestPivxBlock2(d.chainParser),
+		dbtestdata.GetTestPivxBlock3(d.chainParser),
+	)
+
+	// the cold staking outputs are attributed to the owner and to the staker, not to the P2CS script
+	for _, addrDesc := range []string{dbtestdata.PivxScriptOwner, dbtestdata.PivxAddrDescStaker} {
+		checkAddrDescBalance(t, d, addrDesc, &AddrBalance{
+			Txs:         2,
+			SentSat:     *dbtestdata.PivxSatB1T2P2CS,
+			BalanceSat:  *dbtestdata.PivxSatB3T2P2CS,
+			FirstHeight: 1000,
+			LastHeight:  1002,
+			Utxos: []Utxo{
+				{
+					BtxID:    hexToBytes(dbtestdata.PivxTxidB3T2),
+					Vout:     1,
+					Height:   1002,
+					ValueSat: *dbtestdata.PivxSatB3T2P2CS,
+				},
+			},
+		})
+		checkAddrDescTransactions(t, d, addrDesc, []txidIndex{
+			{dbtestdata.PivxTxidB3T2, 1},
+			{dbtestdata.PivxTxidB3T2, ^0},
+			{dbtestdata.PivxTxidB1T2, 0},
+		})
+	}
+	checkAddrDescBalance(t, d, dbtestdata.PivxScriptP2CS, nil)
+	checkAddrDescTransactions(t, d, dbtestdata.PivxScriptP2CS, []txidIndex{})
+
+	// rollback of the cold stake returns the spent cold staking output to the owner and to the staker
+	if err := d.DisconnectBlockRangeBitcoinType(1002, 1002); err != nil {
+		t.Fatal(err)
+	}
+	for _, addrDesc := range []string{dbtestdata.PivxScriptOwner, dbtestdata.PivxAddrDescStaker} {
+		checkAddrDescBalance(t, d, addrDesc, &AddrBalance{
+			Txs:         1,
+			SentSat:     *big.NewInt(0),
+			BalanceSat:  *dbtestdata.PivxSatB1T2P2CS,
+			FirstHeight: 1000,
+			LastHeight:  1000,
+			Utxos: []Utxo{
+				{
+					BtxID:    hexToBytes(dbtestdata.PivxTxidB1T2),
+					Vout:     0,
+					Height:   1000,
+					ValueSat: *dbtestdata.PivxSatB1T2P2CS,
+				},
+			},
+		})
+		checkAddrDescTransactions(t, d, addrDesc, []txidIndex{
+			{dbtestdata.PivxTxidB1T2, 0},
+		})
+	}
+	checkAddrDescBalance(t, d, dbtestdata.PivxScriptP2CS, nil)
+}
+
+func Test_MigrateColdStaking_PivX(t *testing.T) {
+	d := setupRocksDB(t, pivxTestnetParser())
+	defer closeAndDestroyRocksDB(t, d)
+	connectPivxBlocks(t, d,
+		dbtestdata.GetTestPivxBlock1(d.chainParser),
+		dbtestdata.GetTestPivxBlock2(d.chainParser),
+		dbtestdata.GetTestPivxBlock3(d.chainParser),
+	)
+	converted := []int{cfAddresses, cfAddressBalance, cfRichList}
+	want := make(map[int][]keyPair)
+	for _, cf := range converted {
+		want[cf] = columnRows(t, d, cf)
+	}
+
+	// store the cold staking outputs under the P2CS script as the databases before version 6
+	p2cs := hexToBytes(dbtestdata.PivxScriptP2CS)
+	owner := hexToBytes(dbtestdata.PivxScriptOwner)
+	staker := hexToBytes(dbtestdata.PivxAddrDescStaker)
+	for _, height := range []uint32{1000, 1002} {
+		val, err := d.db.GetCF(d.ro, d.cfh[cfAddresses], packAddressKey(owner, height))
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = d.db.PutCF(d.wo, d.cfh[cfAddresses], packAddressKey(p2cs, height), val.Data())
+		val.Free()
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, addrDesc := range [][]byte{owner, staker} {
+			if err := d.db.DeleteCF(d.wo, d.cfh[cfAddresses], packAddressKey(addrDesc, height)); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	ab, err := d.GetAddrDescBalance(owner, AddressBalanceDetailUTXO)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wb := gorocksdb.NewWriteBatch()
+	defer wb.Destroy()
+	if err := d.storeBalances(wb, map[string]*AddrBalance{string(p2cs): ab, string(owner): nil, string(staker): nil}); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.db.Write(d.wo, wb); err != nil {
+		t.Fatal(err)
+	}
+	checkAddrDescBalance(t, d, dbtestdata.PivxScriptOwner, nil)
+	removeAddrActivity(t, d)
+
+	for i := range d.is.DbColumns {
+		d.is.DbColumns[i].Version = 5
+	}
+	if err := d.Migrate(&testBlocksChain{}, make(chan os.Signal, 1)); err != nil {
+		t.Fatal(err)
+	}
+	for _, cf := range converted {
+		if err := checkColumn(d, cf, want[cf]); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func Test_Zerocoin_PivX(t *testing.T) {
+	d := setupRocksDB(t, pivxTestnetParser())
+	defer closeAndDestroyRocksDB(t, d)
+	connectPivxBlocks(t, d,
+		dbtestdata.GetTestPivxBlock1(d.chainParser),
+		dbtestdata.GetTestPivxBlock2(d.chainParser),
+		dbtestdata.GetTestPivxBlock3(d.chainParser),
+		dbtestdata.GetTestPivxBlock4(d.chainParser),
+		dbtestdata.GetTestPivxBlock5(d.chainParser),
+	)
+
+	zp, err := d.GetZerocoinPool(1004)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantPool := &ZerocoinPool{
+		Height:        1004,
+		Denominations: []ZerocoinDenomination{{Denomination: dbtestdata.PivxSatZerocoin.Int64(), Mints: 1, Spends: 1}},
+	}
+	if !reflect.DeepEqual(zp, wantPool) {
+		t.Errorf("GetZerocoinPool() = %+v, want %+v", zp, wantPool)
+	}
+
+	// the minted value stays in the zerocoin pool, it is not burned, the spend does not issue new coins
+	s4, err := d.GetSupply(1003)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s5, err := d.GetSupply(1004)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s5.IssuedSat.Cmp(&s4.IssuedSat) != 0 || s5.BurnedSat.Sign() != 0 {
+		t.Errorf("GetSupply() = issued %v, burned %v, want issued %v, burned 0", &s5.IssuedSat, &s5.BurnedSat, &s4.IssuedSat)
+	}
+
+	// the zerocoin scripts are not indexed as addresses
+	for _, addrDesc := range []string{dbtestdata.PivxScriptZerocoinMint, dbtestdata.PivxScriptZerocoinSpend} {
+		checkAddrDescBalance(t, d, addrDesc, nil)
+		checkAddrDescTransactions(t, d, addrDesc, []txidIndex{})
+	}
+	checkAddrDescTransactions(t, d, dbtestdata.PivxScriptB, []txidIndex{
+		{dbtestdata.PivxTxidB5T2, 0},
+		{dbtestdata.PivxTxidB1T2, 1},
+	})
+
+	if err := d.DisconnectBlockRangeBitcoinType(1004, 1004); err != nil {
+		t.Fatal(err)
+	}
+	if zp, err = d.GetZerocoinPool(1004); err != nil {
+		t.Fatal(err)
+	}
+	if zp != nil {
+		t.Errorf("GetZerocoinPool() = %+v, want nil", zp)
+	}
+	checkAddrDescTransactions(t, d, dbtestdata.PivxScriptB, []txidIndex{
+		{dbtestdata.PivxTxidB1T2, 1},
+	})
+}
+
+func Test_MigrateZerocoin_PivX(t *testing.T) {
+	d := setupRocksDB(t, pivxTestnetParser())
+	defer closeAndDestroyRocksDB(t, d)
+	connectPivxBlocks(t, d,
+		dbtestdata.GetTestPivxBlock1(d.chainParser),
+		dbtestdata.GetTestPivxBlock2(d.chainParser),
+		dbtestdata.GetTestPivxBlock3(d.chainParser),
+		dbtestdata.GetTestPivxBlock4(d.chainParser),
+		dbtestdata.GetTestPivxBlock5(d.chainParser),
+	)
+	converted := []int{cfAddresses, cfAddressBalance, cfRichList}
+	want := make(map[int][]keyPair)
+	for _, cf := range converted {
+		want[cf] = columnRows(t, d, cf)
+	}
+
+	// store the zerocoin mint as a pseudo address as the databases before version 6
+	mint := hexToBytes(dbtestdata.PivxScriptZerocoinMint)
+	btxID, err := d.chainParser.PackTxid(dbtestdata.PivxTxidB5T1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wb := gorocksdb.NewWriteBatch()
+	defer wb.Destroy()
+	wb.PutCF(d.cfh[cfAddresses], packAddressKey(mint, 1004), d.packTxIndexes([]txIndexes{{btxID: btxID, indexes: []int32{0}}}))
+	if err := d.storeBalances(wb, map[string]*AddrBalance{string(mint): {
+		Txs:        1,
+		BalanceSat: *dbtestdata.PivxSatZerocoin,
+		Utxos:      []Utxo{{BtxID: btxID, Vout: 0, Height: 1004, ValueSat: *dbtestdata.PivxSatZerocoin}},
+	}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.db.Write(d.wo, wb); err != nil {
+		t.Fatal(err)
+	}
+	checkAddrDescTransactions(t, d, dbtestdata.PivxScriptZerocoinMint, []txidIndex{{dbtestdata.PivxTxidB5T1, 0}})
+	removeAddrActivity(t, d)
+
+	for i := range d.is.DbColumns {
+		d.is.DbColumns[i].Version = 5
+	}
+	if err := d.Migrate(&testBlocksChain{}, make(chan os.Signal, 1)); err != nil {
+		t.Fatal(err)
+	}
+	for _, cf := range converted {
+		if err := checkColumn(d, cf, want[cf]); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func Test_Supply_PivX(t *testing.T) {
+	d := setupRocksDB(t, pivxTestnetParser())
+	defer closeAndDestroyRocksDB(t, d)
+	connectPivxBlocks(t, d,
+		dbtestdata.GetTestPivxBlock1(d.chainParser),
+		dbtestdata.GetTestPivxBlock2(d.chainParser),
+		dbtestdata.GetTestPivxBlock3(d.chainParser),
+		dbtestdata.GetTestPivxBlock4(d.chainParser),
+		dbtestdata.GetTestPivxBlock5(d.chainParser),
+		dbtestdata.GetTestPivxBlock6(d.chainParser),
+	)
+
+	// 170 PIV issued by the block 1, the coinstake rewards and the masternode payments of the blocks 2, 3 and 4 are 4, 5 and 2 PIV,
+	// the zerocoin mint and spend in the block 5 and the shielding and unshielding in the block 6 do not change the supply
+	wantIssued := big.NewInt(18100000000)
+	for _, height := range []uint32{1003, 1004, 1005} {
+		s, err := d.GetSupply(height)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if s == nil || s.Height != height || s.IssuedSat.Cmp(wantIssued) != 0 || s.BurnedSat.Sign() != 0 {
+			t.Errorf("GetSupply(%v) = %+v, want issued %v, burned 0", height, s, wantIssued)
+		}
+	}
+
+	if err := d.DisconnectBlockRangeBitcoinType(1005, 1005); err != nil {
+		t.Fatal(err)
+	}
+	s, err := d.GetSupply(1005)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s == nil || s.Height != 1004 {
+		t.Errorf("GetSupply(1005) = %+v, want the supply at the height 1004", s)
+	}
+}
+
+// testBudgetChain returns the budget projection of the next superblock and the budget proposals known by the backend
+type testBudgetChain struct {
+	bchain.BlockChain
+	parser    bchain.BlockChainParser
+	proposals []bchain.BudgetProposal
+	budget    []bchain.BudgetProposal
+	err       error
+}
+
+func (c *testBudgetChain) GetChainParser() bchain.BlockChainParser {
+	return c.parser
+}
+
+func (c *testBudgetChain) GetBudgetProjection() ([]bchain.BudgetProposal, error) {
+	return c.proposals, c.err
+}
+
+func (c *testBudgetChain) GetBudgetProposals() ([]bchain.BudgetProposal, error) {
+	return c.budget, c.err
+}
+
+func testBudgetProposal(name string, payment int64) bchain.BudgetProposal {
+	return bchain.BudgetProposal{
+		Name:           name,
+		Hash:           dbtestdata.PivxTxidB1T1,
+		URL:            "https://forum.pivx.org/t/" + name,
+		PaymentAddress: "y4g7ZFQbJq4kDtMZfhWFNCHwaPC9DbpJWW",
+		Yeas:           10,
+		Nays:           1,
+		PaymentSat:     *big.NewInt(payment),
+	}
+}
+
+func checkSuperblockBudgets(t *testing.T, d *RocksDB, want []uint32) {
+	got := make([]uint32, 0)
+	if err := d.GetSuperblockBudgets(func(height uint32, proposals []bchain.BudgetProposal) error {
+		got = append(got, height)
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("GetSuperblockBudgets() heights = %v, want %v", got, want)
+	}
+}
+
+func Test_SuperblockBudget_PivX(t *testing.T) {
+	d := setupRocksDB(t, pivxTestnetParser())
+	defer closeAndDestroyRocksDB(t, d)
+	connectPivxBlocks(t, d,
+		dbtestdata.GetTestPivxBlock1(d.chainParser),
+		dbtestdata.GetTestPivxBlock2(d.chainParser),
+		dbtestdata.GetTestPivxBlock3(d.chainParser),
+		dbtestdata.GetTestPivxBlock4(d.chainParser),
+	)
+	chain := &testBudgetChain{parser: d.chainParser}
+	w := &SyncWorker{db: d, chain: chain}
+
+	// the projection is stored for the next superblock after the synchronized block, the testnet cycle is 144 blocks
+	chain.proposals = []bchain.BudgetProposal{testBudgetProposal("first", 1000000000)}
+	w.storeSuperblockBudget(863, 144)
+	// the last projection before the superblock replaces the previous ones
+	chain.proposals = []bchain.BudgetProposal{testBudgetProposal("first", 1000000000), testBudgetProposal("second", 2500000000)}
+	w.storeSuperblockBudget(1001, 144)
+	w.storeSuperblockBudget(1007, 144)
+	// the failed projection does not change the stored one
+	chain.err = errors.New("getbudgetprojection error")
+	w.storeSuperblockBudget(1007, 144)
+	chain.err = nil
+	proposals, err := d.GetSuperblockBudget(1008)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(proposals, chain.proposals) {
+		t.Errorf("GetSuperblockBudget(1008) = %+v, want %+v", proposals, chain.proposals)
+	}
+	if proposals, err = d.GetSuperblockBudget(1007); err != nil {
+		t.Fatal(err)
+	}
+	if proposals != nil {
+		t.Errorf("GetSuperblockBudget(1007) = %+v, want nil", proposals)
+	}
+	// the superblock 1002 is stored directly, it is connected and can be disconnected
+	if err := d.StoreSuperblockBudget(1002, chain.proposals[:1]); err != nil {
+		t.Fatal(err)
+	}
+	checkSuperblockBudgets(t, d, []uint32{1008, 1002, 864})
+
+	// rollback removes the projections of the disconnected superblocks and of the superblocks after them,
+	// the projection of the next superblock is stored again after the sync
+	if err := d.DisconnectBlockRangeBitcoinType(1003, 1003); err != nil {
+		t.Fatal(err)
+	}
+	checkSuperblockBudgets(t, d, []uint32{1002, 864})
+	if err := d.DisconnectBlockRangeBitcoinType(1002, 1002); err != nil {
+		t.Fatal(err)
+	}
+	checkSuperblockBudgets(t, d, []uint32{864})
+	if proposals, err = d.GetSuperblockBudget(864); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(proposals, chain.proposals[:1]) {
+		t.Errorf("GetSuperblockBudget(864) = %+v, want %+v", proposals, chain.proposals[:1])
+	}
+}
+
+func Test_SuperblockPayments_PivX(t *testing.T) {
+	d := setupRocksDB(t, pivxTestnetParser())
+	defer closeAndDestroyRocksDB(t, d)
+	connectPivxBlocks(t, d,
+		dbtestdata.GetTestPivxBlock1(d.chainParser),
+		dbtestdata.GetTestPivxBlock2(d.chainParser),
+		dbtestdata.GetTestPivxBlock3(d.chainParser),
+		dbtestdata.GetTestPivxBlock4(d.chainParser),
+	)
+	address := func(script string) string {
+		addresses, _, err := d.chainParser.GetAddressesFromAddrDesc(hexToBytes(script))
+		if err != nil || len(addresses) != 1 {
+			t.Fatalf("GetAddressesFromAddrDesc(%v) = %v, %v", script, addresses, err)
+		}
+		return addresses[0]
+	}
+	// the budget payments are indexed as the masternode payments in the blocks 1001 and 1002 of the cycle of the superblock 864
+	paid := testBudgetProposal("paid", 250000000)
+	paid.PaymentAddress = address(dbtestdata.PivxScriptMN1)
+	chain := &testBudgetChain{
+		parser:    d.chainParser,
+		proposals: []bchain.BudgetProposal{paid, testBudgetProposal("unpaid", 1000000000)},
+	}
+	w := &SyncWorker{db: d, chain: chain}
+	w.storeSuperblockBudget(863, 144)
+	w.storeSuperblockBudget(1003, 144)
+	checkPaid := func(want map[string]int64) {
+		t.Helper()
+		proposals, err := d.GetSuperblockBudget(864)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := make(map[string]int64)
+		for i := range proposals {
+			got[proposals[i].Name] = proposals[i].PaidSat.Int64()
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("GetSuperblockBudget(864) paid = %v, want %v", got, want)
+		}
+	}
+	checkPaid(map[string]int64{"paid": 200000000, "unpaid": 0})
+
+	// the proposals known by the backend are added to the past superblocks of their payment period
+	backfilled := testBudgetProposal("backfilled", 300000000)
+	backfilled.Hash = dbtestdata.PivxTxidB1T2
+	backfilled.PaymentAddress = address(dbtestdata.PivxScriptMN2)
+	backfilled.BlockStart = 800
+	backfilled.BlockEnd = 1009
+	chain.budget = []bchain.BudgetProposal{backfilled, paid}
+	w.backfillSuperblockBudgets(1003, 144)
+	checkPaid(map[string]int64{"paid": 200000000, "unpaid": 0, "backfilled": 300000000})
+	checkSuperblockBudgets(t, d, []uint32{1008, 864})
+}
@@ -7,10 +7,12 @@ import (
 	"blockbook/bchain/coins/btc"
 	"blockbook/common"
 	"blockbook/tests/dbtestdata"
+	"bytes"
 	"encoding/binary"
 	"encoding/hex"
 	"encoding/json"
 	"io/ioutil"
+	"math"
 	"math/big"
 	"os"
 	"reflect"
@@ -22,6 +24,7 @@ import (
 	vlq "github.com/bsm/go-vlq"
 	"github.com/juju/errors"
 	"github.com/martinboehm/btcutil/chaincfg"
+	"github.com/tecbot/gorocksdb"
 )
 
 // simplified explanation of signed varint packing, used in many index data structures
@@ -230,31 +233,31 @@ func verifyAfterBitcoinTypeBlock1(t *testing.T, d *RocksDB, afterDisconnect bool
 	if err := checkColumn(d, cfAddressBalance, []keyPair{
 		{
 			dbtestdata.AddressToPubKeyHex(dbtestdata.Addr1, d.chainParser),
-			"01" + bigintToHex(dbtestdata.SatZero) + bigintToHex(dbtestdata.SatB1T1A1) +
+			"01" + bigintToHex(dbtestdata.SatZero) + bigintToHex(dbtestdata.SatB1T1A1) + varuintToHex(225493) + varuintToHex(225493) +
 				dbtestdata.TxidB1T1 + varuintToHex(0) + varuintToHex(225493) + bigintToHex(dbtestdata.SatB1T1A1),
 			nil,
 		},
 		{
 			dbtestdata.AddressToPubKeyHex(dbtestdata.Addr2, d.chainParser),
-			"01" + bigintToHex(dbtestdata.SatZero) + bigintToHex(dbtestdata.SatB1T1A2) +
+			"01" + bigintToHex(dbtestdata.SatZero) + bigintToHex(dbtestdata.SatB1T1A2) + varuintToHex(225493) + varuintToHex(225493) +
 				dbtestdata.TxidB1T1 + varuintToHex(1) + varuintToHex(225493) + bigintToHex(dbtestdata.SatB1T1A2),
 			nil,
 		},
 		{
 			dbtestdata.AddressToPubKeyHex(dbtestdata.Addr3, d.chainParser),
-			"01" + bigintToHex(dbtestdata.SatZero) + bigintToHex(dbtestdata.SatB1T2A3) +
+			"01" + bigintToHex(dbtestdata.SatZero) + bigintToHex(dbtestdata.SatB1T2A3) + varuintToHex(225493) + varuintToHex(225493) +
 				dbtestdata.TxidB1T2 + varuintToHex(0) + varuintToHex(225493) + bigintToHex(dbtestdata.SatB1T2A3),
 			nil,
 		},
 		{
 			dbtestdata.AddressToPubKeyHex(dbtestdata.Addr4, d.chainParser),
-			"01" + bigintToHex(dbtestdata.SatZero) + bigintToHex(dbtestdata.SatB1T2A4) +
+			"01" + bigintToHex(dbtestdata.SatZero) + bigintToHex(dbtestdata.SatB1T2A4) + varuintToHex(225493) + varuintToHex(225493) +
 				dbtestdata.TxidB1T2 + varuintToHex(1) + varuintToHex(225493) + bigintToHex(dbtestdata.SatB1T2A4),
 			nil,
 		},
 		{
 			dbtestdata.AddressToPubKeyHex(dbtestdata.Addr5, d.chainParser),
-			"01" + bigintToHex(dbtestdata.SatZero) + bigintToHex(dbtestdata.SatB1T2A5) +
+			"01" + bigintToHex(dbtestdata.SatZero) + bigintToHex(dbtestdata.SatB1T2A5) + varuintToHex(225493) + varuintToHex(225493) +
 				dbtestdata.TxidB1T2 + varuintToHex(2) + varuintToHex(225493) + bigintToHex(dbtestdata.SatB1T2A5),
 			nil,
 		},
@@ -390,57 +393,57 @@ func verifyAfterBitcoinTypeBlock2(t *testing.T, d *RocksDB) {
 	if err := checkColumn(d, cfAddressBalance, []keyPair{
 		{
 			dbtestdata.AddressToPubKeyHex(dbtestdata.Addr1, d.chainParser),
-			"01" + bigintToHex(dbtestdata.SatZero) + bigintToHex(dbtestdata.SatB1T1A1) +
+			"01" + bigintToHex(dbtestdata.SatZero) + bigintToHex(dbtestdata.SatB1T1A1) + varuintToHex(225493) + varuintToHex(225493) +
 				dbtestdata.TxidB1T1 + varuintToHex(0) + varuintToHex(225493) + bigintToHex(dbtestdata.SatB1T1A1),
 			nil,
 		},
 		{
 			dbtestdata.AddressToPubKeyHex(dbtestdata.Addr2, d.chainParser),
-			"02" + bigintToHex(dbtestdata.SatB1T1A2) + bigintToHex(dbtestdata.SatZero),
+			"02" + bigintToHex(dbtestdata.SatB1T1A2) + bigintToHex(dbtestdata.SatZero) + varuintToHex(225493) + varuintToHex(225494),
 			nil,
 		},
 		{
 			dbtestdata.AddressToPubKeyHex(dbtestdata.Addr3, d.chainParser),
-			"02" + bigintToHex(dbtestdata.SatB1T2A3) + bigintToHex(dbtestdata.SatZero),
+			"02" + bigintToHex(dbtestdata.SatB1T2A3) + bigintToHex(dbtestdata.SatZero) + varuintToHex(225493) + varuintToHex(225494),
 			nil,
 		},
 		{
 			dbtestdata.AddressToPubKeyHex(dbtestdata.Addr4, d.chainParser),
-			"02" + bigintToHex(dbtestdata.SatB1T2A4) + bigintToHex(dbtestdata.SatZero),
+			"02" + bigintToHex(dbtestdata.SatB1T2A4) + bigintToHex(dbtestdata.SatZero) + varuintToHex(225493) + varuintToHex(225494),
 			nil,
 		},
 		{
 			dbtestdata.AddressToPubKeyHex(dbtestdata.Addr5, d.chainParser),
-			"02" + bigintToHex(dbtestdata.SatB1T2A5) + bigintToHex(dbtestdata.SatB2T3A5) +
+			"02" + bigintToHex(dbtestdata.SatB1T2A5) + bigintToHex(dbtestdata.SatB2T3A5) + varuintToHex(225493) + varuintToHex(225494) +
 				dbtestdata.TxidB2T3 + varuintToHex(0) + varuintToHex(225494) + bigintToHex(dbtestdata.SatB2T3A5),
 			nil,
 		},
 		{
 			dbtestdata.AddressToPubKeyHex(dbtestdata.Addr6, d.chainParser),
-			"02" + bigintToHex(dbtestdata.SatB2T1A6) + bigintToHex(dbtestdata.SatZero),
+			"02" + bigintToHex(dbtestdata.SatB2T1A6) + bigintToHex(dbtestdata.SatZero) + varuintToHex(225494) + varuintToHex(225494),
 			nil,
 		},
 		{
 			dbtestdata.AddressToPubKeyHex(dbtestdata.Addr7, d.chainParser),
-			"01" + bigintToHex(dbtestdata.SatZero) + bigintToHex(dbtestdata.SatB2T1A7) +
+			"01" + bigintToHex(dbtestdata.SatZero) + bigintToHex(dbtestdata.SatB2T1A7) + varuintToHex(225494) + varuintToHex(225494) +
 				dbtestdata.TxidB2T1 + varuintToHex(1) + varuintToHex(225494) + bigintToHex(dbtestdata.SatB2T1A7),
 			nil,
 		},
 		{
 			dbtestdata.AddressToPubKeyHex(dbtestdata.Addr8, d.chainParser),
-			"01" + bigintToHex(dbtestdata.SatZero) + bigintToHex(dbtestdata.SatB2T2A8) +
+			"01" + bigintToHex(dbtestdata.SatZero) + bigintToHex(dbtestdata.SatB2T2A8) + varuintToHex(225494) + varuintToHex(225494) +
 				dbtestdata.TxidB2T2 + varuintToHex(0) + varuintToHex(225494) + bigintToHex(dbtestdata.SatB2T2A8),
 			nil,
 		},
 		{
 			dbtestdata.AddressToPubKeyHex(dbtestdata.Addr9, d.chainParser),
-			"01" + bigintToHex(dbtestdata.SatZero) + bigintToHex(dbtestdata.SatB2T2A9) +
+			"01" + bigintToHex(dbtestdata.SatZero) + bigintToHex(dbtestdata.SatB2T2A9) + varuintToHex(225494) + varuintToHex(225494) +
 				dbtestdata.TxidB2T2 + varuintToHex(1) + varuintToHex(225494) + bigintToHex(dbtestdata.SatB2T2A9),
 			nil,
 		},
 		{
 			dbtestdata.AddressToPubKeyHex(dbtestdata.AddrA, d.chainParser),
-			"01" + bigintToHex(dbtestdata.SatZero) + bigintToHex(dbtestdata.SatB2T4AA) +
+			"01" + bigintToHex(dbtestdata.SatZero) + bigintToHex(dbtestdata.SatB2T4AA) + varuintToHex(225494) + varuintToHex(225494) +
 				dbtestdata.TxidB2T4 + varuintToHex(0) + varuintToHex(225494) + bigintToHex(dbtestdata.SatB2T4AA),
 			nil,
 		},
@@ -528,15 +531,111 @@ func testTxCache(t *testing.T, d *RocksDB, b *bchain.Block, tx *bchain.Tx) {
 // 5) Disconnect the block 2 using BlockTxs column
 // 6) Reconnect block 2 and check
 // After each step, the content of DB is examined and any difference against expected state is regarded as failure
+func verifySupply(t *testing.T, d *RocksDB, height uint32, wantHeight uint32, wantIssued *big.Int) {
+	s, err := d.GetSupply(height)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s == nil {
+		t.Fatalf("GetSupply(%d) returned nil", height)
+	}
+	if s.Height != wantHeight || s.IssuedSat.Cmp(wantIssued) != 0 || s.BurnedSat.Sign() != 0 {
+		t.Errorf("GetSupply(%d) = %v %v %v, want %v %v 0", height, s.Height, s.IssuedSat.String(), s.BurnedSat.String(), wantHeight, wantIssued)
+	}
+}
+
+// verifyBlockTime checks the time of the block, 0 means that the block is not stored
+func verifyBlockTime(t *testing.T, d *RocksDB, height uint32, want int64) {
+	got, err := d.GetBlockTime(height)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if int64(got) != want {
+		t.Errorf("GetBlockTime(%v) = %v, want %v", height, got, want)
+	}
+}
+
+func verifyBlockHeightOfTime(t *testing.T, d *RocksDB, tm uint32, wantHeight uint32, wantFound bool) {
+	height, found, err := d.GetBlockHeightOfTime(tm)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if height != wantHeight || found != wantFound {
+		t.Errorf("GetBlockHeightOfTime(%v) = %v, %v, want %v, %v", tm, height, found, wantHeight, wantFound)
+	}
+}
+
+func verifyOpReturnOutputs(t *testing.T, d *RocksDB, hexPrefix string, lower, higher uint32, want []OpReturnOutput) {
+	prefix, _ := hex.DecodeString(hexPrefix)
+	got, err := d.GetOpReturnOutputs(prefix, lower, higher, 0, 1000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("GetOpReturnOutputs(%v, %v, %v) = %+v, want %+v", hexPrefix, lower, higher, got, want)
+	}
+}
+
+func verifySpendingInput(t *testing.T, d *RocksDB, txid string, vout int32, want *SpendingInput) {
+	got, err := d.GetSpendingInput(txid, vout)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("GetSpendingInput(%v, %v) = %+v, want %+v", txid, vout, got, want)
+	}
+}
+
+// verifyRichList checks that the rich list contains all addresses with positive balance ordered by the balance
+func verifyRichList(t *testing.T, d *RocksDB) {
+	var want []RichListAddress
+	it := d.db.NewIteratorCF(d.ro, d.cfh[cfAddressBalance])
+	defer it.Close()
+	for it.SeekToFirst(); it.Valid(); it.Next() {
+		ab, err := unpackAddrBalance(it.Value().Data(), d.chainParser.PackedTxidLen(), AddressBalanceDetailNoUTXO)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ab.BalanceSat.Sign() > 0 {
+			want = append(want, RichListAddress{AddrDesc: append(bchain.AddressDescriptor(nil), it.Key().Data()...), BalanceSat: ab.BalanceSat})
+		}
+	}
+	sort.SliceStable(want, func(i, j int) bool {
+		if c := want[i].BalanceSat.Cmp(&want[j].BalanceSat); c != 0 {
+			return c > 0
+		}
+		return bytes.Compare(want[i].AddrDesc, want[j].AddrDesc) > 0
+	})
+	got, err := d.GetRichList(0, 1000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(want) == 0 || !reflect.DeepEqual(got, want) {
+		t.Errorf("GetRichList() = %+v, want %+v", got, want)
+	}
+	size, err := d.GetRichListSize(1000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if size != len(want) {
+		t.Errorf("GetRichListSize() = %v, want %v", size, len(want))
+	}
+	page, err := d.GetRichList(1, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(page, want[1:3]) {
+		t.Errorf("GetRichList(1, 2) = %+v, want %+v", page, want[1:3])
+	}
+}
+
 func TestRocksDB_Index_BitcoinType(t *testing.T) {
 	d := setupRocksDB(t, &testBitcoinParser{
 		BitcoinParser: bitcoinTestnetParser(),
 	})
 	defer closeAndDestroyRocksDB(t, d)
 
-	if len(d.is.BlockTimes) != 0 {
-		t.Fatal("Expecting is.BlockTimes 0, got ", len(d.is.BlockTimes))
-	}
+	verifyBlockTime(t, d, 225493, 0)
 
 	// connect 1st block - will log warnings about missing UTXO transactions in txAddresses column
 	block1 := dbtestdata.GetTestBitcoinTypeBlock1(d.chainParser)
@@ -545,9 +644,8 @@ func TestRocksDB_Index_BitcoinType(t *testing.T) {
 	}
 	verifyAfterBitcoinTypeBlock1(t, d, false)
 
-	if len(d.is.BlockTimes) != 1 {
-		t.Fatal("Expecting is.BlockTimes 1, got ", len(d.is.BlockTimes))
-	}
+	verifyBlockTime(t, d, 225493, block1.Time)
+	verifyBlockTime(t, d, 225494, 0)
 
 	// connect 2nd block - use some outputs from the 1st block as the inputs and 1 input uses tx from the same block
 	block2 := dbtestdata.GetTestBitcoinTypeBlock2(d.chainParser)
@@ -556,9 +654,7 @@ func TestRocksDB_Index_BitcoinType(t *testing.T) {
 	}
 	verifyAfterBitcoinTypeBlock2(t, d)
 
-	if len(d.is.BlockTimes) != 2 {
-		t.Fatal("Expecting is.BlockTimes 1, got ", len(d.is.BlockTimes))
-	}
+	verifyBlockTime(t, d, 225494, block2.Time)
 
 	// get transactions for various addresses / low-high ranges
 	verifyGetTransactions(t, d, dbtestdata.Addr2, 0, 1000000, []txidIndex{
@@ -627,6 +723,38 @@ func TestRocksDB_Index_BitcoinType(t *testing.T) {
 		t.Errorf("GetBlockInfo() = %+v, want %+v", info, iw)
 	}
 
+	// supply after the 1st block is the value of its outputs, in the 2nd block the value of the outputs minus the inputs is added
+	verifySupply(t, d, 225493, 225493, big.NewInt(1234667912345))
+	verifySupply(t, d, 225494, 225494, big.NewInt(1236027941392))
+	verifySupply(t, d, 1000000, 225494, big.NewInt(1236027941392))
+
+	// spending inputs of the outputs spent in the 2nd block, also by a tx from the same block
+	verifySpendingInput(t, d, dbtestdata.TxidB1T1, 1, &SpendingInput{Txid: dbtestdata.TxidB2T1, Vin: 1, Height: 225494})
+	verifySpendingInput(t, d, dbtestdata.TxidB2T1, 0, &SpendingInput{Txid: dbtestdata.TxidB2T2, Vin: 0, Height: 225494})
+	verifySpendingInput(t, d, dbtestdata.TxidB2T2, 0, nil)
+	// the OP_RETURN data 2020f1686f6a20 of the 2nd block is shorter than the indexed prefix, it is padded by zeros in the key
+	opReturnData, _ := hex.DecodeString("2020f1686f6a20")
+	opReturnOutputs := []OpReturnOutput{{Txid: dbtestdata.TxidB2T1, Vout: 2, Height: 225494, Data: opReturnData}}
+	verifyOpReturnOutputs(t, d, "2020", 0, math.MaxUint32, opReturnOutputs)
+	verifyOpReturnOutputs(t, d, "2020f1686f6a20", 225494, 225494, opReturnOutputs)
+	verifyOpReturnOutputs(t, d, "2020f1686f6a2000", 0, math.MaxUint32, nil)
+	verifyOpReturnOutputs(t, d, "2021", 0, math.MaxUint32, nil)
+	verifyOpReturnOutputs(t, d, "2020", 0, 225493, nil)
+	// the filters were not built from the genesis block, their headers are not known
+	for _, height := range []uint32{225493, 225494} {
+		bf, err := d.GetBlockFilter(height)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if bf == nil || len(bf.Filter) == 0 || bf.Header != nil {
+			t.Errorf("GetBlockFilter(%v) = %+v, want filter without header", height, bf)
+		}
+	}
+	verifyRichList(t, d)
+	verifyBlockHeightOfTime(t, d, 1521515026, 225493, true)
+	verifyBlockHeightOfTime(t, d, 1521515027, 225494, true)
+	verifyBlockHeightOfTime(t, d, 1521595679, 0, false)
+
 	// Test tx caching functionality, leave one tx in db to test cleanup in DisconnectBlock
 	testTxCache(t, d, block1, &block1.Txs[0])
 	testTxCache(t, d, block2, &block2.Txs[0])
@@ -662,10 +790,21 @@ func TestRocksDB_Index_BitcoinType(t *testing.T) {
 			t.Fatal(err)
 		}
 	}
-
-	if len(d.is.BlockTimes) != 1 {
-		t.Fatal("Expecting is.BlockTimes 1, got ", len(d.is.BlockTimes))
+	verifySupply(t, d, 225494, 225493, big.NewInt(1234667912345))
+	if err := checkColumn(d, cfSpentOutpoints, []keyPair{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := checkColumn(d, cfOpReturns, []keyPair{}); err != nil {
+		t.Fatal(err)
+	}
+	if bf, err := d.GetBlockFilter(225494); err != nil || bf != nil {
+		t.Errorf("GetBlockFilter(225494) = %+v, %v, want nil", bf, err)
 	}
+	verifyRichList(t, d)
+	verifyBlockHeightOfTime(t, d, 1521515027, 0, false)
+
+	verifyBlockTime(t, d, 225493, block1.Time)
+	verifyBlockTime(t, d, 225494, 0)
 
 	// connect block again and verify the state of db
 	if err := d.ConnectBlock(block2); err != nil {
@@ -673,9 +812,7 @@ func TestRocksDB_Index_BitcoinType(t *testing.T) {
 	}
 	verifyAfterBitcoinTypeBlock2(t, d)
 
-	if len(d.is.BlockTimes) != 2 {
-		t.Fatal("Expecting is.BlockTimes 1, got ", len(d.is.BlockTimes))
-	}
+	verifyBlockTime(t, d, 225494, block2.Time)
 
 	// test public methods for address balance and tx addresses
 	ab, err := d.GetAddressBalance(dbtestdata.Addr5, AddressBalanceDetailUTXO)
@@ -766,9 +903,7 @@ func Test_BulkConnect_BitcoinType(t *testing.T) {
 		t.Fatal("DB not in DbStateInconsistent")
 	}
 
-	if len(d.is.BlockTimes) != 0 {
-		t.Fatal("Expecting is.BlockTimes 0, got ", len(d.is.BlockTimes))
-	}
+	verifyBlockTime(t, d, 225493, 0)
 
 	if err := bc.ConnectBlock(dbtestdata.GetTestBitcoinTypeBlock1(d.chainParser), false); err != nil {
 		t.Fatal(err)
@@ -793,9 +928,8 @@ func Test_BulkConnect_BitcoinType(t *testing.T) {
 
 	verifyAfterBitcoinTypeBlock2(t, d)
 
-	if len(d.is.BlockTimes) != 225495 {
-		t.Fatal("Expecting is.BlockTimes 225495, got ", len(d.is.BlockTimes))
-	}
+	verifyBlockTime(t, d, 225493, dbtestdata.GetTestBitcoinTypeBlock1(d.chainParser).Time)
+	verifyBlockTime(t, d, 225494, dbtestdata.GetTestBitcoinTypeBlock2(d.chainParser).Time)
 }
 
 func Test_packBigint_unpackBigint(t *testing.T) {
@@ -1036,7 +1170,7 @@ func Test_packAddrBalance_unpackAddrBalance(t *testing.T) {
 	}{
 		{
 			name: "no utxos",
-			hex:  "7b060b44cc1af8520514faf980ac",
+			hex:  "7b060b44cc1af8520514faf980ac0000",
 			data: &AddrBalance{
 				BalanceSat: *big.NewInt(90110001324),
 				SentSat:    *big.NewInt(12390110001234),
@@ -1046,11 +1180,13 @@ func Test_packAddrBalance_unpackAddrBalance(t *testing.T) {
 		},
 		{
 			name: "utxos",
-			hex:  "7b060b44cc1af8520514faf980ac00b2c06055e5e90e9c82bd4181fde310104391a7fa4f289b1704e5d90caa38400c87c440060b2fd12177a6effd9ef509383d536b1c8af5bf434c8efbf521a4f2befd4022bbd68694b4ac750098faf659010105e2e48aeabdd9b75def7b48d756ba304713c2aba7b522bf9dbc893fc4231b0782c6df6d84ccd88552087e9cba87a275ffff",
+			hex:  "7b060b44cc1af8520514faf980ac87c44098faf65900b2c06055e5e90e9c82bd4181fde310104391a7fa4f289b1704e5d90caa38400c87c440060b2fd12177a6effd9ef509383d536b1c8af5bf434c8efbf521a4f2befd4022bbd68694b4ac750098faf659010105e2e48aeabdd9b75def7b48d756ba304713c2aba7b522bf9dbc893fc4231b0782c6df6d84ccd88552087e9cba87a275ffff",
 			data: &AddrBalance{
-				BalanceSat: *big.NewInt(90110001324),
-				SentSat:    *big.NewInt(12390110001234),
-				Txs:        123,
+				BalanceSat:  *big.NewInt(90110001324),
+				SentSat:     *big.NewInt(12390110001234),
+				Txs:         123,
+				FirstHeight: 123456,
+				LastHeight:  52345689,
 				Utxos: []Utxo{
 					{
 						BtxID:    hexToBytes(dbtestdata.TxidB1T1),
@@ -1075,7 +1211,7 @@ func Test_packAddrBalance_unpackAddrBalance(t *testing.T) {
 		},
 		{
 			name: "empty",
-			hex:  "000000",
+			hex:  "0000000000",
 			data: &AddrBalance{
 				Utxos: []Utxo{},
 			},
@@ -1102,6 +1238,566 @@ func Test_packAddrBalance_unpackAddrBalance(t *testing.T) {
 	}
 }
 
+func Test_packBlockStaker_unpackBlockStaker(t *testing.T) {
+	parser := bitcoinTestnetParser()
+	tests := []struct {
+		name string
+		bs   BlockStaker
+		hex  string
+	}{
+		{
+			name: "pow",
+			bs:   BlockStaker{Height: 100, PosBlocks: 0},
+			hex:  "0000",
+		},
+		{
+			name: "pow after pos",
+			bs:   BlockStaker{Height: 1000, PosBlocks: 100},
+			hex:  "0064",
+		},
+		{
+			name: "pos unknown staker",
+			bs:   BlockStaker{Height: 259201, ProofOfStake: true, PosBlocks: 1},
+			hex:  "0101",
+		},
+		{
+			name: "pos",
+			bs:   BlockStaker{Height: 259320, ProofOfStake: true, PosBlocks: 120, Staker: hexToBytes(dbtestdata.AddressToPubKeyHex(dbtestdata.Addr1, parser))},
+			hex:  "0178" + dbtestdata.AddressToPubKeyHex(dbtestdata.Addr1, parser),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf := packBlockStaker(&tt.bs)
+			if got := hex.EncodeToString(buf); got != tt.hex {
+				t.Errorf("packBlockStaker() = %v, want %v", got, tt.hex)
+			}
+			got := unpackBlockStaker(tt.bs.Height, buf)
+			if !reflect.DeepEqual(got, &tt.bs) {
+				t.Errorf("unpackBlockStaker() = %+v, want %+v", got, tt.bs)
+			}
+		})
+	}
+}
+
+func Test_Migrate(t *testing.T) {
+	d := setupRocksDB(t, &testBitcoinParser{
+		BitcoinParser: bitcoinTestnetParser(),
+	})
+	defer closeAndDestroyRocksDB(t, d)
+
+	savedMigrations, savedBatchRows := migrations, migrationBatchRows
+	defer func() {
+		migrations, migrationBatchRows = savedMigrations, savedBatchRows
+	}()
+	stop := make(chan os.Signal, 1)
+	// the test migration appends a byte to the values of the column blockTxs
+	// and interrupts the migration after the second converted row
+	converted := 0
+	migrations = []migration{{
+		from:        dbVersion - 1,
+		description: "test migration",
+		columns: []migrationColumn{{
+			name: "blockTxs",
+			cf:   cfBlockTxs,
+			migrate: func(d *RocksDB, wb *gorocksdb.WriteBatch, key, val []byte) error {
+				converted++
+				if converted == 2 {
+					stop <- os.Interrupt
+				}
+				wb.PutCF(d.cfh[cfBlockTxs], key, append(append([]byte(nil), val...), 0xff))
+				return nil
+			},
+		}},
+	}}
+	migrationBatchRows = 2
+
+	for _, k := range []string{"00000001", "00000002", "00000003"} {
+		key, _ := hex.DecodeString(k)
+		if err := d.db.PutCF(d.wo, d.cfh[cfBlockTxs], key, []byte{0x01}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for i := range d.is.DbColumns {
+		d.is.DbColumns[i].Version = dbVersion - 1
+	}
+	if !d.MigrationRequired() {
+		t.Fatal("MigrationRequired() = false, want true")
+	}
+
+	// interrupted migration stores its progress
+	if err := d.Migrate(nil, stop); err != ErrOperationInterrupted {
+		t.Fatalf("Migrate() error = %v, want %v", err, ErrOperationInterrupted)
+	}
+	if err := checkColumn(d, cfBlockTxs, []keyPair{
+		{"00000001", "01ff", nil},
+		{"00000002", "01ff", nil},
+		{"00000003", "01", nil},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	is, err := d.LoadInternalState("coin-unittest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &common.InternalStateMigration{Version: dbVersion - 1, Column: "blockTxs", LastKey: []byte{0, 0, 0, 2}, Rows: 2}
+	if !reflect.DeepEqual(is.Migration, want) {
+		t.Errorf("InternalState.Migration = %+v, want %+v", is.Migration, want)
+	}
+
+	// resumed migration converts only the remaining rows
+	d.is = is
+	if err := d.Migrate(nil, stop); err != nil {
+		t.Fatal(err)
+	}
+	if err := checkColumn(d, cfBlockTxs, []keyPair{
+		{"00000001", "01ff", nil},
+		{"00000002", "01ff", nil},
+		{"00000003", "01ff", nil},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if d.is.Migration != nil {
+		t.Errorf("InternalState.Migration = %+v, want nil", d.is.Migration)
+	}
+	if d.MigrationRequired() {
+		t.Error("MigrationRequired() = true, want false")
+	}
+}
+
+func Test_PrunedIndex(t *testing.T) {
+	d := setupRocksDB(t, &testBitcoinParser{
+		BitcoinParser: bitcoinTestnetParser(),
+	})
+	defer closeAndDestroyRocksDB(t, d)
+	d.SetPruning(1, 0)
+
+	if err := d.ConnectBlock(dbtestdata.GetTestBitcoinTypeBlock1(d.chainParser)); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.ConnectBlock(dbtestdata.GetTestBitcoinTypeBlock2(d.chainParser)); err != nil {
+		t.Fatal(err)
+	}
+	// all outputs of TxidB1T2 are spent in the block 2, TxidB1T1 and TxidB2T1 have unspent outputs
+	if err := checkColumn(d, cfSpentTxs, []keyPair{
+		{"000370d6", dbtestdata.TxidB1T2, nil},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if h := d.is.GetPrunedHeight(); h != 225493 {
+		t.Errorf("GetPrunedHeight() = %v, want 225493", h)
+	}
+
+	// disconnected block removes its spent transactions
+	if err := d.DisconnectBlockRangeBitcoinType(225494, 225494); err != nil {
+		t.Fatal(err)
+	}
+	if err := checkColumn(d, cfSpentTxs, []keyPair{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.ConnectBlock(dbtestdata.GetTestBitcoinTypeBlock2(d.chainParser)); err != nil {
+		t.Fatal(err)
+	}
+
+	// prune the block 2 as if the next block was connected
+	wb := gorocksdb.NewWriteBatch()
+	defer wb.Destroy()
+	if err := d.pruneSpentTxs(wb, 225494, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.db.Write(d.wo, wb); err != nil {
+		t.Fatal(err)
+	}
+	if err := checkColumn(d, cfSpentTxs, []keyPair{}); err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range []struct {
+		txid   string
+		pruned bool
+	}{
+		{dbtestdata.TxidB1T1, false},
+		{dbtestdata.TxidB1T2, true},
+		{dbtestdata.TxidB2T1, false},
+	} {
+		ta, err := d.GetTxAddresses(tt.txid)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if (ta == nil) != tt.pruned {
+			t.Errorf("GetTxAddresses(%v) = %+v, pruned %v", tt.txid, ta, tt.pruned)
+		}
+	}
+}
+
+func Test_InitSpentTxs(t *testing.T) {
+	d := setupRocksDB(t, &testBitcoinParser{
+		BitcoinParser: bitcoinTestnetParser(),
+	})
+	defer closeAndDestroyRocksDB(t, d)
+	// the blocks are connected before the pruning is enabled, the column spentTxs is not maintained
+	if err := d.ConnectBlock(dbtestdata.GetTestBitcoinTypeBlock1(d.chainParser)); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.ConnectBlock(dbtestdata.GetTestBitcoinTypeBlock2(d.chainParser)); err != nil {
+		t.Fatal(err)
+	}
+	if err := checkColumn(d, cfSpentTxs, []keyPair{}); err != nil {
+		t.Fatal(err)
+	}
+
+	// the sweep finds TxidB1T2 fully spent in the block 2, which is not deeper than the pruning depth
+	d.SetPruning(1, 0)
+	if err := d.InitSpentTxs(nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := checkColumn(d, cfSpentTxs, []keyPair{
+		{"000370d6", dbtestdata.TxidB1T2, nil},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if h := d.is.GetPrunedHeight(); h != 225493 {
+		t.Errorf("GetPrunedHeight() = %v, want 225493", h)
+	}
+	ta, err := d.GetTxAddresses(dbtestdata.TxidB1T2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ta == nil {
+		t.Errorf("GetTxAddresses(%v) = nil, the transaction must not be pruned", dbtestdata.TxidB1T2)
+	}
+
+	// the sweep is done only once
+	if err := d.db.DeleteCF(d.wo, d.cfh[cfSpentTxs], []byte{0x00, 0x03, 0x70, 0xd6}); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.InitSpentTxs(nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := checkColumn(d, cfSpentTxs, []keyPair{}); err != nil {
+		t.Fatal(err)
+	}
+
+	// the index opened in the not pruned mode must be swept again when the pruning is enabled
+	d.pruneDepth = 0
+	if err := d.InitSpentTxs(nil); err != nil {
+		t.Fatal(err)
+	}
+	d.SetPruning(1, 0)
+	if err := d.InitSpentTxs(nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := checkColumn(d, cfSpentTxs, []keyPair{
+		{"000370d6", dbtestdata.TxidB1T2, nil},
+	}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func Test_CreateSnapshot_RestoreSnapshot(t *testing.T) {
+	d := setupRocksDB(t, &testBitcoinParser{
+		BitcoinParser: bitcoinTestnetParser(),
+	})
+	defer closeAndDestroyRocksDB(t, d)
+	if err := d.ConnectBlock(dbtestdata.GetTestBitcoinTypeBlock1(d.chainParser)); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.ConnectBlock(dbtestdata.GetTestBitcoinTypeBlock2(d.chainParser)); err != nil {
+		t.Fatal(err)
+	}
+	d.is.DbState = common.DbStateOpen
+
+	tmp, err := ioutil.TempDir("", "testsnapshot")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+	snapshot := tmp + "/snapshot"
+	si, err := d.CreateSnapshot(snapshot)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if si.BestHeight != 225494 || si.BestHash != "00000000eb0443fd7dc4a1ed5c686a8e995057805f9a161d9a5a77a95e72b7b6" || si.Coin != "coin-unittest" {
+		t.Errorf("CreateSnapshot() = %+v", si)
+	}
+
+	// the best block is not confirmed by the backend
+	restored := tmp + "/restored"
+	_, err = RestoreSnapshot(snapshot, restored, "coin-unittest", d.chainParser, func(height uint32, hash string) error {
+		return errors.New("block not found")
+	})
+	if err == nil {
+		t.Fatal("RestoreSnapshot() expected error")
+	}
+	if _, err := os.Stat(restored); !os.IsNotExist(err) {
+		t.Errorf("restored directory not removed, %v", err)
+	}
+	// different coin
+	if _, err = RestoreSnapshot(snapshot, restored, "other-coin", d.chainParser, func(height uint32, hash string) error {
+		return nil
+	}); err == nil {
+		t.Fatal("RestoreSnapshot() expected error")
+	}
+
+	si, err = RestoreSnapshot(snapshot, restored, "coin-unittest", d.chainParser, func(height uint32, hash string) error {
+		if height != 225494 || hash != "00000000eb0443fd7dc4a1ed5c686a8e995057805f9a161d9a5a77a95e72b7b6" {
+			return errors.Errorf("unexpected block %v %v", height, hash)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if si.BestHeight != 225494 {
+		t.Errorf("RestoreSnapshot() = %+v", si)
+	}
+	// restore to not empty directory
+	if _, err = RestoreSnapshot(snapshot, restored, "coin-unittest", d.chainParser, func(height uint32, hash string) error {
+		return nil
+	}); err == nil {
+		t.Fatal("RestoreSnapshot() expected error")
+	}
+}
+
+// Test_buildGCSFilter checks the filter and its header against the test vector of the testnet genesis block from BIP158
+func Test_buildGCSFilter(t *testing.T) {
+	key, _ := hex.DecodeString("000102030405060708090a0b0c0d0e0f")
+	data, _ := hex.DecodeString("000102030405060708090a0b0c0d0e")
+	if h := sipHash24(binary.LittleEndian.Uint64(key[:8]), binary.LittleEndian.Uint64(key[8:]), data); h != 0xa129ca6149be45e5 {
+		t.Errorf("sipHash24() = %x, want a129ca6149be45e5", h)
+	}
+	hash, _ := hex.DecodeString("000000000933ea01ad0ee984209779baaec3ced90fa3f408719526f8d77f4943")
+	script, _ := hex.DecodeString("4104678afdb0fe5548271967f1a67130b7105cd6a828e03909a67962e0ea1f61deb649f6bc3f4cef38c4f35504e51ec112de5c384df7ba0b8d578a4c702b6bf11d5fac")
+	filter := buildGCSFilter(blockFilterP, blockFilterM, reverseBytes(hash), [][]byte{script})
+	if got := hex.EncodeToString(filter); got != "019dfca8" {
+		t.Errorf("buildGCSFilter() = %v, want 019dfca8", got)
+	}
+	header := reverseBytes(blockFilterHeader(filter, make([]byte, blockFilterHeaderLen)))
+	if got := hex.EncodeToString(header); got != "21584579b7eb08997773e5aeff3a7f932700042d0ed2a6129012b7d7ae81b750" {
+		t.Errorf("blockFilterHeader() = %v, want 21584579b7eb08997773e5aeff3a7f932700042d0ed2a6129012b7d7ae81b750", got)
+	}
+	if got := hex.EncodeToString(buildGCSFilter(blockFilterP, blockFilterM, reverseBytes(hash), nil)); got != "00" {
+		t.Errorf("buildGCSFilter() of no items = %v, want 00", got)
+	}
+}
+
+func Test_rebaseBlockFilters(t *testing.T) {
+	d := setupRocksDB(t, &testBitcoinParser{
+		BitcoinParser: bitcoinTestnetParser(),
+	})
+	defer closeAndDestroyRocksDB(t, d)
+
+	// the filter of the block 10 has the header, the filters from the block 11 were stored without it
+	header10 := make([]byte, blockFilterHeaderLen)
+	header10[0] = 1
+	filters := []*blockFilter{
+		{height: 10, header: header10, filter: []byte{0x00}},
+		{height: 11, filter: []byte{0x01, 0x11}},
+		{height: 12, filter: []byte{0x01, 0x12}},
+	}
+	wb := gorocksdb.NewWriteBatch()
+	defer wb.Destroy()
+	for _, bf := range filters {
+		d.storeBlockFilter(wb, bf)
+	}
+	if err := d.db.Write(d.wo, wb); err != nil {
+		t.Fatal(err)
+	}
+	migrate, err := rebaseBlockFilters(d, 11)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := d.migrateColumn(&migrationColumn{cf: cfBlockFilters, migrate: migrate}, &common.InternalStateMigration{}, make(chan os.Signal, 1)); err != nil {
+		t.Fatal(err)
+	}
+	header11 := blockFilterHeader(filters[1].filter, header10)
+	header12 := blockFilterHeader(filters[2].filter, header11)
+	if err := checkColumn(d, cfBlockFilters, []keyPair{
+		{"0000000a", "20" + hex.EncodeToString(header10) + "00", nil},
+		{"0000000b", "20" + hex.EncodeToString(header11) + "0111", nil},
+		{"0000000c", "20" + hex.EncodeToString(header12) + "0112", nil},
+	}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func Test_GetOpReturnOutputs(t *testing.T) {
+	d := setupRocksDB(t, &testBitcoinParser{
+		BitcoinParser: bitcoinTestnetParser(),
+	})
+	defer closeAndDestroyRocksDB(t, d)
+	d.opReturnPrefixLength = 2
+
+	outputs := []OpReturnOutput{
+		{Txid: dbtestdata.TxidB1T1, Vout: 0, Height: 10, Data: hexToBytes("aa0102")},
+		{Txid: dbtestdata.TxidB1T2, Vout: 1, Height: 20, Data: hexToBytes("aa0103")},
+		{Txid: dbtestdata.TxidB2T1, Vout: 2, Height: 30, Data: hexToBytes("aa0102")},
+		{Txid: dbtestdata.TxidB2T2, Vout: 0, Height: 15, Data: hexToBytes("aa02")},
+		{Txid: dbtestdata.TxidB2T3, Vout: 1, Height: 30, Data: hexToBytes("aa02")},
+		{Txid: dbtestdata.TxidB2T4, Vout: 0, Height: 22, Data: hexToBytes("aa")},
+		{Txid: dbtestdata.TxidB1T1, Vout: 1, Height: 40, Data: hexToBytes("ab01")},
+	}
+	wb := gorocksdb.NewWriteBatch()
+	defer wb.Destroy()
+	for _, o := range outputs {
+		btxID, err := d.chainParser.PackTxid(o.Txid)
+		if err != nil {
+			t.Fatal(err)
+		}
+		wb.PutCF(d.cfh[cfOpReturns], d.packOpReturnKey(o.Data, o.Height, btxID, o.Vout), o.Data)
+	}
+	if err := d.db.Write(d.wo, wb); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name          string
+		prefix        string
+		lower, higher uint32
+		skip, count   int
+		want          []int
+	}{
+		// the outputs of the indexed prefixes aa02, aa01 and aa00 are merged by the height, the higher indexed prefix first
+		{name: "shorter prefix", prefix: "aa", higher: math.MaxUint32, count: 10, want: []int{4, 2, 5, 1, 3, 0}},
+		{name: "shorter prefix page", prefix: "aa", higher: math.MaxUint32, skip: 2, count: 2, want: []int{5, 1}},
+		{name: "shorter prefix heights", prefix: "aa", lower: 12, higher: 24, count: 10, want: []int{5, 1, 3}},
+		{name: "indexed prefix", prefix: "aa01", higher: math.MaxUint32, count: 10, want: []int{2, 1, 0}},
+		{name: "indexed prefix page", prefix: "aa01", higher: math.MaxUint32, skip: 1, count: 1, want: []int{1}},
+		{name: "longer prefix", prefix: "aa0102", higher: math.MaxUint32, count: 10, want: []int{2, 0}},
+		{name: "after the last page", prefix: "aa01", higher: math.MaxUint32, skip: 3, count: 10},
+		{name: "no output", prefix: "ac", higher: math.MaxUint32, count: 10},
+		{name: "highest prefix", prefix: "ff", higher: math.MaxUint32, count: 10},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := d.GetOpReturnOutputs(hexToBytes(tt.prefix), tt.lower, tt.higher, tt.skip, tt.count)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var want []OpReturnOutput
+			for _, i := range tt.want {
+				want = append(want, outputs[i])
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("GetOpReturnOutputs() = %+v, want %+v", got, want)
+			}
+		})
+	}
+}
+
+func Test_BlockTimes(t *testing.T) {
+	d := setupRocksDB(t, &testBitcoinParser{
+		BitcoinParser: bitcoinTestnetParser(),
+	})
+	defer closeAndDestroyRocksDB(t, d)
+
+	store := func(from uint32, times ...uint32) {
+		wb := gorocksdb.NewWriteBatch()
+		defer wb.Destroy()
+		for i, tm := range times {
+			if err := d.storeBlockTime(wb, from+uint32(i), tm); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := d.db.Write(d.wo, wb); err != nil {
+			t.Fatal(err)
+		}
+	}
+	verify := func(tm uint32, wantHeight uint32, wantFound bool) {
+		verifyBlockHeightOfTime(t, d, tm, wantHeight, wantFound)
+	}
+	// the block times are not monotonic
+	store(0, 100, 90, 110, 105, 120)
+	verify(0, 0, true)
+	verify(95, 0, true)
+	verify(101, 2, true)
+	verify(106, 2, true)
+	verify(111, 4, true)
+	verify(121, 0, false)
+
+	wb := gorocksdb.NewWriteBatch()
+	defer wb.Destroy()
+	d.disconnectBlockTimes(wb, 3)
+	if err := d.db.Write(d.wo, wb); err != nil {
+		t.Fatal(err)
+	}
+	verify(111, 0, false)
+	// the maximum time of the lower blocks is read from the db after disconnect
+	store(3, 108)
+	verify(108, 2, true)
+	verify(111, 0, false)
+	store(4, 130)
+	verify(111, 4, true)
+}
+
+func Test_CheckDB(t *testing.T) {
+	d := setupRocksDB(t, &testBitcoinParser{
+		BitcoinParser: bitcoinTestnetParser(),
+	})
+	defer closeAndDestroyRocksDB(t, d)
+	if err := d.ConnectBlock(dbtestdata.GetTestBitcoinTypeBlock1(d.chainParser)); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.ConnectBlock(dbtestdata.GetTestBitcoinTypeBlock2(d.chainParser)); err != nil {
+		t.Fatal(err)
+	}
+	stop := make(chan os.Signal)
+
+	res, err := d.CheckDB(0, math.MaxUint32, false, stop)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Errors != 0 || res.Addresses == 0 || res.Balances == 0 || res.BlockTxs != 2 {
+		t.Fatalf("CheckDB() = %+v", res)
+	}
+
+	// corrupt the balance of an address
+	addrDesc := addressToAddrDesc(dbtestdata.Addr6, d.chainParser)
+	ab, err := d.GetAddrDescBalance(addrDesc, AddressBalanceDetailUTXO)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ab.Txs++
+	ab.BalanceSat.Add(&ab.BalanceSat, big.NewInt(1))
+	wb := gorocksdb.NewWriteBatch()
+	defer wb.Destroy()
+	if err := d.storeBalances(wb, map[string]*AddrBalance{string(addrDesc): ab}); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.db.Write(d.wo, wb); err != nil {
+		t.Fatal(err)
+	}
+
+	// the address does not have transactions in the block 225493
+	res, err = d.CheckDB(225493, 225493, false, stop)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Errors != 0 {
+		t.Errorf("CheckDB(225493) = %+v", res)
+	}
+	res, err = d.CheckDB(225494, 225494, false, stop)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Errors != 2 || res.Repaired != 0 {
+		t.Errorf("CheckDB(225494) = %+v", res)
+	}
+	res, err = d.CheckDB(0, math.MaxUint32, true, stop)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Errors != 2 || res.Repaired != 1 {
+		t.Errorf("CheckDB() repair = %+v", res)
+	}
+	res, err = d.CheckDB(0, math.MaxUint32, false, stop)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Errors != 0 {
+		t.Errorf("CheckDB() after repair = %+v", res)
+	}
+}
+
 func TestRocksTickers(t *testing.T) {
 	d := setupRocksDB(t, &testBitcoinParser{
 		BitcoinParser: bitcoinTestnetParser(),
@@ -1171,3 +1867,134 @@ func TestRocksTickers(t *testing.T) {
 		t.Errorf("Ticker found, but the timestamp is older than the last ticker entry.")
 	}
 }
+
+func columnRows(t *testing.T, d *RocksDB, col int) []keyPair {
+	var kp []keyPair
+	it := d.db.NewIteratorCF(d.ro, d.cfh[col])
+	defer it.Close()
+	for it.SeekToFirst(); it.Valid(); it.Next() {
+		kp = append(kp, keyPair{hex.EncodeToString(it.Key().Data()), hex.EncodeToString(it.Value().Data()), nil})
+	}
+	return kp
+}
+
+func deleteColumnRows(t *testing.T, d *RocksDB, col int) {
+	for _, kp := range columnRows(t, d, col) {
+		if err := d.db.DeleteCF(d.wo, d.cfh[col], hexToBytes(kp.Key)); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// testBlocksChain serves the blocks replayed by the migration
+type testBlocksChain struct {
+	bchain.BlockChain
+	blocks map[string]*bchain.Block
+}
+
+func (c *testBlocksChain) GetBlock(hash string, height uint32) (*bchain.Block, error) {
+	if b, found := c.blocks[hash]; found {
+		return b, nil
+	}
+	return nil, bchain.ErrBlockNotFound
+}
+
+// removeAddrActivity stores the rows of the column addressBalance without the heights of the activity as the databases before version 6
+func removeAddrActivity(t *testing.T, d *RocksDB) {
+	for _, kp := range columnRows(t, d, cfAddressBalance) {
+		val := hexToBytes(kp.Value)
+		_, l := unpackVaruint(val)
+		_, sl := unpackBigint(val[l:])
+		_, bl := unpackBigint(val[l+sl:])
+		l += sl + bl
+		_, fl := unpackVaruint(val[l:])
+		_, ll := unpackVaruint(val[l+fl:])
+		if err := d.db.PutCF(d.wo, d.cfh[cfAddressBalance], hexToBytes(kp.Key), append(val[:l:l], val[l+fl+ll:]...)); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// testMigrateBlocks connects the blocks, leaves in the columns built by the migration from the version 5 only the data
+// of the last block, as if the columns were introduced before it, removes the heights of the activity from the balances
+// and checks that the migration restores the columns
+func testMigrateBlocks(t *testing.T, d *RocksDB, blocks []*bchain.Block) {
+	chain := &testBlocksChain{blocks: make(map[string]*bchain.Block)}
+	for _, b := range blocks {
+		if err := d.ConnectBlock(b); err != nil {
+			t.Fatal(err)
+		}
+		chain.blocks[b.Hash] = b
+	}
+	// the columns replayed from the blocks and the columns built from the columns height and txAddresses
+	rebuilt := []int{cfStakingRewards, cfMasternodePayments, cfZerocoinPool, cfSupply, cfBlockStakers, cfSpentOutpoints, cfBlockFilters, cfBlockTimes, cfOpReturns}
+	want := make(map[int][]keyPair)
+	for _, cf := range rebuilt {
+		want[cf] = columnRows(t, d, cf)
+		deleteColumnRows(t, d, cf)
+	}
+
+	last := blocks[len(blocks)-1]
+	txAddressesMap := make(map[string]*TxAddresses)
+	for i := range last.Txs {
+		btxID, err := d.chainParser.PackTxid(last.Txs[i].Txid)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if txAddressesMap[string(btxID)], err = d.getTxAddresses(btxID); err != nil {
+			t.Fatal(err)
+		}
+	}
+	wb := gorocksdb.NewWriteBatch()
+	defer wb.Destroy()
+	if err := migrateBlockColumns(d, wb, last, txAddressesMap); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.db.Write(d.wo, wb); err != nil {
+		t.Fatal(err)
+	}
+	want[cfAddressBalance] = columnRows(t, d, cfAddressBalance)
+	removeAddrActivity(t, d)
+	for i := range d.is.DbColumns {
+		d.is.DbColumns[i].Version = 5
+	}
+
+	if err := d.Migrate(chain, make(chan os.Signal, 1)); err != nil {
+		t.Fatal(err)
+	}
+	for _, cf := range append(rebuilt, cfAddressBalance) {
+		if err := checkColumn(d, cf, want[cf]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if d.MigrationRequired() {
+		t.Error("MigrationRequired() = true, want false")
+	}
+}
+
+func Test_MigrateBlocks(t *testing.T) {
+	d := setupRocksDB(t, &testBitcoinParser{
+		BitcoinParser: bitcoinTestnetParser(),
+	})
+	defer closeAndDestroyRocksDB(t, d)
+	testMigrateBlocks(t, d, []*bchain.Block{
+		dbtestdata.GetTestBitcoinTypeBlock1(d.chainParser),
+		dbtestdata.GetTestBitcoinTypeBlock2(d.chainParser),
+	})
+
+	// the columns missing in the stored internal state are created with the version of the database
+	d.is.DbColumns = d.is.DbColumns[:len(d.is.DbColumns)-1]
+	for i := range d.is.DbColumns {
+		d.is.DbColumns[i].Version = 5
+	}
+	if err := d.storeState(d.is); err != nil {
+		t.Fatal(err)
+	}
+	is, err := d.LoadInternalState("coin-unittest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v := is.DbColumns[len(is.DbColumns)-1].Version; v != 5 {
+		t.Errorf("Version of the missing column = %v, want 5", v)
+	}
+}
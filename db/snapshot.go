@@ -0,0 +1,211 @@
+package db
+
+import (
+	"blockbook/bchain"
+	"blockbook/common"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/golang/glog"
+	"github.com/juju/errors"
+	"github.com/tecbot/gorocksdb"
+)
+
+// size of the block cache used when the snapshot is opened
+const snapshotCacheSize = 1 << 24
+
+// SnapshotInfo describes the snapshot of the database
+type SnapshotInfo struct {
+	Dir        string    `json:"dir"`
+	Coin       string    `json:"coin"`
+	DbVersion  uint32    `json:"dbVersion"`
+	BestHeight uint32    `json:"bestHeight"`
+	BestHash   string    `json:"bestHash"`
+	Created    time.Time `json:"created"`
+}
+
+// CreateSnapshot creates a consistent snapshot of the database in the directory dir, which must not exist
+// the snapshot is a RocksDB checkpoint, the internal state stored in it is updated to match the data in the checkpoint
+// the snapshot can be used as the data directory of Blockbook or restored by RestoreSnapshot
+func (d *RocksDB) CreateSnapshot(dir string) (*SnapshotInfo, error) {
+	if d.is == nil {
+		return nil, errors.New("Internal state not created")
+	}
+	if d.is.DbState == common.DbStateInconsistent {
+		return nil, errors.New("Database is in inconsistent state, snapshot cannot be created")
+	}
+	start := time.Now()
+	cp, err := d.db.NewCheckpoint()
+	if err != nil {
+		return nil, err
+	}
+	defer cp.Destroy()
+	// pack the internal state before the checkpoint, the columns stats are updated after the data are written
+	buf, err := d.is.Pack()
+	if err != nil {
+		return nil, err
+	}
+	if err = cp.CreateCheckpoint(dir, 0); err != nil {
+		return nil, errors.Annotatef(err, "CreateCheckpoint %v", dir)
+	}
+	s, err := d.openCheckpoint(dir)
+	if err != nil {
+		return nil, err
+	}
+	defer s.Close()
+	is, err := common.UnpackInternalState(buf)
+	if err != nil {
+		return nil, err
+	}
+	bestHeight, bestHash, err := s.GetBestBlock()
+	if err != nil {
+		return nil, err
+	}
+	is.DbState = common.DbStateClosed
+	is.BestHeight = bestHeight
+	is.IsSynchronized = false
+	is.IsMempoolSynchronized = false
+	is.MempoolSize = 0
+	if err = s.storeState(is); err != nil {
+		return nil, err
+	}
+	glog.Info("rocksdb: snapshot of height ", bestHeight, " created in ", dir, ", done in ", time.Since(start))
+	return &SnapshotInfo{
+		Dir:        dir,
+		Coin:       is.Coin,
+		DbVersion:  dbVersion,
+		BestHeight: bestHeight,
+		BestHash:   bestHash,
+		Created:    is.LastStore,
+	}, nil
+}
+
+// openCheckpoint opens the checkpoint of the database, the columns of the checkpoint are the same as the columns of the database
+func (d *RocksDB) openCheckpoint(dir string) (*RocksDB, error) {
+	c := gorocksdb.NewLRUCache(snapshotCacheSize)
+	db, cfh, err := openDB(dir, c, d.maxOpenFiles)
+	if err != nil {
+		return nil, err
+	}
+	return &RocksDB{
+		path:         dir,
+		db:           db,
+		wo:           gorocksdb.NewDefaultWriteOptions(),
+		ro:           gorocksdb.NewDefaultReadOptions(),
+		cfh:          cfh,
+		chainParser:  d.chainParser,
+		cache:        c,
+		maxOpenFiles: d.maxOpenFiles,
+	}, nil
+}
+
+// RestoreSnapshot restores the snapshot from the directory dir to the empty data directory path
+// the restored database must be of given coin, of the current data version and closed,
+// its best block is checked by the function verifyBestBlock, usually against the backend
+// if the validation fails, the restored data are removed
+func RestoreSnapshot(dir, path, coin string, parser bchain.BlockChainParser, verifyBestBlock func(height uint32, hash string) error) (*SnapshotInfo, error) {
+	if files, err := ioutil.ReadDir(path); err == nil && len(files) > 0 {
+		return nil, errors.Errorf("Data directory %v is not empty", path)
+	}
+	glog.Info("rocksdb: restoring snapshot ", dir, " to ", path)
+	if err := copySnapshot(dir, path); err != nil {
+		os.RemoveAll(path)
+		return nil, err
+	}
+	si, err := validateSnapshot(path, coin, parser, verifyBestBlock)
+	if err != nil {
+		os.RemoveAll(path)
+		return nil, err
+	}
+	si.Dir = dir
+	glog.Info("rocksdb: snapshot of height ", si.BestHeight, " restored")
+	return si, nil
+}
+
+func validateSnapshot(path, coin string, parser bchain.BlockChainParser, verifyBestBlock func(height uint32, hash string) error) (*SnapshotInfo, error) {
+	// the internal state is not set, Close does not modify the snapshot
+	s, err := NewRocksDB(path, snapshotCacheSize, -1, parser, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer s.Close()
+	// LoadInternalState checks the coin of the database
+	is, err := s.LoadInternalState(coin)
+	if err != nil {
+		return nil, err
+	}
+	if is.DbState != common.DbStateClosed {
+		return nil, errors.New("Snapshot is not in closed state")
+	}
+	for _, c := range is.DbColumns {
+		if c.Version != dbVersion {
+			return nil, errors.Errorf("DB version %v of column '%v' of the snapshot does not match the required version %v", c.Version, c.Name, dbVersion)
+		}
+	}
+	bestHeight, bestHash, err := s.GetBestBlock()
+	if err != nil {
+		return nil, err
+	}
+	if bestHash == "" {
+		return nil, errors.New("Snapshot does not contain any block")
+	}
+	if err = verifyBestBlock(bestHeight, bestHash); err != nil {
+		return nil, errors.Annotatef(err, "Best block %v %v of the snapshot", bestHeight, bestHash)
+	}
+	return &SnapshotInfo{
+		Coin:       is.Coin,
+		DbVersion:  dbVersion,
+		BestHeight: bestHeight,
+		BestHash:   bestHash,
+		Created:    is.LastStore,
+	}, nil
+}
+
+// copySnapshot copies the files of the checkpoint from the directory dir to the directory path
+// the table files are immutable, they are hard linked if possible, the other files are modified by RocksDB and must be copied
+func copySnapshot(dir, path string) error {
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+	if err = os.MkdirAll(path, 0755); err != nil {
+		return err
+	}
+	for _, f := range files {
+		if f.IsDir() {
+			continue
+		}
+		src := filepath.Join(dir, f.Name())
+		dst := filepath.Join(path, f.Name())
+		if strings.HasSuffix(f.Name(), ".sst") {
+			if err = os.Link(src, dst); err == nil {
+				continue
+			}
+		}
+		if err = copyFile(src, dst); err != nil {
+			return errors.Annotatef(err, "copy %v", f.Name())
+		}
+	}
+	return nil
+}
+
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	if _, err = io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
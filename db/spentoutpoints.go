@@ -0,0 +1,151 @@
+package db
+
+import (
+	"blockbook/bchain"
+	"encoding/hex"
+
+	"github.com/juju/errors"
+	"github.com/tecbot/gorocksdb"
+)
+
+// spent outpoints
+// the column spentOutpoints maps the outpoint (txid, vout) to the input which spent it,
+// the key is the packed txid followed by the packed vout, the value is the packed txid of the spending transaction,
+// the index of the input and the height of the block of the spending transaction
+
+// SpendingInput is the input of a transaction which spent an outpoint
+type SpendingInput struct {
+	Txid   string
+	Vin    int32
+	Height uint32
+}
+
+type spendingInput struct {
+	btxID  []byte
+	index  int32
+	height uint32
+}
+
+func packSpentOutpointKey(btxID []byte, vout int32) []byte {
+	varBuf := make([]byte, maxPackedBigintBytes)
+	l := packVaruint(uint(vout), varBuf)
+	return append(append(make([]byte, 0, len(btxID)+l), btxID...), varBuf[:l]...)
+}
+
+func packSpendingInput(si *spendingInput) []byte {
+	varBuf := make([]byte, maxPackedBigintBytes)
+	buf := make([]byte, 0, len(si.btxID)+8)
+	buf = append(buf, si.btxID...)
+	l := packVaruint(uint(si.index), varBuf)
+	buf = append(buf, varBuf[:l]...)
+	l = packVaruint(uint(si.height), varBuf)
+	return append(buf, varBuf[:l]...)
+}
+
+func (d *RocksDB) unpackSpendingInput(buf []byte) (*spendingInput, error) {
+	pl := d.chainParser.PackedTxidLen()
+	if len(buf) < pl+2 {
+		return nil, errors.Errorf("Inconsistent data in spentOutpoints %v", hex.EncodeToString(buf))
+	}
+	index, l := unpackVaruint(buf[pl:])
+	height, _ := unpackVaruint(buf[pl+l:])
+	return &spendingInput{
+		btxID:  append([]byte(nil), buf[:pl]...),
+		index:  int32(index),
+		height: uint32(height),
+	}, nil
+}
+
+// addSpentOutpoint records the input spending the output vout of the transaction btxID
+func addSpentOutpoint(spentOutpoints map[string]*spendingInput, btxID []byte, vout int32, si *spendingInput) {
+	spentOutpoints[string(packSpentOutpointKey(btxID, vout))] = si
+}
+
+// processSpentOutpoints records the inputs of the block spending the outpoints of the known transactions,
+// it is used by the migration replaying the blocks, ConnectBlock records them when processing the inputs of the block
+func (d *RocksDB) processSpentOutpoints(block *bchain.Block, txAddressesMap map[string]*TxAddresses, spentOutpoints map[string]*spendingInput) error {
+	for i := range block.Txs {
+		tx := &block.Txs[i]
+		spendingTxid, err := d.chainParser.PackTxid(tx.Txid)
+		if err != nil {
+			return err
+		}
+		for j := range tx.Vin {
+			btxID, err := d.chainParser.PackTxid(tx.Vin[j].Txid)
+			if err != nil {
+				// do not process inputs without input txid
+				if err == bchain.ErrTxidMissing {
+					continue
+				}
+				return err
+			}
+			if _, found := txAddressesMap[string(btxID)]; !found {
+				ta, err := d.getTxAddresses(btxID)
+				if err != nil {
+					return err
+				}
+				if ta == nil {
+					continue
+				}
+			}
+			addSpentOutpoint(spentOutpoints, btxID, int32(tx.Vin[j].Vout), &spendingInput{
+				btxID:  spendingTxid,
+				index:  int32(j),
+				height: block.Height,
+			})
+		}
+	}
+	return nil
+}
+
+func (d *RocksDB) storeSpentOutpoints(wb *gorocksdb.WriteBatch, spentOutpoints map[string]*spendingInput) {
+	for key, si := range spentOutpoints {
+		wb.PutCF(d.cfh[cfSpentOutpoints], []byte(key), packSpendingInput(si))
+	}
+}
+
+// disconnectSpentOutpoints removes the spending inputs of the outpoints spent by the disconnected transaction
+func (d *RocksDB) disconnectSpentOutpoints(wb *gorocksdb.WriteBatch, inputs []outpoint) {
+	for i := range inputs {
+		wb.DeleteCF(d.cfh[cfSpentOutpoints], packSpentOutpointKey(inputs[i].btxID, inputs[i].index))
+	}
+}
+
+// deleteSpentOutpoints removes the spending inputs of the outputs of the transaction
+func (d *RocksDB) deleteSpentOutpoints(wb *gorocksdb.WriteBatch, btxID []byte, outputs int) {
+	for i := 0; i < outputs; i++ {
+		wb.DeleteCF(d.cfh[cfSpentOutpoints], packSpentOutpointKey(btxID, int32(i)))
+	}
+}
+
+// GetSpendingInput returns the input which spent the output vout of the transaction txid or nil if the output is not spent
+func (d *RocksDB) GetSpendingInput(txid string, vout int32) (*SpendingInput, error) {
+	if d.chainParser.GetChainType() != bchain.ChainBitcoinType || vout < 0 {
+		return nil, nil
+	}
+	btxID, err := d.chainParser.PackTxid(txid)
+	if err != nil {
+		return nil, err
+	}
+	val, err := d.db.GetCF(d.ro, d.cfh[cfSpentOutpoints], packSpentOutpointKey(btxID, vout))
+	if err != nil {
+		return nil, err
+	}
+	defer val.Free()
+	if len(val.Data()) == 0 {
+		return nil, nil
+	}
+	si, err := d.unpackSpendingInput(val.Data())
+	if err != nil {
+		return nil, err
+	}
+	spendingTxid, err := d.chainParser.UnpackTxid(si.btxID)
+	if err != nil {
+		return nil, err
+	}
+	return &SpendingInput{
+		Txid:   spendingTxid,
+		Vin:    si.index,
+		Height: si.height,
+	}, nil
+}
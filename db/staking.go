@@ -0,0 +1,149 @@
+package db
+
+import (
+	"blockbook/bchain"
+	"bytes"
+	"math/big"
+
+	vlq "github.com/bsm/go-vlq"
+	"github.com/tecbot/gorocksdb"
+)
+
+// StakingRewards contains cumulative staking rewards of an address
+type StakingRewards struct {
+	Stakes    uint32
+	RewardSat big.Int
+}
+
+// IsCoinstake checks if the transaction is coinstake transaction of a proof of stake chain
+// coinstake transaction has at least one input and the first of at least two outputs is empty
+func (d *RocksDB) IsCoinstake(ta *TxAddresses) bool {
+	return d.chainParser.SupportsCoinstake() && len(ta.Inputs) > 0 &&
+		len(ta.Outputs) > 1 && len(ta.Outputs[0].AddrDesc) == 0 && ta.Outputs[0].ValueSat.Sign() == 0
+}
+
+// CoinstakeRewards returns staking rewards in the coinstake transaction
+// the staker is the address of the first input, the staker outputs are the outputs to the addresses of the inputs
+// or to the same script as the first staker output, other outputs are masternode or budget payments
+// the reward is the difference between the value of the staker outputs and the inputs
+// the reward of the cold stake belongs to the owner of the delegated coins, the staker address is not credited
+func (d *RocksDB) CoinstakeRewards(ta *TxAddresses) map[string]*big.Int {
+	rewards := make(map[string]*big.Int)
+	if len(ta.Inputs[0].AddrDesc) == 0 || ta.Inputs[0].ValueSat.Sign() == 0 {
+		// zerocoin stake, the staked value is not known
+		return rewards
+	}
+	var reward big.Int
+	inputs := make(map[string]struct{})
+	for i := range ta.Inputs {
+		inputs[string(ta.Inputs[i].AddrDesc)] = struct{}{}
+		reward.Sub(&reward, &ta.Inputs[i].ValueSat)
+	}
+	for i := 1; i < len(ta.Outputs); i++ {
+		tao := &ta.Outputs[i]
+		if _, e := inputs[string(tao.AddrDesc)]; e || bytes.Equal(tao.AddrDesc, ta.Outputs[1].AddrDesc) {
+			reward.Add(&reward, &tao.ValueSat)
+		}
+	}
+	if reward.Sign() > 0 {
+		rewards[string(d.balanceAddrDescs(ta.Inputs[0].AddrDesc)[0])] = &reward
+	}
+	return rewards
+}
+
+func (d *RocksDB) getStakingRewardsForUpdate(addrDesc string, stakingRewards map[string]*StakingRewards) (*StakingRewards, error) {
+	sr, e := stakingRewards[addrDesc]
+	if !e {
+		var err error
+		sr, err = d.GetAddrDescStakingRewards(bchain.AddressDescriptor(addrDesc))
+		if err != nil {
+			return nil, err
+		}
+		if sr == nil {
+			sr = &StakingRewards{}
+		}
+		stakingRewards[addrDesc] = sr
+	}
+	return sr, nil
+}
+
+// processStakingRewards adds rewards of the coinstake transactions of the block to stakingRewards
+func (d *RocksDB) processStakingRewards(block *bchain.Block, txAddressesMap map[string]*TxAddresses, stakingRewards map[string]*StakingRewards) error {
+	if !d.chainParser.SupportsCoinstake() {
+		return nil
+	}
+	for i := range block.Txs {
+		btxID, err := d.chainParser.PackTxid(block.Txs[i].Txid)
+		if err != nil {
+			return err
+		}
+		ta := txAddressesMap[string(btxID)]
+		if ta == nil || !d.IsCoinstake(ta) {
+			continue
+		}
+		for ad, r := range d.CoinstakeRewards(ta) {
+			sr, err := d.getStakingRewardsForUpdate(ad, stakingRewards)
+			if err != nil {
+				return err
+			}
+			sr.Stakes++
+			sr.RewardSat.Add(&sr.RewardSat, r)
+		}
+	}
+	return nil
+}
+
+// disconnectStakingRewards subtracts rewards of the disconnected coinstake transaction from stakingRewards
+func (d *RocksDB) disconnectStakingRewards(ta *TxAddresses, stakingRewards map[string]*StakingRewards) error {
+	if !d.IsCoinstake(ta) {
+		return nil
+	}
+	for ad, r := range d.CoinstakeRewards(ta) {
+		sr, err := d.getStakingRewardsForUpdate(ad, stakingRewards)
+		if err != nil {
+			return err
+		}
+		if sr.Stakes > 0 {
+			sr.Stakes--
+		}
+		sr.RewardSat.Sub(&sr.RewardSat, r)
+		if sr.RewardSat.Sign() < 0 {
+			d.resetValueSatToZero(&sr.RewardSat, bchain.AddressDescriptor(ad), "staking rewards")
+		}
+	}
+	return nil
+}
+
+func (d *RocksDB) storeStakingRewards(wb *gorocksdb.WriteBatch, stakingRewards map[string]*StakingRewards) error {
+	buf := make([]byte, vlq.MaxLen32+maxPackedBigintBytes)
+	for addrDesc, sr := range stakingRewards {
+		// staking rewards without stakes are removed from db - happens on disconnect
+		if sr.Stakes == 0 {
+			wb.DeleteCF(d.cfh[cfStakingRewards], bchain.AddressDescriptor(addrDesc))
+		} else {
+			l := packVaruint(uint(sr.Stakes), buf)
+			l += packBigint(&sr.RewardSat, buf[l:])
+			wb.PutCF(d.cfh[cfStakingRewards], bchain.AddressDescriptor(addrDesc), buf[:l])
+		}
+	}
+	return nil
+}
+
+// GetAddrDescStakingRewards returns cumulative staking rewards of the addrDesc or nil if the address has not staked
+func (d *RocksDB) GetAddrDescStakingRewards(addrDesc bchain.AddressDescriptor) (*StakingRewards, error) {
+	val, err := d.db.GetCF(d.ro, d.cfh[cfStakingRewards], addrDesc)
+	if err != nil {
+		return nil, err
+	}
+	defer val.Free()
+	buf := val.Data()
+	if len(buf) < 2 {
+		return nil, nil
+	}
+	stakes, l := unpackVaruint(buf)
+	reward, _ := unpackBigint(buf[l:])
+	return &StakingRewards{
+		Stakes:    uint32(stakes),
+		RewardSat: reward,
+	}, nil
+}
@@ -0,0 +1,139 @@
+package db
+
+import (
+	"blockbook/bchain"
+	"math/big"
+
+	"github.com/juju/errors"
+	"github.com/tecbot/gorocksdb"
+)
+
+// coin supply is stored in the column supply as snapshots of the cumulative state after each block
+// key is the height in binary complement so that the latest snapshot at given height can be found by Seek
+// value is the issued and burned amount
+
+// Supply contains cumulative amount of issued and burned coins up to the block of given height
+type Supply struct {
+	Height    uint32
+	IssuedSat big.Int
+	BurnedSat big.Int
+}
+
+// processSupply adds coins issued and burned in the block to the supply
+// issued amount is the value of the outputs minus the value of the inputs of all transactions in the block,
+// i.e. the coinbase or coinstake reward minus the fees which were not claimed by the miner or staker
+// zerocoin spends do not have inputs with value, their denominations are used instead,
+// the value moved from the shielded pool to the transparent outputs (sapling valueBalance) is not issued, shielded value is part of the supply
+// burned amount is the value of provably unspendable (OP_RETURN) outputs, the parser does not index them,
+// zerocoin mints are not indexed either but their value stays in the zerocoin pool, it is not burned
+func (d *RocksDB) processSupply(block *bchain.Block, txAddressesMap map[string]*TxAddresses, s *Supply) error {
+	for i := range block.Txs {
+		tx := &block.Txs[i]
+		btxID, err := d.chainParser.PackTxid(tx.Txid)
+		if err != nil {
+			return err
+		}
+		ta := txAddressesMap[string(btxID)]
+		if ta == nil {
+			return errors.Errorf("TxAddresses for txid %v not found", tx.Txid)
+		}
+		for j := range ta.Inputs {
+			s.IssuedSat.Sub(&s.IssuedSat, &ta.Inputs[j].ValueSat)
+		}
+		mints, spends := d.chainParser.GetZerocoinMintsAndSpends(tx)
+		for _, v := range spends {
+			s.IssuedSat.Sub(&s.IssuedSat, big.NewInt(v))
+		}
+		for _, v := range mints {
+			s.BurnedSat.Sub(&s.BurnedSat, big.NewInt(v))
+		}
+		sd, err := d.chainParser.GetShieldedTxData(tx)
+		if err != nil {
+			return err
+		}
+		if sd != nil {
+			s.IssuedSat.Sub(&s.IssuedSat, &sd.ValueBalanceSat)
+		}
+		for j := range tx.Vout {
+			vout := &tx.Vout[j]
+			s.IssuedSat.Add(&s.IssuedSat, &vout.ValueSat)
+			if vout.ValueSat.Sign() > 0 {
+				addrDesc, err := d.chainParser.GetAddrDescFromVout(vout)
+				if err == nil && len(addrDesc) > 0 && !d.chainParser.IsAddrDescIndexable(addrDesc) {
+					s.BurnedSat.Add(&s.BurnedSat, &vout.ValueSat)
+				}
+			}
+		}
+	}
+	s.Height = block.Height
+	return nil
+}
+
+// connectSupply stores the supply after the block
+func (d *RocksDB) connectSupply(wb *gorocksdb.WriteBatch, block *bchain.Block, txAddressesMap map[string]*TxAddresses) error {
+	var s *Supply
+	if block.Height > 0 {
+		var err error
+		if s, err = d.GetSupply(block.Height - 1); err != nil {
+			return err
+		}
+	}
+	if s == nil {
+		s = &Supply{}
+	}
+	if err := d.processSupply(block, txAddressesMap, s); err != nil {
+		return err
+	}
+	d.storeSupply(wb, s)
+	return nil
+}
+
+func (d *RocksDB) storeSupply(wb *gorocksdb.WriteBatch, s *Supply) {
+	buf := make([]byte, 2*maxPackedBigintBytes)
+	l := packBigint(&s.IssuedSat, buf)
+	l += packBigint(&s.BurnedSat, buf[l:])
+	wb.PutCF(d.cfh[cfSupply], packUint(^s.Height), buf[:l])
+}
+
+// GetSupply returns the supply after the block of given height or nil if the supply was not computed up to the height
+func (d *RocksDB) GetSupply(height uint32) (*Supply, error) {
+	it := d.db.NewIteratorCF(d.ro, d.cfh[cfSupply])
+	defer it.Close()
+	// heights are stored in binary complement, the first key after the seek is the latest snapshot at or below the height
+	if it.Seek(packUint(^height)); it.Valid() {
+		buf := it.Value().Data()
+		issued, l := unpackBigint(buf)
+		burned, _ := unpackBigint(buf[l:])
+		return &Supply{
+			Height:    ^unpackUint(it.Key().Data()),
+			IssuedSat: issued,
+			BurnedSat: burned,
+		}, nil
+	}
+	return nil, nil
+}
+
+// disconnectSupply removes the supply snapshot of the disconnected block
+func (d *RocksDB) disconnectSupply(wb *gorocksdb.WriteBatch, height uint32) {
+	wb.DeleteCF(d.cfh[cfSupply], packUint(^height))
+}
+
+// rebaseSupply adds the supply of the replayed blocks to the snapshots stored from the height builtFrom
+func rebaseSupply(d *RocksDB, builtFrom uint32) (migrateRowFunc, error) {
+	base, err := d.GetSupply(builtFrom - 1)
+	if err != nil {
+		return nil, err
+	}
+	return func(d *RocksDB, wb *gorocksdb.WriteBatch, key, val []byte) error {
+		s := &Supply{Height: ^unpackUint(key)}
+		if base == nil || s.Height < builtFrom {
+			return nil
+		}
+		issued, l := unpackBigint(val)
+		burned, _ := unpackBigint(val[l:])
+		s.IssuedSat.Add(&issued, &base.IssuedSat)
+		s.BurnedSat.Add(&burned, &base.BurnedSat)
+		d.storeSupply(wb, s)
+		return nil
+	}, nil
+}
@@ -65,6 +65,7 @@ func (w *SyncWorker) ResyncIndex(onNewBlock bchain.OnNewBlockFunc, initialSync b
 		bh, _, err := w.db.GetBestBlock()
 		if err == nil {
 			w.is.FinishedSync(bh)
+			w.updateSuperblocks(initialSync)
 		}
 		return err
 	case errSynced:
@@ -74,6 +75,7 @@ func (w *SyncWorker) ResyncIndex(onNewBlock bchain.OnNewBlockFunc, initialSync b
 		if initialSync {
 			d := time.Since(start)
 			glog.Info("resync: finished in ", d)
+			w.updateSuperblocks(initialSync)
 		}
 		return nil
 	}
@@ -0,0 +1,203 @@
+package db
+
+import (
+	"blockbook/bchain"
+	"bytes"
+	"sort"
+
+	vlq "github.com/bsm/go-vlq"
+	"github.com/juju/errors"
+	"github.com/tecbot/gorocksdb"
+)
+
+// zerocoin pool is stored in the column zerocoinPool as snapshots of the cumulative state
+// the snapshot is stored only for the heights at which the pool changed
+// key is the height in binary complement so that the latest snapshot at given height can be found by Seek
+
+// ZerocoinDenomination contains number of minted and spent zerocoins of one denomination
+type ZerocoinDenomination struct {
+	Denomination int64
+	Mints        uint32
+	Spends       uint32
+}
+
+// ZerocoinPool contains state of the zerocoin pool, denominations are sorted in ascending order
+type ZerocoinPool struct {
+	Height        uint32
+	Denominations []ZerocoinDenomination
+}
+
+func (zp *ZerocoinPool) getDenomination(denomination int64) *ZerocoinDenomination {
+	i := sort.Search(len(zp.Denominations), func(i int) bool { return zp.Denominations[i].Denomination >= denomination })
+	if i == len(zp.Denominations) || zp.Denominations[i].Denomination != denomination {
+		zp.Denominations = append(zp.Denominations, ZerocoinDenomination{})
+		copy(zp.Denominations[i+1:], zp.Denominations[i:])
+		zp.Denominations[i] = ZerocoinDenomination{Denomination: denomination}
+	}
+	return &zp.Denominations[i]
+}
+
+func (zp *ZerocoinPool) add(delta *ZerocoinPool) {
+	for i := range delta.Denominations {
+		dd := &delta.Denominations[i]
+		zd := zp.getDenomination(dd.Denomination)
+		zd.Mints += dd.Mints
+		zd.Spends += dd.Spends
+	}
+	zp.Height = delta.Height
+}
+
+func (zp *ZerocoinPool) copy() *ZerocoinPool {
+	c := ZerocoinPool{
+		Height:        zp.Height,
+		Denominations: make([]ZerocoinDenomination, len(zp.Denominations)),
+	}
+	copy(c.Denominations, zp.Denominations)
+	return &c
+}
+
+// zerocoinPoolDelta returns zerocoin mints and spends of the block or nil if there are none
+func (d *RocksDB) zerocoinPoolDelta(block *bchain.Block) *ZerocoinPool {
+	var delta *ZerocoinPool
+	for i := range block.Txs {
+		mints, spends := d.chainParser.GetZerocoinMintsAndSpends(&block.Txs[i])
+		if len(mints) == 0 && len(spends) == 0 {
+			continue
+		}
+		if delta == nil {
+			delta = &ZerocoinPool{Height: block.Height}
+		}
+		for _, m := range mints {
+			delta.getDenomination(m).Mints++
+		}
+		for _, s := range spends {
+			delta.getDenomination(s).Spends++
+		}
+	}
+	return delta
+}
+
+// connectZerocoinPool stores new state of the zerocoin pool if the block contains zerocoin mints or spends
+func (d *RocksDB) connectZerocoinPool(wb *gorocksdb.WriteBatch, block *bchain.Block) error {
+	delta := d.zerocoinPoolDelta(block)
+	if delta == nil {
+		return nil
+	}
+	zp, err := d.GetZerocoinPool(block.Height - 1)
+	if err != nil {
+		return err
+	}
+	if zp == nil {
+		zp = &ZerocoinPool{}
+	}
+	zp.add(delta)
+	d.storeZerocoinPool(wb, zp)
+	return nil
+}
+
+func (d *RocksDB) storeZerocoinPool(wb *gorocksdb.WriteBatch, zp *ZerocoinPool) {
+	buf := make([]byte, 0, vlq.MaxLen64+len(zp.Denominations)*(vlq.MaxLen64+2*vlq.MaxLen32))
+	varBuf := make([]byte, vlq.MaxLen64)
+	l := packVaruint(uint(len(zp.Denominations)), varBuf)
+	buf = append(buf, varBuf[:l]...)
+	for i := range zp.Denominations {
+		zd := &zp.Denominations[i]
+		l = packVaruint(uint(zd.Denomination), varBuf)
+		buf = append(buf, varBuf[:l]...)
+		l = packVaruint(uint(zd.Mints), varBuf)
+		buf = append(buf, varBuf[:l]...)
+		l = packVaruint(uint(zd.Spends), varBuf)
+		buf = append(buf, varBuf[:l]...)
+	}
+	wb.PutCF(d.cfh[cfZerocoinPool], packUint(^zp.Height), buf)
+}
+
+func unpackZerocoinPool(height uint32, buf []byte) (*ZerocoinPool, error) {
+	n, l := unpackVaruint(buf)
+	zp := ZerocoinPool{
+		Height:        height,
+		Denominations: make([]ZerocoinDenomination, n),
+	}
+	for i := range zp.Denominations {
+		if l >= len(buf) {
+			return nil, errors.New("Invalid zerocoin pool data")
+		}
+		zd := &zp.Denominations[i]
+		v, ll := unpackVaruint(buf[l:])
+		zd.Denomination = int64(v)
+		l += ll
+		v, ll = unpackVaruint(buf[l:])
+		zd.Mints = uint32(v)
+		l += ll
+		v, ll = unpackVaruint(buf[l:])
+		zd.Spends = uint32(v)
+		l += ll
+	}
+	return &zp, nil
+}
+
+// GetZerocoinPool returns state of the zerocoin pool at given height or nil if there were no zerocoin transactions up to the height
+func (d *RocksDB) GetZerocoinPool(height uint32) (*ZerocoinPool, error) {
+	it := d.db.NewIteratorCF(d.ro, d.cfh[cfZerocoinPool])
+	defer it.Close()
+	// heights are stored in binary complement, the first key after the seek is the latest snapshot at or below the height
+	if it.Seek(packUint(^height)); it.Valid() {
+		return unpackZerocoinPool(^unpackUint(it.Key().Data()), it.Value().Data())
+	}
+	return nil, nil
+}
+
+// disconnectZerocoinPool removes the snapshot of the zerocoin pool of the disconnected block
+func (d *RocksDB) disconnectZerocoinPool(wb *gorocksdb.WriteBatch, height uint32) {
+	wb.DeleteCF(d.cfh[cfZerocoinPool], packUint(^height))
+}
+
+// rebaseZerocoinPool adds the zerocoin pool of the replayed blocks to the snapshots stored from the height builtFrom
+func rebaseZerocoinPool(d *RocksDB, builtFrom uint32) (migrateRowFunc, error) {
+	base, err := d.GetZerocoinPool(builtFrom - 1)
+	if err != nil {
+		return nil, err
+	}
+	return func(d *RocksDB, wb *gorocksdb.WriteBatch, key, val []byte) error {
+		height := ^unpackUint(key)
+		if base == nil || height < builtFrom {
+			return nil
+		}
+		zp, err := unpackZerocoinPool(height, val)
+		if err != nil {
+			return err
+		}
+		rebased := base.copy()
+		rebased.add(zp)
+		d.storeZerocoinPool(wb, rebased)
+		return nil
+	}, nil
+}
+
+// migrateZerocoinBalances removes the zerocoin mints and spends indexed by the databases before version 6 as pseudo addresses
+// together with their rows in the columns addresses and richList
+func migrateZerocoinBalances(d *RocksDB, wb *gorocksdb.WriteBatch, key, val []byte) error {
+	// the column addressBalance is used by the Bitcoin type coins only, it is shared with the column addressContracts
+	if d.chainParser.GetChainType() != bchain.ChainBitcoinType || len(val) < 3 || d.chainParser.IsAddrDescIndexable(key) {
+		return nil
+	}
+	ab, err := unpackAddrBalance(val, d.chainParser.PackedTxidLen(), AddressBalanceDetailNoUTXO)
+	if err != nil {
+		return err
+	}
+	it := d.db.NewIteratorCF(d.ro, d.cfh[cfAddresses])
+	defer it.Close()
+	for it.Seek(key); it.Valid(); it.Next() {
+		addrKey := it.Key().Data()
+		if !bytes.HasPrefix(addrKey, key) {
+			break
+		}
+		// skip the keys of longer address descriptors with the same prefix
+		if len(addrKey) == len(key)+packedHeightBytes {
+			wb.DeleteCF(d.cfh[cfAddresses], append([]byte(nil), addrKey...))
+		}
+	}
+	wb.DeleteCF(d.cfh[cfRichList], packRichListKey(&ab.BalanceSat, key))
+	wb.DeleteCF(d.cfh[cfAddressBalance], key)
+	return nil
+}
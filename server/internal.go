@@ -9,27 +9,30 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"path/filepath"
 
 	"github.com/golang/glog"
+	"github.com/juju/errors"
 
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 )
 
 // InternalServer is handle to internal http server
 type InternalServer struct {
-	https       *http.Server
-	certFiles   string
-	db          *db.RocksDB
-	txCache     *db.TxCache
-	chain       bchain.BlockChain
-	chainParser bchain.BlockChainParser
-	mempool     bchain.Mempool
-	is          *common.InternalState
-	api         *api.Worker
+	https        *http.Server
+	certFiles    string
+	snapshotBase string
+	db           *db.RocksDB
+	txCache      *db.TxCache
+	chain        bchain.BlockChain
+	chainParser  bchain.BlockChainParser
+	mempool      bchain.Mempool
+	is           *common.InternalState
+	api          *api.Worker
 }
 
 // NewInternalServer creates new internal http interface to blockbook and returns its handle
-func NewInternalServer(binding, certFiles string, db *db.RocksDB, chain bchain.BlockChain, mempool bchain.Mempool, txCache *db.TxCache, is *common.InternalState) (*InternalServer, error) {
+func NewInternalServer(binding, certFiles, snapshotBase string, db *db.RocksDB, chain bchain.BlockChain, mempool bchain.Mempool, txCache *db.TxCache, is *common.InternalState) (*InternalServer, error) {
 	api, err := api.NewWorker(db, chain, mempool, txCache, is)
 	if err != nil {
 		return nil, err
@@ -42,19 +45,21 @@ func NewInternalServer(binding, certFiles string, db *db.RocksDB, chain bchain.B
 		Handler: serveMux,
 	}
 	s := &InternalServer{
-		https:       https,
-		certFiles:   certFiles,
-		db:          db,
-		txCache:     txCache,
-		chain:       chain,
-		chainParser: chain.GetChainParser(),
-		mempool:     mempool,
-		is:          is,
-		api:         api,
+		https:        https,
+		certFiles:    certFiles,
+		snapshotBase: snapshotBase,
+		db:           db,
+		txCache:      txCache,
+		chain:        chain,
+		chainParser:  chain.GetChainParser(),
+		mempool:      mempool,
+		is:           is,
+		api:          api,
 	}
 
 	serveMux.Handle(path+"favicon.ico", http.FileServer(http.Dir("./static/")))
 	serveMux.HandleFunc(path+"metrics", promhttp.Handler().ServeHTTP)
+	serveMux.HandleFunc(path+"snapshot", s.snapshot)
 	serveMux.HandleFunc(path, s.index)
 
 	return s, nil
@@ -98,3 +103,51 @@ func (s *InternalServer) index(w http.ResponseWriter, r *http.Request) {
 
 	w.Write(buf)
 }
+
+// snapshotDir returns the directory of the snapshot of given name in the snapshot base directory
+// the name must be a plain file name, the paths are rejected so that the snapshot cannot be created outside of the base directory
+func (s *InternalServer) snapshotDir(name string) (string, error) {
+	if s.snapshotBase == "" {
+		return "", errors.New("Snapshots are disabled, the flag -snapshotbase is not set")
+	}
+	if name == "" {
+		return "", errors.New("Missing parameter 'name'")
+	}
+	if name == "." || name == ".." || filepath.IsAbs(name) || filepath.Base(name) != name {
+		return "", errors.New("Invalid snapshot name, only a name without a path is accepted")
+	}
+	return filepath.Join(s.snapshotBase, name), nil
+}
+
+// snapshot creates a consistent snapshot of the database in the snapshot base directory under the name given by the parameter name,
+// the snapshot must not exist, only POST requests are accepted
+func (s *InternalServer) snapshot(w http.ResponseWriter, r *http.Request) {
+	var result interface{}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	type snapshotError struct {
+		Error string `json:"error"`
+	}
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		result = snapshotError{"Only POST method is supported"}
+	} else if dir, err := s.snapshotDir(r.FormValue("name")); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		result = snapshotError{err.Error()}
+	} else {
+		si, err := s.db.CreateSnapshot(dir)
+		if err != nil {
+			glog.Error("snapshot: ", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			result = snapshotError{err.Error()}
+		} else {
+			result = si
+		}
+	}
+	buf, err := json.MarshalIndent(result, "", "    ")
+	if err != nil {
+		glog.Error(err)
+		return
+	}
+	w.Write(buf)
+}
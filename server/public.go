@@ -6,12 +6,14 @@ import (
 	"blockbook/common"
 	"blockbook/db"
 	"context"
+	"encoding/csv"
 	"encoding/json"
 	"fmt"
 	"html/template"
 	"io/ioutil"
 	"math/big"
 	"net/http"
+	"net/url"
 	"path/filepath"
 	"reflect"
 	"regexp"
@@ -27,6 +29,7 @@ import (
 const txsOnPage = 25
 const blocksOnPage = 50
 const mempoolTxsOnPage = 50
+const richListOnPage = 50
 const txsInAPI = 1000
 
 const (
@@ -141,6 +144,7 @@ func (s *PublicServer) ConnectFullPublicInterface() {
 		serveMux.HandleFunc(path+"spending/", s.htmlTemplateHandler(s.explorerSpendingTx))
 		serveMux.HandleFunc(path+"sendtx", s.htmlTemplateHandler(s.explorerSendTx))
 		serveMux.HandleFunc(path+"mempool", s.htmlTemplateHandler(s.explorerMempool))
+		serveMux.HandleFunc(path+"richlist", s.htmlTemplateHandler(s.explorerRichList))
 	} else {
 		// redirect to wallet requests for tx and address, possibly to external site
 		serveMux.HandleFunc(path+"tx/", s.txRedirect)
@@ -182,14 +186,25 @@ func (s *PublicServer) ConnectFullPublicInterface() {
 	serveMux.HandleFunc(path+"api/v2/tx/", s.jsonHandler(s.apiTx, apiV2))
 	serveMux.HandleFunc(path+"api/v2/address/", s.jsonHandler(s.apiAddress, apiV2))
 	serveMux.HandleFunc(path+"api/v2/xpub/", s.jsonHandler(s.apiXpub, apiV2))
+	serveMux.HandleFunc(path+"api/v2/addresses/", s.jsonHandler(s.apiAddresses, apiV2))
 	serveMux.HandleFunc(path+"api/v2/utxo/", s.jsonHandler(s.apiUtxo, apiV2))
 	serveMux.HandleFunc(path+"api/v2/block/", s.jsonHandler(s.apiBlock, apiV2))
 	serveMux.HandleFunc(path+"api/v2/sendtx/", s.jsonHandler(s.apiSendTx, apiV2))
 	serveMux.HandleFunc(path+"api/v2/estimatefee/", s.jsonHandler(s.apiEstimateFee, apiV2))
 	serveMux.HandleFunc(path+"api/v2/feestats/", s.jsonHandler(s.apiFeeStats, apiV2))
 	serveMux.HandleFunc(path+"api/v2/balancehistory/", s.jsonHandler(s.apiBalanceHistory, apiDefault))
+	serveMux.HandleFunc(path+"api/v2/export/", s.apiExport)
 	serveMux.HandleFunc(path+"api/v2/tickers/", s.jsonHandler(s.apiTickers, apiV2))
 	serveMux.HandleFunc(path+"api/v2/tickers-list/", s.jsonHandler(s.apiTickersList, apiV2))
+	serveMux.HandleFunc(path+"api/v2/masternode/", s.jsonHandler(s.apiMasternode, apiV2))
+	serveMux.HandleFunc(path+"api/v2/zerocoin/", s.jsonHandler(s.apiZerocoin, apiV2))
+	serveMux.HandleFunc(path+"api/v2/supply/", s.jsonHandler(s.apiSupply, apiV2))
+	serveMux.HandleFunc(path+"api/v2/superblocks/", s.jsonHandler(s.apiSuperblocks, apiV2))
+	serveMux.HandleFunc(path+"api/v2/stakers/", s.jsonHandler(s.apiStakers, apiV2))
+	serveMux.HandleFunc(path+"api/v2/richlist/", s.jsonHandler(s.apiRichList, apiV2))
+	serveMux.HandleFunc(path+"api/v2/block-by-time/", s.jsonHandler(s.apiBlockByTime, apiV2))
+	serveMux.HandleFunc(path+"api/v2/opreturn/", s.jsonHandler(s.apiOpReturn, apiV2))
+	serveMux.HandleFunc(path+"api/v2/blockfilter/", s.jsonHandler(s.apiBlockFilter, apiV2))
 	// socket.io interface
 	serveMux.Handle(path+"socket.io/", s.socketio.GetHandler())
 	// websocket interface
@@ -397,6 +412,7 @@ const (
 	blockTpl
 	sendTransactionTpl
 	mempoolTpl
+	richListTpl
 
 	tplCount
 )
@@ -416,6 +432,7 @@ type TemplateData struct {
 	Block                *api.Block
 	Info                 *api.SystemInfo
 	MempoolTxids         *api.MempoolTxids
+	RichList             *api.RichList
 	Page                 int
 	PrevPage             int
 	NextPage             int
@@ -492,6 +509,7 @@ func (s *PublicServer) parseTemplates() []*template.Template {
 	}
 	t[xpubTpl] = createTemplate("./static/templates/xpub.html", "./static/templates/txdetail.html", "./static/templates/paging.html", "./static/templates/base.html")
 	t[mempoolTpl] = createTemplate("./static/templates/mempool.html", "./static/templates/paging.html", "./static/templates/base.html")
+	t[richListTpl] = createTemplate("./static/templates/richlist.html", "./static/templates/paging.html", "./static/templates/base.html")
 	return t
 }
 
@@ -646,6 +664,7 @@ func (s *PublicServer) getAddressQueryParams(r *http.Request, accountDetails api
 		TokensToReturn: tokensToReturn,
 		FromHeight:     uint32(from),
 		ToHeight:       uint32(to),
+		Cursor:         r.URL.Query().Get("cursor"),
 	}, filterParam, gap
 }
 
@@ -717,7 +736,8 @@ func (s *PublicServer) explorerBlocks(w http.ResponseWriter, r *http.Request) (t
 	if ec != nil {
 		page = 0
 	}
-	blocks, err = s.api.GetBlocks(page, blocksOnPage)
+	filterParam := r.URL.Query().Get("filter")
+	blocks, err = s.api.GetBlocks(page, blocksOnPage, filterParam)
 	if err != nil {
 		return errorTpl, nil, err
 	}
@@ -725,9 +745,29 @@ func (s *PublicServer) explorerBlocks(w http.ResponseWriter, r *http.Request) (t
 	data.Blocks = blocks
 	data.Page = blocks.Page
 	data.PagingRange, data.PrevPage, data.NextPage = getPagingRange(blocks.Page, blocks.TotalPages)
+	if filterParam != "" {
+		data.PageParams = template.URL("&filter=" + filterParam)
+	}
 	return blocksTpl, data, nil
 }
 
+func (s *PublicServer) explorerRichList(w http.ResponseWriter, r *http.Request) (tpl, *TemplateData, error) {
+	s.metrics.ExplorerViews.With(common.Labels{"action": "richlist"}).Inc()
+	page, ec := strconv.Atoi(r.URL.Query().Get("page"))
+	if ec != nil {
+		page = 0
+	}
+	richList, err := s.api.GetRichList(page, richListOnPage)
+	if err != nil {
+		return errorTpl, nil, err
+	}
+	data := s.newTemplateData()
+	data.RichList = richList
+	data.Page = richList.Page
+	data.PagingRange, data.PrevPage, data.NextPage = getPagingRange(richList.Page, richList.TotalPages)
+	return richListTpl, data, nil
+}
+
 func (s *PublicServer) explorerBlock(w http.ResponseWriter, r *http.Request) (tpl, *TemplateData, error) {
 	var block *api.Block
 	var err error
@@ -948,6 +988,9 @@ func (s *PublicServer) apiTx(r *http.Request, apiVersion int) (interface{}, erro
 	if err == nil && apiVersion == apiV1 {
 		return s.api.TxToV1(tx), nil
 	}
+	if currency := r.URL.Query().Get("currency"); err == nil && currency != "" {
+		err = s.api.SetTxFiatValues(tx, currency)
+	}
 	return tx, err
 }
 
@@ -984,15 +1027,29 @@ func (s *PublicServer) apiAddress(r *http.Request, apiVersion int) (interface{},
 	if err == nil && apiVersion == apiV1 {
 		return s.api.AddressToV1(address), nil
 	}
+	if currency := r.URL.Query().Get("currency"); err == nil && currency != "" {
+		err = s.api.SetAddressFiatValues(address, currency)
+	}
 	return address, err
 }
 
-func (s *PublicServer) apiXpub(r *http.Request, apiVersion int) (interface{}, error) {
-	var xpub string
-	i := strings.LastIndexByte(r.URL.Path, '/')
-	if i > 0 {
-		xpub = r.URL.Path[i+1:]
+// lastPathParameter returns the unescaped last segment of the path of the request
+// the segment can contain escaped slashes, e.g. in an output descriptor
+func lastPathParameter(r *http.Request) string {
+	p := r.URL.EscapedPath()
+	i := strings.LastIndexByte(p, '/')
+	if i < 0 {
+		return ""
 	}
+	param, err := url.PathUnescape(p[i+1:])
+	if err != nil {
+		return p[i+1:]
+	}
+	return param
+}
+
+func (s *PublicServer) apiXpub(r *http.Request, apiVersion int) (interface{}, error) {
+	xpub := lastPathParameter(r)
 	if len(xpub) == 0 {
 		return nil, api.NewAPIError("Missing xpub", true)
 	}
@@ -1004,12 +1061,38 @@ func (s *PublicServer) apiXpub(r *http.Request, apiVersion int) (interface{}, er
 	if err == nil && apiVersion == apiV1 {
 		return s.api.AddressToV1(address), nil
 	}
+	if currency := r.URL.Query().Get("currency"); err == nil && currency != "" {
+		err = s.api.SetAddressFiatValues(address, currency)
+	}
 	if err == api.ErrUnsupportedXpub {
 		err = api.NewAPIError("XPUB functionality is not supported", true)
 	}
 	return address, err
 }
 
+// apiAddresses returns the account composed of the addresses posted as a JSON array or passed in the path separated by commas
+func (s *PublicServer) apiAddresses(r *http.Request, apiVersion int) (interface{}, error) {
+	var addresses []string
+	s.metrics.ExplorerViews.With(common.Labels{"action": "api-addresses"}).Inc()
+	if r.Method == http.MethodPost {
+		data, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			return nil, api.NewAPIError("Missing addresses", true)
+		}
+		if err = json.Unmarshal(data, &addresses); err != nil {
+			return nil, api.NewAPIError("Addresses must be a JSON array of strings", true)
+		}
+	} else if i := strings.LastIndexByte(r.URL.Path, '/'); i > 0 && i < len(r.URL.Path)-1 {
+		addresses = strings.Split(r.URL.Path[i+1:], ",")
+	}
+	page, pageSize, details, filter, _, _ := s.getAddressQueryParams(r, api.AccountDetailsTxidHistory, txsInAPI)
+	address, err := s.api.GetAddressesAccount(addresses, page, pageSize, details, filter)
+	if currency := r.URL.Query().Get("currency"); err == nil && currency != "" {
+		err = s.api.SetAddressFiatValues(address, currency)
+	}
+	return address, err
+}
+
 func (s *PublicServer) apiUtxo(r *http.Request, apiVersion int) (interface{}, error) {
 	var utxo []api.Utxo
 	var err error
@@ -1026,11 +1109,12 @@ func (s *PublicServer) apiUtxo(r *http.Request, apiVersion int) (interface{}, er
 		if ec != nil {
 			gap = 0
 		}
-		utxo, err = s.api.GetXpubUtxo(r.URL.Path[i+1:], onlyConfirmed, gap)
+		desc := lastPathParameter(r)
+		utxo, err = s.api.GetXpubUtxo(desc, onlyConfirmed, gap)
 		if err == nil {
 			s.metrics.ExplorerViews.With(common.Labels{"action": "api-xpub-utxo"}).Inc()
-		} else {
-			utxo, err = s.api.GetAddressUtxo(r.URL.Path[i+1:], onlyConfirmed)
+		} else if !api.IsOutputDescriptor(desc) {
+			utxo, err = s.api.GetAddressUtxo(desc, onlyConfirmed)
 			s.metrics.ExplorerViews.With(common.Labels{"action": "api-address-utxo"}).Inc()
 		}
 		if err == nil && apiVersion == apiV1 {
@@ -1059,17 +1143,214 @@ func (s *PublicServer) apiBalanceHistory(r *http.Request, apiVersion int) (inter
 			toTime, _ = time.Parse("2006-01-02", t)
 		}
 		fiat := r.URL.Query().Get("fiatcurrency")
-		history, err = s.api.GetXpubBalanceHistory(r.URL.Path[i+1:], fromTime, toTime, fiat, gap)
+		desc := lastPathParameter(r)
+		history, err = s.api.GetXpubBalanceHistory(desc, fromTime, toTime, fiat, gap)
 		if err == nil {
 			s.metrics.ExplorerViews.With(common.Labels{"action": "api-xpub-balancehistory"}).Inc()
-		} else {
-			history, err = s.api.GetBalanceHistory(r.URL.Path[i+1:], fromTime, toTime, fiat)
+		} else if !api.IsOutputDescriptor(desc) {
+			history, err = s.api.GetBalanceHistory(desc, fromTime, toTime, fiat)
 			s.metrics.ExplorerViews.With(common.Labels{"action": "api-address-balancehistory"}).Inc()
 		}
 	}
 	return history, err
 }
 
+// exportRowsToFlush is the number of the exported rows after which the output is flushed to the client
+const exportRowsToFlush = 100
+
+// apiExport streams the confirmed transaction history of an address or xpub as CSV or JSON lines
+// the handler does not use jsonHandler as the rows are written to the response as they are found
+func (s *PublicServer) apiExport(w http.ResponseWriter, r *http.Request) {
+	writeError := func(text string, status int) {
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		w.WriteHeader(status)
+		if err := json.NewEncoder(w).Encode(struct {
+			Text string `json:"error"`
+		}{text}); err != nil {
+			glog.Warning("json encode ", err)
+		}
+	}
+	defer func() {
+		if e := recover(); e != nil {
+			glog.Error("apiExport recovered from panic: ", e)
+			debug.PrintStack()
+		}
+	}()
+	s.metrics.ExplorerViews.With(common.Labels{"action": "api-export"}).Inc()
+	desc := lastPathParameter(r)
+	if len(desc) == 0 {
+		writeError("Missing address or xpub", http.StatusBadRequest)
+		return
+	}
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = "csv"
+	} else if format != "csv" && format != "jsonl" {
+		writeError("Unsupported format, use csv or jsonl", http.StatusBadRequest)
+		return
+	}
+	var fromTime, toTime time.Time
+	var err error
+	if t := r.URL.Query().Get("from"); t != "" {
+		if fromTime, err = time.Parse("2006-01-02", t); err != nil {
+			writeError("Parameter 'from' must be a date in the format YYYY-MM-DD", http.StatusBadRequest)
+			return
+		}
+	}
+	if t := r.URL.Query().Get("to"); t != "" {
+		if toTime, err = time.Parse("2006-01-02", t); err != nil {
+			writeError("Parameter 'to' must be a date in the format YYYY-MM-DD", http.StatusBadRequest)
+			return
+		}
+	}
+	fiat := r.URL.Query().Get("fiatcurrency")
+	gap, ec := strconv.Atoi(r.URL.Query().Get("gap"))
+	if ec != nil {
+		gap = 0
+	}
+	var cw *csv.Writer
+	var je *json.Encoder
+	flusher, _ := w.(http.Flusher)
+	rows := 0
+	// the headers are written with the first row so that an error found before can be returned as json
+	start := func() {
+		if format == "csv" {
+			w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+			w.Header().Set("Content-Disposition", "attachment; filename=history.csv")
+			cw = csv.NewWriter(w)
+			cw.Write([]string{"txid", "time", "height", "direction", "amount", "fee", "balance", "fiatRate", "fiatValue"})
+		} else {
+			w.Header().Set("Content-Type", "application/x-ndjson; charset=utf-8")
+			w.Header().Set("Content-Disposition", "attachment; filename=history.jsonl")
+			je = json.NewEncoder(w)
+		}
+	}
+	err = s.api.ExportHistory(desc, fromTime, toTime, fiat, gap, func(e *api.ExportEntry) error {
+		if rows == 0 {
+			start()
+		}
+		rows++
+		var err error
+		if cw != nil {
+			fee := "0"
+			if e.FeeSat != nil {
+				fee = e.FeeSat.String()
+			}
+			err = cw.Write([]string{e.Txid, strconv.FormatUint(uint64(e.Time), 10), strconv.FormatUint(uint64(e.Height), 10), e.Direction,
+				e.AmountSat.String(), fee, e.BalanceSat.String(), e.FiatRate, e.FiatValue})
+			if err == nil && rows%exportRowsToFlush == 0 {
+				cw.Flush()
+				err = cw.Error()
+			}
+		} else {
+			err = je.Encode(e)
+		}
+		if flusher != nil && rows%exportRowsToFlush == 0 {
+			flusher.Flush()
+		}
+		return err
+	})
+	if err != nil {
+		if rows > 0 {
+			// the response is already being sent, the error cannot be returned to the client
+			glog.Error("apiExport error: ", err)
+		} else if apiErr, ok := err.(*api.APIError); ok && apiErr.Public {
+			writeError(apiErr.Error(), http.StatusBadRequest)
+			return
+		} else {
+			glog.Error("apiExport error: ", err)
+			if s.debug {
+				writeError(fmt.Sprintf("Internal server error: %v", err), http.StatusInternalServerError)
+			} else {
+				writeError("Internal server error", http.StatusInternalServerError)
+			}
+			return
+		}
+	}
+	if rows == 0 {
+		start()
+	}
+	if cw != nil {
+		cw.Flush()
+	}
+}
+
+func (s *PublicServer) apiMasternode(r *http.Request, apiVersion int) (interface{}, error) {
+	var masternode *api.Masternode
+	var err error
+	s.metrics.ExplorerViews.With(common.Labels{"action": "api-masternode"}).Inc()
+	if i := strings.LastIndexByte(r.URL.Path, '/'); i > 0 {
+		masternode, err = s.api.GetMasternode(r.URL.Path[i+1:])
+	}
+	return masternode, err
+}
+
+func (s *PublicServer) apiZerocoin(r *http.Request, apiVersion int) (interface{}, error) {
+	s.metrics.ExplorerViews.With(common.Labels{"action": "api-zerocoin"}).Inc()
+	return s.api.GetZerocoinPool(r.URL.Query().Get("height"))
+}
+
+func (s *PublicServer) apiSupply(r *http.Request, apiVersion int) (interface{}, error) {
+	s.metrics.ExplorerViews.With(common.Labels{"action": "api-supply"}).Inc()
+	return s.api.GetSupply(r.URL.Query().Get("height"))
+}
+
+func (s *PublicServer) apiSuperblocks(r *http.Request, apiVersion int) (interface{}, error) {
+	s.metrics.ExplorerViews.With(common.Labels{"action": "api-superblocks"}).Inc()
+	return s.api.GetSuperblocks()
+}
+
+func (s *PublicServer) apiStakers(r *http.Request, apiVersion int) (interface{}, error) {
+	var fromTime, toTime time.Time
+	s.metrics.ExplorerViews.With(common.Labels{"action": "api-stakers"}).Inc()
+	q := r.URL.Query()
+	if t := q.Get("from"); t != "" {
+		fromTime, _ = time.Parse("2006-01-02", t)
+	}
+	if t := q.Get("to"); t != "" {
+		toTime, _ = time.Parse("2006-01-02", t)
+	}
+	return s.api.GetStakers(q.Get("fromHeight"), q.Get("toHeight"), fromTime, toTime)
+}
+
+func (s *PublicServer) apiBlockByTime(r *http.Request, apiVersion int) (interface{}, error) {
+	var unixTime string
+	s.metrics.ExplorerViews.With(common.Labels{"action": "api-block-by-time"}).Inc()
+	if i := strings.LastIndexByte(r.URL.Path, '/'); i > 0 {
+		unixTime = r.URL.Path[i+1:]
+	}
+	return s.api.GetBlockByTime(unixTime)
+}
+
+func (s *PublicServer) apiBlockFilter(r *http.Request, apiVersion int) (interface{}, error) {
+	var height string
+	s.metrics.ExplorerViews.With(common.Labels{"action": "api-blockfilter"}).Inc()
+	if i := strings.LastIndexByte(r.URL.Path, '/'); i > 0 {
+		height = r.URL.Path[i+1:]
+	}
+	return s.api.GetBlockFilter(height)
+}
+
+func (s *PublicServer) apiRichList(r *http.Request, apiVersion int) (interface{}, error) {
+	s.metrics.ExplorerViews.With(common.Labels{"action": "api-richlist"}).Inc()
+	page, ec := strconv.Atoi(r.URL.Query().Get("page"))
+	if ec != nil {
+		page = 0
+	}
+	return s.api.GetRichList(page, richListOnPage)
+}
+
+func (s *PublicServer) apiOpReturn(r *http.Request, apiVersion int) (interface{}, error) {
+	var opReturnTxs *api.OpReturnTxs
+	var err error
+	s.metrics.ExplorerViews.With(common.Labels{"action": "api-opreturn"}).Inc()
+	if i := strings.LastIndexByte(r.URL.Path, '/'); i > 0 {
+		page, pageSize, _, filter, _, _ := s.getAddressQueryParams(r, api.AccountDetailsBasic, txsInAPI)
+		opReturnTxs, err = s.api.GetOpReturnTxs(r.URL.Path[i+1:], page, pageSize, filter.FromHeight, filter.ToHeight)
+	}
+	return opReturnTxs, err
+}
+
 func (s *PublicServer) apiBlock(r *http.Request, apiVersion int) (interface{}, error) {
 	var block *api.Block
 	var err error
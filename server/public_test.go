@@ -51,10 +51,6 @@ func setupRocksDB(t *testing.T, parser bchain.BlockChainParser) (*db.RocksDB, *c
 	}
 	d.SetInternalState(is)
 	block1 := dbtestdata.GetTestBitcoinTypeBlock1(parser)
-	// setup internal state BlockTimes
-	for i := uint32(0); i < block1.Height; i++ {
-		is.BlockTimes = append(is.BlockTimes, 0)
-	}
 	// import data
 	if err := d.ConnectBlock(block1); err != nil {
 		t.Fatal(err)
@@ -491,6 +487,25 @@ func httpTestsBitcoinType(t *testing.T, ts *httptest.Server) {
 				`{"txid":"05e2e48aeabdd9b75def7b48d756ba304713c2aba7b522bf9dbc893fc4231b07","vin":[{"txid":"effd9ef509383d536b1c8af5bf434c8efbf521a4f2befd4022bbd68694b4ac75","vout":2,"n":0,"addresses":["2NEVv9LJmAnY99W1pFoc5UJjVdypBqdnvu1"],"isAddress":true,"value":"9876"}],"vout":[{"value":"9000","n":0,"hex":"a914e921fc4912a315078f370d959f2c4f7b6d2a683c87","addresses":["2NEVv9LJmAnY99W1pFoc5UJjVdypBqdnvu1"],"isAddress":true}],"blockHash":"00000000eb0443fd7dc4a1ed5c686a8e995057805f9a161d9a5a77a95e72b7b6","blockHeight":225494,"confirmations":1,"blockTime":1521595678,"value":"9000","valueIn":"9876","fees":"876"}`,
 			},
 		},
+		{
+			name:        "apiTx v2 currency=usd",
+			r:           newGetRequest(ts.URL + "/api/v2/tx/05e2e48aeabdd9b75def7b48d756ba304713c2aba7b522bf9dbc893fc4231b07?currency=usd"),
+			status:      http.StatusOK,
+			contentType: "application/json; charset=utf-8",
+			body: []string{
+				`"txid":"05e2e48aeabdd9b75def7b48d756ba304713c2aba7b522bf9dbc893fc4231b07"`,
+				`"fiatValues":{"currency":"usd","rate":"2003.0","value":"0.18","fees":"0.02"}`,
+			},
+		},
+		{
+			name:        "apiTx v2 unsupported currency",
+			r:           newGetRequest(ts.URL + "/api/v2/tx/05e2e48aeabdd9b75def7b48d756ba304713c2aba7b522bf9dbc893fc4231b07?currency=xyz"),
+			status:      http.StatusBadRequest,
+			contentType: "application/json; charset=utf-8",
+			body: []string{
+				`{"error":"Unsupported currency xyz"}`,
+			},
+		},
 		{
 			name:        "apiTx - not found v2",
 			r:           newGetRequest(ts.URL + "/api/v2/tx/1232e48aeabdd9b75def7b48d756ba304713c2aba7b522bf9dbc893fc4231b07"),
@@ -632,7 +647,7 @@ func httpTestsBitcoinType(t *testing.T, ts *httptest.Server) {
 			status:      http.StatusOK,
 			contentType: "application/json; charset=utf-8",
 			body: []string{
-				`{"page":1,"totalPages":1,"itemsOnPage":1000,"address":"mv9uLThosiEnGRbVPS7Vhyw6VssbVRsiAw","balance":"0","totalReceived":"1234567890123","totalSent":"1234567890123","unconfirmedBalance":"0","unconfirmedTxs":0,"txs":2,"txids":["7c3be24063f268aaa1ed81b64776798f56088757641a34fb156c4f51ed2e9d25","effd9ef509383d536b1c8af5bf434c8efbf521a4f2befd4022bbd68694b4ac75"]}`,
+				`{"page":1,"totalPages":1,"itemsOnPage":1000,"address":"mv9uLThosiEnGRbVPS7Vhyw6VssbVRsiAw","balance":"0","totalReceived":"1234567890123","totalSent":"1234567890123","unconfirmedBalance":"0","unconfirmedTxs":0,"txs":2,"firstSeenHeight":225493,"lastActivityHeight":225494,"txids":["7c3be24063f268aaa1ed81b64776798f56088757641a34fb156c4f51ed2e9d25","effd9ef509383d536b1c8af5bf434c8efbf521a4f2befd4022bbd68694b4ac75"]}`,
 			},
 		},
 		{
@@ -641,7 +656,7 @@ func httpTestsBitcoinType(t *testing.T, ts *httptest.Server) {
 			status:      http.StatusOK,
 			contentType: "application/json; charset=utf-8",
 			body: []string{
-				`{"address":"mv9uLThosiEnGRbVPS7Vhyw6VssbVRsiAw","balance":"0","totalReceived":"1234567890123","totalSent":"1234567890123","unconfirmedBalance":"0","unconfirmedTxs":0,"txs":2}`,
+				`{"address":"mv9uLThosiEnGRbVPS7Vhyw6VssbVRsiAw","balance":"0","totalReceived":"1234567890123","totalSent":"1234567890123","unconfirmedBalance":"0","unconfirmedTxs":0,"txs":2,"firstSeenHeight":225493,"lastActivityHeight":225494}`,
 			},
 		},
 		{
@@ -650,7 +665,44 @@ func httpTestsBitcoinType(t *testing.T, ts *httptest.Server) {
 			status:      http.StatusOK,
 			contentType: "application/json; charset=utf-8",
 			body: []string{
-				`{"page":1,"totalPages":1,"itemsOnPage":1000,"address":"mv9uLThosiEnGRbVPS7Vhyw6VssbVRsiAw","balance":"0","totalReceived":"1234567890123","totalSent":"1234567890123","unconfirmedBalance":"0","unconfirmedTxs":0,"txs":2,"transactions":[{"txid":"7c3be24063f268aaa1ed81b64776798f56088757641a34fb156c4f51ed2e9d25","vin":[{"txid":"effd9ef509383d536b1c8af5bf434c8efbf521a4f2befd4022bbd68694b4ac75","n":0,"addresses":["mv9uLThosiEnGRbVPS7Vhyw6VssbVRsiAw"],"isAddress":true,"value":"1234567890123"},{"txid":"00b2c06055e5e90e9c82bd4181fde310104391a7fa4f289b1704e5d90caa3840","vout":1,"n":1,"addresses":["mtGXQvBowMkBpnhLckhxhbwYK44Gs9eEtz"],"isAddress":true,"value":"12345"}],"vout":[{"value":"317283951061","n":0,"spent":true,"hex":"76a914ccaaaf374e1b06cb83118453d102587b4273d09588ac","addresses":["mzB8cYrfRwFRFAGTDzV8LkUQy5BQicxGhX"],"isAddress":true},{"value":"917283951061","n":1,"hex":"76a9148d802c045445df49613f6a70ddd2e48526f3701f88ac","addresses":["mtR97eM2HPWVM6c8FGLGcukgaHHQv7THoL"],"isAddress":true},{"value":"0","n":2,"hex":"6a072020f1686f6a20","addresses":["OP_RETURN 2020f1686f6a20"],"isAddress":false}],"blockHash":"00000000eb0443fd7dc4a1ed5c686a8e995057805f9a161d9a5a77a95e72b7b6","blockHeight":225494,"confirmations":1,"blockTime":1521595678,"value":"1234567902122","valueIn":"1234567902468","fees":"346"},{"txid":"effd9ef509383d536b1c8af5bf434c8efbf521a4f2befd4022bbd68694b4ac75","vin":[],"vout":[{"value":"1234567890123","n":0,"spent":true,"hex":"76a914a08eae93007f22668ab5e4a9c83c8cd1c325e3e088ac","addresses":["mv9uLThosiEnGRbVPS7Vhyw6VssbVRsiAw"],"isAddress":true},{"value":"1","n":1,"spent":true,"hex":"a91452724c5178682f70e0ba31c6ec0633755a3b41d987","addresses":["2MzmAKayJmja784jyHvRUW1bXPget1csRRG"],"isAddress":true},{"value":"9876","n":2,"spent":true,"hex":"a914e921fc4912a315078f370d959f2c4f7b6d2a683c87","addresses":["2NEVv9LJmAnY99W1pFoc5UJjVdypBqdnvu1"],"isAddress":true}],"blockHash":"0000000076fbbed90fd75b0e18856aa35baa984e9c9d444cf746ad85e94e2997","blockHeight":225493,"confirmations":2,"blockTime":1521515026,"value":"1234567900000","valueIn":"0","fees":"0"}]}`,
+				`{"page":1,"totalPages":1,"itemsOnPage":1000,"address":"mv9uLThosiEnGRbVPS7Vhyw6VssbVRsiAw","balance":"0","totalReceived":"1234567890123","totalSent":"1234567890123","unconfirmedBalance":"0","unconfirmedTxs":0,"txs":2,"firstSeenHeight":225493,"lastActivityHeight":225494,"transactions":[{"txid":"7c3be24063f268aaa1ed81b64776798f56088757641a34fb156c4f51ed2e9d25","vin":[{"txid":"effd9ef509383d536b1c8af5bf434c8efbf521a4f2befd4022bbd68694b4ac75","n":0,"addresses":["mv9uLThosiEnGRbVPS7Vhyw6VssbVRsiAw"],"isAddress":true,"value":"1234567890123"},{"txid":"00b2c06055e5e90e9c82bd4181fde310104391a7fa4f289b1704e5d90caa3840","vout":1,"n":1,"addresses":["mtGXQvBowMkBpnhLckhxhbwYK44Gs9eEtz"],"isAddress":true,"value":"12345"}],"vout":[{"value":"317283951061","n":0,"spent":true,"hex":"76a914ccaaaf374e1b06cb83118453d102587b4273d09588ac","addresses":["mzB8cYrfRwFRFAGTDzV8LkUQy5BQicxGhX"],"isAddress":true},{"value":"917283951061","n":1,"hex":"76a9148d802c045445df49613f6a70ddd2e48526f3701f88ac","addresses":["mtR97eM2HPWVM6c8FGLGcukgaHHQv7THoL"],"isAddress":true},{"value":"0","n":2,"hex":"6a072020f1686f6a20","addresses":["OP_RETURN 2020f1686f6a20"],"isAddress":false}],"blockHash":"00000000eb0443fd7dc4a1ed5c686a8e995057805f9a161d9a5a77a95e72b7b6","blockHeight":225494,"confirmations":1,"blockTime":1521595678,"value":"1234567902122","valueIn":"1234567902468","fees":"346"},{"txid":"effd9ef509383d536b1c8af5bf434c8efbf521a4f2befd4022bbd68694b4ac75","vin":[],"vout":[{"value":"1234567890123","n":0,"spent":true,"hex":"76a914a08eae93007f22668ab5e4a9c83c8cd1c325e3e088ac","addresses":["mv9uLThosiEnGRbVPS7Vhyw6VssbVRsiAw"],"isAddress":true},{"value":"1","n":1,"spent":true,"hex":"a91452724c5178682f70e0ba31c6ec0633755a3b41d987","addresses":["2MzmAKayJmja784jyHvRUW1bXPget1csRRG"],"isAddress":true},{"value":"9876","n":2,"spent":true,"hex":"a914e921fc4912a315078f370d959f2c4f7b6d2a683c87","addresses":["2NEVv9LJmAnY99W1pFoc5UJjVdypBqdnvu1"],"isAddress":true}],"blockHash":"0000000076fbbed90fd75b0e18856aa35baa984e9c9d444cf746ad85e94e2997","blockHeight":225493,"confirmations":2,"blockTime":1521515026,"value":"1234567900000","valueIn":"0","fees":"0"}]}`,
+			},
+		},
+		{
+			name:        "apiAddress v2 pageSize=1",
+			r:           newGetRequest(ts.URL + "/api/v2/address/mv9uLThosiEnGRbVPS7Vhyw6VssbVRsiAw?pageSize=1"),
+			status:      http.StatusOK,
+			contentType: "application/json; charset=utf-8",
+			body: []string{
+				`{"page":1,"totalPages":2,"itemsOnPage":1,"nextCursor":"AANw1gAAAAE","address":"mv9uLThosiEnGRbVPS7Vhyw6VssbVRsiAw","balance":"0","totalReceived":"1234567890123","totalSent":"1234567890123","unconfirmedBalance":"0","unconfirmedTxs":0,"txs":2,"firstSeenHeight":225493,"lastActivityHeight":225494,"txids":["7c3be24063f268aaa1ed81b64776798f56088757641a34fb156c4f51ed2e9d25"]}`,
+			},
+		},
+		{
+			name:        "apiAddress v2 pageSize=1&cursor",
+			r:           newGetRequest(ts.URL + "/api/v2/address/mv9uLThosiEnGRbVPS7Vhyw6VssbVRsiAw?pageSize=1&cursor=AANw1gAAAAE"),
+			status:      http.StatusOK,
+			contentType: "application/json; charset=utf-8",
+			body: []string{
+				`{"itemsOnPage":1,"address":"mv9uLThosiEnGRbVPS7Vhyw6VssbVRsiAw","balance":"0","totalReceived":"1234567890123","totalSent":"1234567890123","unconfirmedBalance":"0","unconfirmedTxs":0,"txs":2,"firstSeenHeight":225493,"lastActivityHeight":225494,"txids":["effd9ef509383d536b1c8af5bf434c8efbf521a4f2befd4022bbd68694b4ac75"]}`,
+			},
+		},
+		{
+			name:        "apiAddress v2 invalid cursor",
+			r:           newGetRequest(ts.URL + "/api/v2/address/mv9uLThosiEnGRbVPS7Vhyw6VssbVRsiAw?cursor=xyz"),
+			status:      http.StatusBadRequest,
+			contentType: "application/json; charset=utf-8",
+			body: []string{
+				`{"error":"Invalid cursor"}`,
+			},
+		},
+		{
+			name:        "apiAddress v2 details=txs&pageSize=1&currency=usd",
+			r:           newGetRequest(ts.URL + "/api/v2/address/mv9uLThosiEnGRbVPS7Vhyw6VssbVRsiAw?details=txs&pageSize=1&currency=usd"),
+			status:      http.StatusOK,
+			contentType: "application/json; charset=utf-8",
+			body: []string{
+				`"lastActivityHeight":225494,"fiatValues":{"currency":"usd","rate":"7914.5","balance":"0.00"},"transactions":[{"txid":"7c3be24063f268aaa1ed81b64776798f56088757641a34fb156c4f51ed2e9d25"`,
+				`"fiatValues":{"currency":"usd","rate":"2003.0","value":"24728395.08","fees":"0.01","amount":"-24728394.84"}`,
 			},
 		},
 		{
@@ -734,6 +786,51 @@ func httpTestsBitcoinType(t *testing.T, ts *httptest.Server) {
 				`{"error":"Missing xpub"}`,
 			},
 		},
+		{
+			name:        "apiAddresses",
+			r:           newPostRequest(ts.URL+"/api/v2/addresses/", `["mv9uLThosiEnGRbVPS7Vhyw6VssbVRsiAw","2MzmAKayJmja784jyHvRUW1bXPget1csRRG","mv9uLThosiEnGRbVPS7Vhyw6VssbVRsiAw"]`),
+			status:      http.StatusOK,
+			contentType: "application/json; charset=utf-8",
+			body: []string{
+				`{"page":1,"totalPages":1,"itemsOnPage":1000,"address":"mv9uLThosiEnGRbVPS7Vhyw6VssbVRsiAw,2MzmAKayJmja784jyHvRUW1bXPget1csRRG","balance":"0","totalReceived":"1234567890124","totalSent":"1234567890124","unconfirmedBalance":"0","unconfirmedTxs":0,"txs":3,"txids":["3d90d15ed026dc45e19ffb52875ed18fa9e8012ad123d7f7212176e2b0ebdb71","7c3be24063f268aaa1ed81b64776798f56088757641a34fb156c4f51ed2e9d25","effd9ef509383d536b1c8af5bf434c8efbf521a4f2befd4022bbd68694b4ac75"],"usedTokens":2}`,
+			},
+		},
+		{
+			name:        "apiAddresses details=tokenBalances",
+			r:           newGetRequest(ts.URL + "/api/v2/addresses/mv9uLThosiEnGRbVPS7Vhyw6VssbVRsiAw,2MzmAKayJmja784jyHvRUW1bXPget1csRRG?details=tokenBalances&tokens=used"),
+			status:      http.StatusOK,
+			contentType: "application/json; charset=utf-8",
+			body: []string{
+				`{"address":"mv9uLThosiEnGRbVPS7Vhyw6VssbVRsiAw,2MzmAKayJmja784jyHvRUW1bXPget1csRRG","balance":"0","totalReceived":"1234567890124","totalSent":"1234567890124","unconfirmedBalance":"0","unconfirmedTxs":0,"txs":4,"usedTokens":2,"tokens":[{"type":"Address","name":"mv9uLThosiEnGRbVPS7Vhyw6VssbVRsiAw","transfers":2,"decimals":8,"balance":"0","totalReceived":"1234567890123","totalSent":"1234567890123"},{"type":"Address","name":"2MzmAKayJmja784jyHvRUW1bXPget1csRRG","transfers":2,"decimals":8,"balance":"0","totalReceived":"1","totalSent":"1"}]}`,
+			},
+		},
+		{
+			name:        "apiAddresses pageSize=2",
+			r:           newPostRequest(ts.URL+"/api/v2/addresses/?pageSize=2", `["mv9uLThosiEnGRbVPS7Vhyw6VssbVRsiAw","2MzmAKayJmja784jyHvRUW1bXPget1csRRG"]`),
+			status:      http.StatusOK,
+			contentType: "application/json; charset=utf-8",
+			body: []string{
+				`{"page":1,"totalPages":2,"itemsOnPage":2,"nextCursor":"AANw1gAAAAI","address":"mv9uLThosiEnGRbVPS7Vhyw6VssbVRsiAw,2MzmAKayJmja784jyHvRUW1bXPget1csRRG","balance":"0","totalReceived":"1234567890124","totalSent":"1234567890124","unconfirmedBalance":"0","unconfirmedTxs":0,"txs":3,"txids":["3d90d15ed026dc45e19ffb52875ed18fa9e8012ad123d7f7212176e2b0ebdb71","7c3be24063f268aaa1ed81b64776798f56088757641a34fb156c4f51ed2e9d25"],"usedTokens":2}`,
+			},
+		},
+		{
+			name:        "apiAddresses pageSize=2&cursor",
+			r:           newPostRequest(ts.URL+"/api/v2/addresses/?pageSize=2&cursor=AANw1gAAAAI", `["mv9uLThosiEnGRbVPS7Vhyw6VssbVRsiAw","2MzmAKayJmja784jyHvRUW1bXPget1csRRG"]`),
+			status:      http.StatusOK,
+			contentType: "application/json; charset=utf-8",
+			body: []string{
+				`{"itemsOnPage":2,"address":"mv9uLThosiEnGRbVPS7Vhyw6VssbVRsiAw,2MzmAKayJmja784jyHvRUW1bXPget1csRRG","balance":"0","totalReceived":"1234567890124","totalSent":"1234567890124","unconfirmedBalance":"0","unconfirmedTxs":0,"txs":3,"txids":["effd9ef509383d536b1c8af5bf434c8efbf521a4f2befd4022bbd68694b4ac75"],"usedTokens":2}`,
+			},
+		},
+		{
+			name:        "apiAddresses missing addresses",
+			r:           newPostRequest(ts.URL+"/api/v2/addresses/", `[]`),
+			status:      http.StatusBadRequest,
+			contentType: "application/json; charset=utf-8",
+			body: []string{
+				`{"error":"Missing addresses"}`,
+			},
+		},
 		{
 			name:        "apiUtxo v1",
 			r:           newGetRequest(ts.URL + "/api/v1/utxo/mtR97eM2HPWVM6c8FGLGcukgaHHQv7THoL"),
@@ -833,6 +930,47 @@ func httpTestsBitcoinType(t *testing.T, ts *httptest.Server) {
 				`[{"time":1521594000,"txs":1,"received":"118641975500","sent":"1"}]`,
 			},
 		},
+		{
+			name:        "apiExport Addr4 csv",
+			r:           newGetRequest(ts.URL + "/api/v2/export/mv9uLThosiEnGRbVPS7Vhyw6VssbVRsiAw"),
+			status:      http.StatusOK,
+			contentType: "text/csv; charset=utf-8",
+			body: []string{
+				"txid,time,height,direction,amount,fee,balance,fiatRate,fiatValue\n" +
+					"7c3be24063f268aaa1ed81b64776798f56088757641a34fb156c4f51ed2e9d25,1521595678,225494,sent,-1234567890123,346,0,,\n" +
+					"effd9ef509383d536b1c8af5bf434c8efbf521a4f2befd4022bbd68694b4ac75,1521515026,225493,received,1234567890123,0,1234567890123,,\n",
+			},
+		},
+		{
+			name:        "apiExport Addr4 jsonl fiatcurrency=usd",
+			r:           newGetRequest(ts.URL + "/api/v2/export/mv9uLThosiEnGRbVPS7Vhyw6VssbVRsiAw?format=jsonl&fiatcurrency=usd"),
+			status:      http.StatusOK,
+			contentType: "application/x-ndjson; charset=utf-8",
+			body: []string{
+				`{"txid":"7c3be24063f268aaa1ed81b64776798f56088757641a34fb156c4f51ed2e9d25","time":1521595678,"height":225494,"direction":"sent","amount":"-1234567890123","fee":"346","balance":"0","fiatRate":"2003.0","fiatValue":"-24728394.84"}
+{"txid":"effd9ef509383d536b1c8af5bf434c8efbf521a4f2befd4022bbd68694b4ac75","time":1521515026,"height":225493,"direction":"received","amount":"1234567890123","balance":"1234567890123","fiatRate":"2002.0","fiatValue":"24716049.16"}
+`,
+			},
+		},
+		{
+			name:        "apiExport xpub jsonl from=2018-03-21",
+			r:           newGetRequest(ts.URL + "/api/v2/export/" + dbtestdata.Xpub + "?format=jsonl&from=2018-03-21"),
+			status:      http.StatusOK,
+			contentType: "application/x-ndjson; charset=utf-8",
+			body: []string{
+				`{"txid":"3d90d15ed026dc45e19ffb52875ed18fa9e8012ad123d7f7212176e2b0ebdb71","time":1521595678,"height":225494,"direction":"received","amount":"118641975499","fee":"62","balance":"118641975500"}
+`,
+			},
+		},
+		{
+			name:        "apiExport invalid format",
+			r:           newGetRequest(ts.URL + "/api/v2/export/mv9uLThosiEnGRbVPS7Vhyw6VssbVRsiAw?format=xml"),
+			status:      http.StatusBadRequest,
+			contentType: "application/json; charset=utf-8",
+			body: []string{
+				`{"error":"Unsupported format, use csv or jsonl"}`,
+			},
+		},
 		{
 			name:        "apiSendTx",
 			r:           newGetRequest(ts.URL + "/api/v2/sendtx/1234567890"),
@@ -1067,7 +1205,7 @@ func websocketTestsBitcoinType(t *testing.T, ts *httptest.Server) {
 					"details":    "txids",
 				},
 			},
-			want: `{"id":"3","data":{"page":1,"totalPages":1,"itemsOnPage":25,"address":"2MzmAKayJmja784jyHvRUW1bXPget1csRRG","balance":"0","totalReceived":"1","totalSent":"1","unconfirmedBalance":"0","unconfirmedTxs":0,"txs":2,"txids":["3d90d15ed026dc45e19ffb52875ed18fa9e8012ad123d7f7212176e2b0ebdb71","effd9ef509383d536b1c8af5bf434c8efbf521a4f2befd4022bbd68694b4ac75"]}}`,
+			want: `{"id":"3","data":{"page":1,"totalPages":1,"itemsOnPage":25,"address":"2MzmAKayJmja784jyHvRUW1bXPget1csRRG","balance":"0","totalReceived":"1","totalSent":"1","unconfirmedBalance":"0","unconfirmedTxs":0,"txs":2,"firstSeenHeight":225493,"lastActivityHeight":225494,"txids":["3d90d15ed026dc45e19ffb52875ed18fa9e8012ad123d7f7212176e2b0ebdb71","effd9ef509383d536b1c8af5bf434c8efbf521a4f2befd4022bbd68694b4ac75"]}}`,
 		},
 		{
 			name: "websocket getAccountInfo xpub",
@@ -1375,6 +1513,36 @@ func websocketTestsBitcoinType(t *testing.T, ts *httptest.Server) {
 			},
 			want: `{"id":"33","data":[{"time":1521514800,"txs":1,"received":"1","sent":"0","fiatRate":"2001.0"}]}`,
 		},
+		{
+			name: "websocket getSupply best block",
+			req: websocketReq{
+				Method: "getSupply",
+				Params: map[string]interface{}{
+					"height": "",
+				},
+			},
+			want: `{"id":"34","data":{"height":225494,"issued":"1236027941392","burned":"0","circulating":"1236027941392"}}`,
+		},
+		{
+			name: "websocket getSupply height string",
+			req: websocketReq{
+				Method: "getSupply",
+				Params: map[string]interface{}{
+					"height": "225493",
+				},
+			},
+			want: `{"id":"35","data":{"height":225493,"issued":"1234667912345","burned":"0","circulating":"1234667912345"}}`,
+		},
+		{
+			name: "websocket getSupply height number",
+			req: websocketReq{
+				Method: "getSupply",
+				Params: map[string]interface{}{
+					"height": 225494,
+				},
+			},
+			want: `{"id":"36","data":{"height":225494,"issued":"1236027941392","burned":"0","circulating":"1236027941392"}}`,
+		},
 	}
 
 	// send all requests at once
@@ -41,6 +41,26 @@ type websocketRes struct {
 	Data interface{} `json:"data"`
 }
 
+// numberOrString is a numeric request parameter sent either as a number or as a string, the empty string or null means the value is not set
+type numberOrString string
+
+func (n *numberOrString) UnmarshalJSON(b []byte) error {
+	if len(b) > 0 && b[0] == '"' {
+		var s string
+		if err := json.Unmarshal(b, &s); err != nil {
+			return err
+		}
+		*n = numberOrString(s)
+		return nil
+	}
+	var num json.Number
+	if err := json.Unmarshal(b, &num); err != nil {
+		return err
+	}
+	*n = numberOrString(num)
+	return nil
+}
+
 type websocketChannel struct {
 	id            uint64
 	conn          *websocket.Conn
@@ -243,6 +263,36 @@ var requestHandlers = map[string]func(*WebsocketServer, *websocketChannel, *webs
 		}
 		return
 	},
+	"getSupply": func(s *WebsocketServer, c *websocketChannel, req *websocketReq) (rv interface{}, err error) {
+		r := struct {
+			Height numberOrString `json:"height"`
+		}{}
+		err = json.Unmarshal(req.Params, &r)
+		if err == nil {
+			rv, err = s.api.GetSupply(string(r.Height))
+		}
+		return
+	},
+	"getBlockByTime": func(s *WebsocketServer, c *websocketChannel, req *websocketReq) (rv interface{}, err error) {
+		r := struct {
+			Time json.Number `json:"time"`
+		}{}
+		err = json.Unmarshal(req.Params, &r)
+		if err == nil {
+			rv, err = s.api.GetBlockByTime(r.Time.String())
+		}
+		return
+	},
+	"getBlockFilter": func(s *WebsocketServer, c *websocketChannel, req *websocketReq) (rv interface{}, err error) {
+		r := struct {
+			Height json.Number `json:"height"`
+		}{}
+		err = json.Unmarshal(req.Params, &r)
+		if err == nil {
+			rv, err = s.api.GetBlockFilter(r.Height.String())
+		}
+		return
+	},
 	"getAccountUtxo": func(s *WebsocketServer, c *websocketChannel, req *websocketReq) (rv interface{}, err error) {
 		r := struct {
 			Descriptor string `json:"descriptor"`
@@ -277,7 +327,7 @@ var requestHandlers = map[string]func(*WebsocketServer, *websocketChannel, *webs
 				}
 			}
 			rv, err = s.api.GetXpubBalanceHistory(r.Descriptor, fromTime, toTime, r.Fiat, r.Gap)
-			if err != nil {
+			if err != nil && !api.IsOutputDescriptor(r.Descriptor) {
 				rv, err = s.api.GetBalanceHistory(r.Descriptor, fromTime, toTime, r.Fiat)
 			}
 		}
@@ -431,15 +481,17 @@ func (s *WebsocketServer) onRequest(c *websocketChannel, req *websocketReq) {
 }
 
 type accountInfoReq struct {
-	Descriptor     string `json:"descriptor"`
-	Details        string `json:"details"`
-	Tokens         string `json:"tokens"`
-	PageSize       int    `json:"pageSize"`
-	Page           int    `json:"page"`
-	FromHeight     int    `json:"from"`
-	ToHeight       int    `json:"to"`
-	ContractFilter string `json:"contractFilter"`
-	Gap            int    `json:"gap"`
+	Descriptor     string   `json:"descriptor"`
+	Addresses      []string `json:"addresses"`
+	Details        string   `json:"details"`
+	Tokens         string   `json:"tokens"`
+	PageSize       int      `json:"pageSize"`
+	Page           int      `json:"page"`
+	Cursor         string   `json:"cursor"`
+	FromHeight     int      `json:"from"`
+	ToHeight       int      `json:"to"`
+	ContractFilter string   `json:"contractFilter"`
+	Gap            int      `json:"gap"`
 }
 
 func unmarshalGetAccountInfoRequest(params []byte) (*accountInfoReq, error) {
@@ -480,23 +532,27 @@ func (s *WebsocketServer) getAccountInfo(req *accountInfoReq) (res *api.Address,
 		Contract:       req.ContractFilter,
 		Vout:           api.AddressFilterVoutOff,
 		TokensToReturn: tokensToReturn,
+		Cursor:         req.Cursor,
 	}
 	if req.PageSize == 0 {
 		req.PageSize = txsOnPage
 	}
+	if len(req.Addresses) > 0 {
+		return s.api.GetAddressesAccount(req.Addresses, req.Page, req.PageSize, opt, &filter)
+	}
 	a, err := s.api.GetXpubAddress(req.Descriptor, req.Page, req.PageSize, opt, &filter, req.Gap)
-	if err != nil {
+	if err != nil && !api.IsOutputDescriptor(req.Descriptor) {
 		return s.api.GetAddress(req.Descriptor, req.Page, req.PageSize, opt, &filter)
 	}
-	return a, nil
+	return a, err
 }
 
 func (s *WebsocketServer) getAccountUtxo(descriptor string) (interface{}, error) {
 	utxo, err := s.api.GetXpubUtxo(descriptor, false, 0)
-	if err != nil {
+	if err != nil && !api.IsOutputDescriptor(descriptor) {
 		return s.api.GetAddressUtxo(descriptor, false)
 	}
-	return utxo, nil
+	return utxo, err
 }
 
 func (s *WebsocketServer) getTransaction(txid string) (interface{}, error) {
@@ -0,0 +1,407 @@
+package dbtestdata
+
+import (
+	"blockbook/bchain"
+	"blockbook/bchain/coins/pivx"
+	"math/big"
+)
+
+// PIVX proof of stake test data, the scripts are stored directly as hex
+const (
+	PivxTxidB1T1 = "6ab9bfd1f4b1ba8b4a29e5a6a0ff1ad58b1f2327e0ae6d6cbd3cb0c4baa7a3b1"
+	PivxTxidB1T2 = "2a1c4d4a3bd1cbb9c0a96b6d3acd2f9a08c5b5a9c4cf6fd4a2e9e1b3ba1e57c2"
+	PivxTxidB2T1 = "8f3c1e0b5dd4b3a2c1f0e9d8c7b6a5f4e3d2c1b0a9f8e7d6c5b4a3f2e1d0c9b3"
+	PivxTxidB2T2 = "1d8b7f1a2e5c4b3d6a9f8e7c0b1a2d3e4f5a6b7c8d9e0f1a2b3c4d5e6f7a8b94"
+	PivxTxidB3T1 = "c4a0f7e6d5c4b3a29180f7e6d5c4b3a29180f7e6d5c4b3a29180f7e6d5c4b3a5"
+	PivxTxidB3T2 = "5e2d1c0b9a8f7e6d5c4b3a291807f6e5d4c3b2a1908f7e6d5c4b3a2918070f16"
+	PivxTxidB4T1 = "a7b6c5d4e3f2a1b0c9d8e7f6a5b4c3d2e1f0a9b8c7d6e5f4a3b2c1d0e9f8a7b7"
+	PivxTxidB4T2 = "3b4c5d6e7f8091a2b3c4d5e6f708192a3b4c5d6e7f8091a2b3c4d5e6f7081928"
+	PivxTxidB5T1 = "9d8c7b6a5f4e3d2c1b0a99887766554433221100ffeeddccbbaa998877665549"
+	PivxTxidB5T2 = "e1d2c3b4a5968778695a4b3c2d1e0f00f1e2d3c4b5a69788796a5b4c3d2e1f5a"
+	PivxTxidB6T1 = "47a6b5c4d3e2f1a0b9c8d7e6f5a4b3c2d1e0f9a8b7c6d5e4f3a2b1c0d9e8f76b"
+	PivxTxidB6T2 = "f0e1d2c3b4a5968778695a4b3c2d1e0ff0e1d2c3b4a5968778695a4b3c2d1e7c"
+
+	// PivxScriptA is P2PKH script of the staker of the block 2
+	PivxScriptA = "76a914010d39800f86122416e28f485029acf77507169288ac"
+	// PivxScriptB is P2PKH script of a regular output
+	PivxScriptB = "76a9148bdf0aa3c567aa5975c2e61321b8bebbe7293df688ac"
+	// PivxScriptMN1 and PivxScriptMN2 are P2PKH scripts of the masternodes paid in the blocks 2 and 3
+	PivxScriptMN1 = "76a914a08eae93007f22668ab5e4a9c83c8cd1c325e3e088ac"
+	PivxScriptMN2 = "76a914ccaaaf374e1b06cb83118453d102587b4273d09588ac"
+	// PivxScriptP2CS is the cold staking script of the owner PivxScriptOwner and of the staker PivxAddrDescStaker
+	PivxScriptP2CS     = "76a97b63d2144f3d1f6b3ad5d4f4b4c55c6fa9b09e8e4ae2b6f16714dda91c0396050d660f9c0e38f78064486bbfcb2c6888ac"
+	PivxScriptOwner    = "76a914dda91c0396050d660f9c0e38f78064486bbfcb2c88ac"
+	PivxAddrDescStaker = "d2144f3d1f6b3ad5d4f4b4c55c6fa9b09e8e4ae2b6f1"
+	// PivxScriptZerocoinMint is the script of the zerocoin mint output (OP_ZEROCOINMINT followed by the commitment)
+	PivxScriptZerocoinMint = "c10281004c816f5ce1eeda911203319a256e8560c8dbfd47b569ff32c27559bda78854e63e49718ce4"
+	// PivxScriptZerocoinSpend is the signature script of the zerocoin spend of the denomination 10,
+	// OP_ZEROCOINSPEND followed by the size of the serialized CoinSpend and by the CoinSpend starting with the denomination
+	PivxScriptZerocoinSpend = "c20260000a000000abababababababababababababababababababababababababababababababababababababababababababababababababababababababababababababababababababababababababababababababababababababababababababab"
+)
+
+// PIVX amounts in satoshis
+var (
+	PivxSatB1T1A    = big.NewInt(10000000000)
+	PivxSatB1T2P2CS = big.NewInt(5000000000)
+	PivxSatB1T2B    = big.NewInt(2000000000)
+	PivxSatB2T2A    = big.NewInt(5100000000)
+	PivxSatB2T2MN1  = big.NewInt(200000000)
+	PivxSatB3T2P2CS = big.NewInt(5200000000)
+	PivxSatB3T2MN2  = big.NewInt(300000000)
+	PivxSatB4T2A    = big.NewInt(5300000000)
+	PivxSatB5T1A    = big.NewInt(4300000000)
+	// PivxSatZerocoin is the denomination of the zerocoin minted and spent in the block 5
+	PivxSatZerocoin = big.NewInt(1000000000)
+	PivxSatB6T1A    = big.NewInt(3000000000)
+	PivxSatB6T2B    = big.NewInt(500000000)
+	// PivxSatShielded is the value moved to the shielded pool in the block 6, the value PivxSatB6T2B is moved back from it
+	PivxSatShielded = big.NewInt(1300000000)
+	// PivxSatReward is the staking reward of the coinstake transactions in the test blocks
+	PivxSatReward = big.NewInt(200000000)
+)
+
+func pivxCoinbaseTx(txid string, coinbase string, time int64) bchain.Tx {
+	return bchain.Tx{
+		Txid: txid,
+		Vin: []bchain.Vin{
+			{
+				Coinbase: coinbase,
+			},
+		},
+		// the coinbase of the proof of stake block has one empty output
+		Vout: []bchain.Vout{
+			{
+				N:        0,
+				ValueSat: *big.NewInt(0),
+			},
+		},
+		Blocktime: time,
+		Time:      time,
+	}
+}
+
+// GetTestPivxBlock1 returns block with outputs to the staker and to the cold staking script
+func GetTestPivxBlock1(parser bchain.BlockChainParser) *bchain.Block {
+	return &bchain.Block{
+		BlockHeader: bchain.BlockHeader{
+			Height:        1000,
+			Hash:          "0000000a45d4c3b2a1908f7e6d5c4b3a2918070f6e5d4c3b2a1908f7e6d5c4b1",
+			Size:          1000,
+			Time:          1580000000,
+			Confirmations: 4,
+		},
+		Txs: []bchain.Tx{
+			{
+				Txid: PivxTxidB1T1,
+				Vin:  []bchain.Vin{},
+				Vout: []bchain.Vout{
+					{
+						N: 0,
+						ScriptPubKey: bchain.ScriptPubKey{
+							Hex: PivxScriptA,
+						},
+						ValueSat: *PivxSatB1T1A,
+					},
+				},
+				Blocktime: 1580000000,
+				Time:      1580000000,
+			},
+			{
+				Txid: PivxTxidB1T2,
+				Vin:  []bchain.Vin{},
+				Vout: []bchain.Vout{
+					{
+						N: 0,
+						ScriptPubKey: bchain.ScriptPubKey{
+							Hex: PivxScriptP2CS,
+						},
+						ValueSat: *PivxSatB1T2P2CS,
+					},
+					{
+						N: 1,
+						ScriptPubKey: bchain.ScriptPubKey{
+							Hex: PivxScriptB,
+						},
+						ValueSat: *PivxSatB1T2B,
+					},
+				},
+				Blocktime: 1580000000,
+				Time:      1580000000,
+			},
+		},
+	}
+}
+
+// GetTestPivxBlock2 returns proof of stake block with the coinstake split to two outputs and with masternode payment
+func GetTestPivxBlock2(parser bchain.BlockChainParser) *bchain.Block {
+	return &bchain.Block{
+		BlockHeader: bchain.BlockHeader{
+			Height:        1001,
+			Hash:          "0000000b45d4c3b2a1908f7e6d5c4b3a2918070f6e5d4c3b2a1908f7e6d5c4b2",
+			Size:          2000,
+			Time:          1580000060,
+			Confirmations: 3,
+		},
+		Txs: []bchain.Tx{
+			pivxCoinbaseTx(PivxTxidB2T1, "02e903", 1580000060),
+			{
+				Txid: PivxTxidB2T2,
+				Vin: []bchain.Vin{
+					{
+						Txid: PivxTxidB1T1,
+						Vout: 0,
+					},
+				},
+				Vout: []bchain.Vout{
+					{
+						N:        0,
+						ValueSat: *big.NewInt(0),
+					},
+					{
+						N: 1,
+						ScriptPubKey: bchain.ScriptPubKey{
+							Hex: PivxScriptA,
+						},
+						ValueSat: *PivxSatB2T2A,
+					},
+					{
+						N: 2,
+						ScriptPubKey: bchain.ScriptPubKey{
+							Hex: PivxScriptA,
+						},
+						ValueSat: *PivxSatB2T2A,
+					},
+					{
+						N: 3,
+						ScriptPubKey: bchain.ScriptPubKey{
+							Hex: PivxScriptMN1,
+						},
+						ValueSat: *PivxSatB2T2MN1,
+					},
+				},
+				Blocktime: 1580000060,
+				Time:      1580000060,
+			},
+		},
+	}
+}
+
+// GetTestPivxBlock3 returns proof of stake block with the cold stake and with masternode payment
+func GetTestPivxBlock3(parser bchain.BlockChainParser) *bchain.Block {
+	return &bchain.Block{
+		BlockHeader: bchain.BlockHeader{
+			Height:        1002,
+			Hash:          "0000000c45d4c3b2a1908f7e6d5c4b3a2918070f6e5d4c3b2a1908f7e6d5c4b3",
+			Size:          3000,
+			Time:          1580000120,
+			Confirmations: 2,
+		},
+		Txs: []bchain.Tx{
+			pivxCoinbaseTx(PivxTxidB3T1, "02ea03", 1580000120),
+			{
+				Txid: PivxTxidB3T2,
+				Vin: []bchain.Vin{
+					{
+						Txid: PivxTxidB1T2,
+						Vout: 0,
+					},
+				},
+				Vout: []bchain.Vout{
+					{
+						N:        0,
+						ValueSat: *big.NewInt(0),
+					},
+					{
+						N: 1,
+						ScriptPubKey: bchain.ScriptPubKey{
+							Hex: PivxScriptP2CS,
+						},
+						ValueSat: *PivxSatB3T2P2CS,
+					},
+					{
+						N: 2,
+						ScriptPubKey: bchain.ScriptPubKey{
+							Hex: PivxScriptMN2,
+						},
+						ValueSat: *PivxSatB3T2MN2,
+					},
+				},
+				Blocktime: 1580000120,
+				Time:      1580000120,
+			},
+		},
+	}
+}
+
+// GetTestPivxBlock4 returns proof of stake block with the coinstake without masternode payment
+func GetTestPivxBlock4(parser bchain.BlockChainParser) *bchain.Block {
+	return &bchain.Block{
+		BlockHeader: bchain.BlockHeader{
+			Height:        1003,
+			Hash:          "0000000d45d4c3b2a1908f7e6d5c4b3a2918070f6e5d4c3b2a1908f7e6d5c4b4",
+			Size:          4000,
+			Time:          1580000180,
+			Confirmations: 1,
+		},
+		Txs: []bchain.Tx{
+			pivxCoinbaseTx(PivxTxidB4T1, "02eb03", 1580000180),
+			{
+				Txid: PivxTxidB4T2,
+				Vin: []bchain.Vin{
+					{
+						Txid: PivxTxidB2T2,
+						Vout: 1,
+					},
+				},
+				Vout: []bchain.Vout{
+					{
+						N:        0,
+						ValueSat: *big.NewInt(0),
+					},
+					{
+						N: 1,
+						ScriptPubKey: bchain.ScriptPubKey{
+							Hex: PivxScriptA,
+						},
+						ValueSat: *PivxSatB4T2A,
+					},
+				},
+				Blocktime: 1580000180,
+				Time:      1580000180,
+			},
+		},
+	}
+}
+
+// GetTestPivxBlock5 returns block with zerocoin mint and zerocoin spend
+func GetTestPivxBlock5(parser bchain.BlockChainParser) *bchain.Block {
+	return &bchain.Block{
+		BlockHeader: bchain.BlockHeader{
+			Height:        1004,
+			Hash:          "0000000e45d4c3b2a1908f7e6d5c4b3a2918070f6e5d4c3b2a1908f7e6d5c4b5",
+			Size:          5000,
+			Time:          1580000240,
+			Confirmations: 1,
+		},
+		Txs: []bchain.Tx{
+			{
+				Txid: PivxTxidB5T1,
+				Vin: []bchain.Vin{
+					{
+						Txid: PivxTxidB4T2,
+						Vout: 1,
+					},
+				},
+				Vout: []bchain.Vout{
+					{
+						N: 0,
+						ScriptPubKey: bchain.ScriptPubKey{
+							Hex: PivxScriptZerocoinMint,
+						},
+						ValueSat: *PivxSatZerocoin,
+					},
+					{
+						N: 1,
+						ScriptPubKey: bchain.ScriptPubKey{
+							Hex: PivxScriptA,
+						},
+						ValueSat: *PivxSatB5T1A,
+					},
+				},
+				Blocktime: 1580000240,
+				Time:      1580000240,
+			},
+			{
+				Txid: PivxTxidB5T2,
+				// the zerocoin spend does not spend any output
+				Vin: []bchain.Vin{
+					{
+						Txid: "0000000000000000000000000000000000000000000000000000000000000000",
+						Vout: 4294967295,
+						ScriptSig: bchain.ScriptSig{
+							Hex: PivxScriptZerocoinSpend,
+						},
+					},
+				},
+				Vout: []bchain.Vout{
+					{
+						N: 0,
+						ScriptPubKey: bchain.ScriptPubKey{
+							Hex: PivxScriptB,
+						},
+						ValueSat: *PivxSatZerocoin,
+					},
+				},
+				Blocktime: 1580000240,
+				Time:      1580000240,
+			},
+		},
+	}
+}
+
+// GetTestPivxBlock6 returns block with shielding and unshielding sapling transactions
+func GetTestPivxBlock6(parser bchain.BlockChainParser) *bchain.Block {
+	return &bchain.Block{
+		BlockHeader: bchain.BlockHeader{
+			Height:        1005,
+			Hash:          "0000000f45d4c3b2a1908f7e6d5c4b3a2918070f6e5d4c3b2a1908f7e6d5c4b6",
+			Size:          6000,
+			Time:          1580000300,
+			Confirmations: 1,
+		},
+		Txs: []bchain.Tx{
+			{
+				Txid:    PivxTxidB6T1,
+				Version: pivx.SaplingTxVersion,
+				Vin: []bchain.Vin{
+					{
+						Txid: PivxTxidB5T1,
+						Vout: 1,
+					},
+				},
+				Vout: []bchain.Vout{
+					{
+						N: 0,
+						ScriptPubKey: bchain.ScriptPubKey{
+							Hex: PivxScriptA,
+						},
+						ValueSat: *PivxSatB6T1A,
+					},
+				},
+				// the shielded value is the negative value balance
+				CoinSpecificData: &pivx.PivxTxData{
+					Sapling: &pivx.SaplingTxData{
+						ValueBalance:    -PivxSatShielded.Int64(),
+						ShieldedOutputs: 1,
+					},
+				},
+				Blocktime: 1580000300,
+				Time:      1580000300,
+			},
+			{
+				Txid:    PivxTxidB6T2,
+				Version: pivx.SaplingTxVersion,
+				Vin:     []bchain.Vin{},
+				Vout: []bchain.Vout{
+					{
+						N: 0,
+						ScriptPubKey: bchain.ScriptPubKey{
+							Hex: PivxScriptB,
+						},
+						ValueSat: *PivxSatB6T2B,
+					},
+				},
+				// the value moved from the shielded pool to the transparent output
+				CoinSpecificData: &pivx.PivxTxData{
+					Sapling: &pivx.SaplingTxData{
+						ValueBalance:    PivxSatB6T2B.Int64(),
+						ShieldedSpends:  1,
+						ShieldedOutputs: 1,
+					},
+				},
+				Blocktime: 1580000300,
+				Time:      1580000300,
+			},
+		},
+	}
+}